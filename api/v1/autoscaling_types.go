@@ -0,0 +1,76 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Annotation set by the API on the pools that could not satisfy an acquire
+	// request, with the time of the last rejection
+	AcquireRejectedAnnotation string = "ofcir.openshift/acquire-rejected"
+
+	defaultScaleDownDelay = 30 * time.Minute
+)
+
+// Autoscaling defines how the size of a pool follows the demand
+type Autoscaling struct {
+	// The minimum number of resources of the pool
+	// +kubebuilder:validation:Minimum=0
+	MinSize int `json:"minSize"`
+
+	// The maximum number of resources of the pool
+	// +kubebuilder:validation:Minimum=0
+	MaxSize int `json:"maxSize"`
+
+	// The number of idle available resources to be kept ready for the incoming
+	// requests. The pool grows when they drop below the target
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TargetAvailable int `json:"targetAvailable,omitempty"`
+
+	// How long the pool waits after the last scaling decision before removing the
+	// idle resources exceeding the target. Default is 30m
+	// +optional
+	ScaleDownDelay *metav1.Duration `json:"scaleDownDelay,omitempty"`
+}
+
+// AutoscalingStatus reports the last decision of the autoscaler
+type AutoscalingStatus struct {
+	// The number of resources required by the autoscaler
+	DesiredSize int `json:"desiredSize"`
+
+	// When the desired size was last changed
+	// +optional
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+
+	// The reason of the last change
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// GetScaleDownDelay returns how long the pool waits before removing the idle
+// resources exceeding the target
+func (a Autoscaling) GetScaleDownDelay() time.Duration {
+	if a.ScaleDownDelay == nil || a.ScaleDownDelay.Duration <= 0 {
+		return defaultScaleDownDelay
+	}
+	return a.ScaleDownDelay.Duration
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Annotations set by the API on a CIResource when it gets acquired,
+	// later used to build the lease record once it is released
+	LeaseTokenAnnotation    string = "ofcir.openshift/lease-token"
+	LeaseJobAnnotation      string = "ofcir.openshift/lease-job"
+	LeaseAcquiredAnnotation string = "ofcir.openshift/lease-acquired"
+
+	// Identifies the pool an object belongs to
+	PoolLabel string = "ofcir.openshift/pool"
+)
+
+// CILeaseSpec records a single usage of a CIResource, from its acquisition
+// up to its release
+type CILeaseSpec struct {
+	// Name of the leased CIResource
+	CIResource string `json:"ciResource"`
+
+	// Reference to the CIPool that was managing the leased CIResource
+	PoolRef corev1.LocalObjectReference `json:"poolRef"`
+
+	// The provider used by the pool at the time of the lease
+	Provider string `json:"provider"`
+
+	// The type of the leased resource
+	Type CIResourceType `json:"type"`
+
+	// The unique identifier of the leased resource
+	// +optional
+	ResourceId string `json:"resourceId,omitempty"`
+
+	// Fingerprint of the token used to acquire the resource
+	// +optional
+	TokenFingerprint string `json:"tokenFingerprint,omitempty"`
+
+	// Free-form information about the job that acquired the resource
+	// +optional
+	Job string `json:"job,omitempty"`
+
+	// When the resource was acquired
+	AcquiredAt metav1.Time `json:"acquiredAt"`
+
+	// When the resource was released
+	ReleasedAt metav1.Time `json:"releasedAt"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:object:generate=true
+//+kubebuilder:resource:shortName=cil
+//+kubebuilder:printcolumn:name="CIR",type="string",JSONPath=".spec.ciResource",description="The leased resource"
+//+kubebuilder:printcolumn:name="Pool",type="string",JSONPath=".spec.poolRef.name",description="Pool owning the leased resource"
+//+kubebuilder:printcolumn:name="Token",type="string",JSONPath=".spec.tokenFingerprint",description="Fingerprint of the token used for the lease"
+//+kubebuilder:printcolumn:name="Acquired",type="date",JSONPath=".spec.acquiredAt",description="Acquisition time"
+//+kubebuilder:printcolumn:name="Released",type="date",JSONPath=".spec.releasedAt",description="Release time"
+
+// CILease is the usage record of a CIResource, created when it leaves the in use state
+type CILease struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CILeaseSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:object:generate=true
+
+// CILeaseList contains a list of CILease
+type CILeaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CILease `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CILease{}, &CILeaseList{})
+}
+
+// InUseWithin returns how long the lease was in use within the given period
+func (l CILease) InUseWithin(from time.Time, to time.Time) time.Duration {
+	start := l.Spec.AcquiredAt.Time
+	if start.Before(from) {
+		start = from
+	}
+	end := l.Spec.ReleasedAt.Time
+	if end.After(to) {
+		end = to
+	}
+
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
@@ -17,7 +17,13 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // CIPoolState defines the states for the CIPool
@@ -27,6 +33,17 @@ func (c CIPoolState) String() string {
 	return string(c)
 }
 
+const (
+	// Default number of consecutive failures tolerated for a resource
+	DefaultMaxFailures = 5
+
+	// Default number of consecutive reprovisions allowed for a resource
+	DefaultMaxReprovisions = 3
+
+	// Default time a resource is allowed to remain in use
+	DefaultPoolTimeout = 4 * time.Hour
+)
+
 const (
 	// Indicates that the pool is active and can be selected when
 	// looking for an eligible resource
@@ -35,6 +52,47 @@ const (
 	// Indicates that the pool is not active and cannot be selected when
 	// looking for an eligible resource
 	StatePoolOffline CIPoolState = "offline"
+
+	// Indicates that the pool cannot be selected when looking for an eligible
+	// resource, but its resources are still managed until all of them are
+	// released. Then the pool moves to the offline state
+	StatePoolDraining CIPoolState = "draining"
+)
+
+const (
+	// The pool secret field holding the provider configuration, in JSON
+	ProviderConfigSecretKey = "config"
+
+	// Reports whether the pool secret exists and its provider configuration
+	// can be parsed
+	PoolConditionSecretReady = "SecretReady"
+
+	// Reasons of the SecretReady condition
+	ReasonSecretFound    = "SecretFound"
+	ReasonSecretNotFound = "SecretNotFound"
+	ReasonInvalidConfig  = "InvalidConfig"
+
+	// Reports whether the pool failed to create the resources required to
+	// reach its size
+	PoolConditionSizeUnreachable = "SizeUnreachable"
+
+	// Reasons of the SizeUnreachable condition
+	ReasonSizeReachable  = "SizeReachable"
+	ReasonCreationFailed = "CreationFailed"
+)
+
+// EvictionStrategy defines the order used for selecting the resources removed
+// when a pool is shrunk
+// +kubebuilder:validation:Enum=newest;oldest;least-healthy
+type EvictionStrategy string
+
+const (
+	// The most recently created resources are removed first
+	EvictNewest EvictionStrategy = "newest"
+	// The least recently created resources are removed first
+	EvictOldest EvictionStrategy = "oldest"
+	// The resources with the most failures are removed first
+	EvictLeastHealthy EvictionStrategy = "least-healthy"
 )
 
 // CIPoolSpec defines the desired state of CIPool
@@ -52,6 +110,17 @@ type CIPoolSpec struct {
 	// +optional
 	ProviderInfo string `json:"providerInfo,omitempty"`
 
+	// Reference to the secret holding the provider configuration (in the
+	// `config` field) and credentials. Default is <pool name>-secret
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// Provider specific settings, overriding the ones found in the `config`
+	// field of the pool secret. Credentials should be kept in the secret
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	ProviderConfig *runtime.RawExtension `json:"providerConfig,omitempty"`
+
 	// Specify how long a CIR instance will be allowed to remain in the inuse state
 	Timeout metav1.Duration `json:"timeout"`
 
@@ -60,6 +129,82 @@ type CIPoolSpec struct {
 
 	// The type of the resources managed by the pool
 	Type CIResourceType `json:"type"`
+
+	// How many consecutive failures are tolerated while provisioning or cleaning
+	// a resource, before moving it to the error state. Default is 5
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxFailures int `json:"maxFailures,omitempty"`
+
+	// How long a resource is allowed to wait for being provisioned. Once expired,
+	// the resource is released and provisioned again. No deadline if not set
+	// +optional
+	ProvisioningTimeout *metav1.Duration `json:"provisioningTimeout,omitempty"`
+
+	// How long a resource is allowed to wait for being cleaned. Once expired,
+	// the resource is released and provisioned again. No deadline if not set
+	// +optional
+	CleaningTimeout *metav1.Duration `json:"cleaningTimeout,omitempty"`
+
+	// How many consecutive times a resource can be provisioned again after a
+	// deadline expiration, before moving it to the error state. Default is 3
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxReprovisions int `json:"maxReprovisions,omitempty"`
+
+	// How many resources of the pool can be provisioned at the same time. The
+	// other ones wait in the provisioning state until a slot is freed. No limit
+	// if not set
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConcurrentProvisioning int `json:"maxConcurrentProvisioning,omitempty"`
+
+	// How many resources of the pool can be cleaned at the same time. The other
+	// ones wait in the cleaning state until a slot is freed. No limit if not set
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConcurrentCleaning int `json:"maxConcurrentCleaning,omitempty"`
+
+	// Probe periodically run against the available resources. Resources failing
+	// it are taken out of rotation. No probe if not set
+	// +optional
+	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
+
+	// Checks run once the provider reported a resource as provisioned or
+	// cleaned. The resource is made available only after all of them succeeded.
+	// The time spent waiting is bounded by provisioningTimeout and cleaningTimeout
+	// +optional
+	ReadinessChecks []Probe `json:"readinessChecks,omitempty"`
+
+	// Custom logic run at some points of the resources lifecycle
+	// +optional
+	Hooks []Hook `json:"hooks,omitempty"`
+
+	// Script run over SSH for cleaning the released resources, authenticating
+	// with the ssh-privatekey field of the pool secret. Not used by fallback pools
+	// +optional
+	CleaningScript *CleaningScript `json:"cleaningScript,omitempty"`
+
+	// The order used for selecting the resources to be removed when the pool is
+	// shrunk. The idle resources are always removed before the ones in use.
+	// Default is newest
+	// +optional
+	EvictionStrategy EvictionStrategy `json:"evictionStrategy,omitempty"`
+
+	// Lets the pool size follow the demand, within the given bounds. When set,
+	// size is ignored
+	// +optional
+	Autoscaling *Autoscaling `json:"autoscaling,omitempty"`
+
+	// Recurring time windows overriding size. When more than one is active, the
+	// first one listed wins. Ignored when autoscaling is set
+	// +optional
+	Schedules []SizeSchedule `json:"schedules,omitempty"`
+
+	// Tracks the spend of the pool resources, and optionally caps it with a
+	// monthly budget
+	// +optional
+	Cost *Cost `json:"cost,omitempty"`
 }
 
 // CIPoolStatus defines the observed state of CIPool
@@ -70,6 +215,49 @@ type CIPoolStatus struct {
 	// Current number of instances maintained by the current pool
 	Size int `json:"size"`
 
+	// Number of resources still in use, reported while the pool is draining
+	// +optional
+	InUse int `json:"inUse,omitempty"`
+
+	// Number of resources selected for removal while in use. They will be
+	// deleted, instead of cleaned, once released
+	// +optional
+	PendingEvictions int `json:"pendingEvictions,omitempty"`
+
+	// Number of resources waiting for a provisioning slot, when
+	// maxConcurrentProvisioning is set
+	// +optional
+	QueuedProvisioning int `json:"queuedProvisioning,omitempty"`
+
+	// Number of resources waiting for a cleaning slot, when
+	// maxConcurrentCleaning is set
+	// +optional
+	QueuedCleaning int `json:"queuedCleaning,omitempty"`
+
+	// The last decision of the autoscaler, if enabled
+	// +optional
+	Autoscaling *AutoscalingStatus `json:"autoscaling,omitempty"`
+
+	// The number of resources the pool is required to have, according to size,
+	// the active schedule or the autoscaler
+	// +optional
+	EffectiveSize int `json:"effectiveSize,omitempty"`
+
+	// The name of the schedule currently overriding size, if any
+	// +optional
+	ActiveSchedule string `json:"activeSchedule,omitempty"`
+
+	// The spend of the pool resources in the current month, reported when their
+	// price is known
+	// +optional
+	Cost *CostStatus `json:"cost,omitempty"`
+
+	// The latest available observations of the pool
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
 	// LastUpdated identifies when this status was last observed.
 	// +optional
 	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
@@ -112,3 +300,70 @@ func init() {
 func (c CIPool) IsFallbackPool() bool {
 	return c.Spec.Priority == -1
 }
+
+// IsSelectable returns true if the pool resources can be acquired
+func (c CIPool) IsSelectable() bool {
+	return c.Status.State != StatePoolOffline && c.Status.State != StatePoolDraining
+}
+
+// GetSecretName returns the name of the secret holding the pool provider
+// configuration
+func (c CIPool) GetSecretName() string {
+	if c.Spec.SecretRef == nil || c.Spec.SecretRef.Name == "" {
+		return fmt.Sprintf("%s-secret", c.Name)
+	}
+	return c.Spec.SecretRef.Name
+}
+
+// GetMaxFailures returns the number of consecutive failures tolerated
+// for a resource of the pool
+func (c CIPool) GetMaxFailures() int {
+	if c.Spec.MaxFailures <= 0 {
+		return DefaultMaxFailures
+	}
+	return c.Spec.MaxFailures
+}
+
+// GetEvictionStrategy returns the order used for selecting the resources to
+// be removed when the pool is shrunk
+func (c CIPool) GetEvictionStrategy() EvictionStrategy {
+	if c.Spec.EvictionStrategy == "" {
+		return EvictNewest
+	}
+	return c.Spec.EvictionStrategy
+}
+
+// GetMaxReprovisions returns how many consecutive times a resource of the
+// pool can be provisioned again after a deadline expiration
+func (c CIPool) GetMaxReprovisions() int {
+	if c.Spec.MaxReprovisions <= 0 {
+		return DefaultMaxReprovisions
+	}
+	return c.Spec.MaxReprovisions
+}
+
+// GetHourlyPrice returns the hourly price of a resource of the pool, when not
+// reported by the provider
+func (c CIPool) GetHourlyPrice() float64 {
+	if c.Spec.Cost == nil {
+		return 0
+	}
+	price, _ := ParseAmount(c.Spec.Cost.HourlyPrice)
+	return price
+}
+
+// GetMonthlyBudget returns the maximum monthly spend of the pool. False if
+// the pool has no budget
+func (c CIPool) GetMonthlyBudget() (float64, bool) {
+	if c.Spec.Cost == nil || c.Spec.Cost.MonthlyBudget == "" {
+		return 0, false
+	}
+	budget, err := ParseAmount(c.Spec.Cost.MonthlyBudget)
+	return budget, err == nil
+}
+
+// IsOverBudget returns true if no new resource can be provisioned, since the
+// pool spend reached its monthly budget
+func (c CIPool) IsOverBudget() bool {
+	return meta.IsStatusConditionTrue(c.Status.Conditions, PoolConditionBudgetExceeded)
+}
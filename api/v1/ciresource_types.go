@@ -17,6 +17,8 @@ limitations under the License.
 package v1
 
 import (
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -58,11 +60,16 @@ const (
 	// StateDelete manages the removal of the resource
 	StateDelete CIResourceState = "delete"
 
-	// StateError is a terminal state in case something wrong happens during
-	// the provisioning or the cleaning state
+	// StateError is reached when the provisioning or the cleaning of the
+	// instance keeps failing. The resource can be moved out of it by setting
+	// the required state to available (retry the failed step), provisioning
+	// (release the instance and provision a new one) or delete
 	StateError CIResourceState = "error"
 )
 
+// FallbackResourceID is the dummy ID of a fallback resource not yet provisioned
+const FallbackResourceID = "000-fallback-dummy-000"
+
 const (
 	EvictionLabel string = "ofcir/eviction"
 
@@ -111,9 +118,60 @@ type CIResourceStatus struct {
 	// specific resource type used
 	Extra string `json:"extra,omitempty"`
 
+	// The hourly price of the resource, as reported by the provider
+	// +optional
+	HourlyPrice string `json:"hourlyPrice,omitempty"`
+
 	// Current state of the resource
 	State CIResourceState `json:"state"`
 
+	// When the resource entered the current state
+	// +optional
+	StateChanged *metav1.Time `json:"stateChanged,omitempty"`
+
+	// Number of consecutive failures in the current state
+	// +optional
+	Failures int `json:"failures,omitempty"`
+
+	// The reason of the last failure
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// The state where the resource was before moving to the error state
+	// +optional
+	FailedState CIResourceState `json:"failedState,omitempty"`
+
+	// Set when the last acquisition was refused by the pre-acquire hooks, or by
+	// the pool budget for a fallback resource, until the resource changes state again
+	// +optional
+	AcquireFailed bool `json:"acquireFailed,omitempty"`
+
+	// Number of consecutive times the resource was provisioned again, after
+	// a provisioning or cleaning deadline expiration
+	// +optional
+	Reprovisions int `json:"reprovisions,omitempty"`
+
+	// The reason of the last reprovisioning
+	// +optional
+	ReprovisionReason string `json:"reprovisionReason,omitempty"`
+
+	// When the resource details were last refreshed from the provider
+	// +optional
+	ProviderSynced *metav1.Time `json:"providerSynced,omitempty"`
+
+	// The result of the last health check probe
+	// +optional
+	HealthCheck *ProbeStatus `json:"healthCheck,omitempty"`
+
+	// The result of the last readiness checks, run once the provider reported
+	// the resource as provisioned or cleaned
+	// +optional
+	Readiness *ProbeStatus `json:"readiness,omitempty"`
+
+	// The result of the last cleaning script run
+	// +optional
+	CleaningScript *CleaningScriptStatus `json:"cleaningScript,omitempty"`
+
 	// LastUpdated identifies when this status was last observed.
 	// +optional
 	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
@@ -139,6 +197,18 @@ type CIResource struct {
 	Status CIResourceStatus `json:"status,omitempty"`
 }
 
+// StateSince returns when the resource entered its current state, or when its
+// status was last updated if the state change was not recorded
+func (c CIResource) StateSince() time.Time {
+	if c.Status.StateChanged != nil {
+		return c.Status.StateChanged.Time
+	}
+	if c.Status.LastUpdated != nil {
+		return c.Status.LastUpdated.Time
+	}
+	return time.Time{}
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:object:generate=true
 // CIResourceList contains a list of CIResource
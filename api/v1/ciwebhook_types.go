@@ -0,0 +1,97 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CIWebhookEventType identifies an event notified to the webhooks
+// +kubebuilder:validation:Enum=ResourceError;ResourceTimeout;PoolSizeUnreachable
+type CIWebhookEventType string
+
+const (
+	// EventResourceError is sent when a CIResource enters the error state
+	EventResourceError CIWebhookEventType = "ResourceError"
+	// EventResourceTimeout is sent when a CIResource is force-released after
+	// having been in use for longer than the pool timeout
+	EventResourceTimeout CIWebhookEventType = "ResourceTimeout"
+	// EventPoolSizeUnreachable is sent when a pool fails to create the
+	// resources required to reach its desired size
+	EventPoolSizeUnreachable CIWebhookEventType = "PoolSizeUnreachable"
+
+	// Key of the webhook secret containing the HMAC signing key
+	WebhookSecretKey string = "secret"
+)
+
+// CIWebhookSpec defines where and which events must be notified
+type CIWebhookSpec struct {
+	// The URL where the events are POSTed to
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// The events to be notified, all of them if empty
+	// +optional
+	Events []CIWebhookEventType `json:"events,omitempty"`
+
+	// Reference to a secret containing the key used to sign the payloads
+	// (in the `secret` field). If not specified the payloads are not signed
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:object:generate=true
+//+kubebuilder:resource:shortName=ciw
+//+kubebuilder:printcolumn:name="URL",type="string",JSONPath=".spec.url",description="Notification endpoint"
+//+kubebuilder:printcolumn:name="Events",type="string",JSONPath=".spec.events",description="Notified events"
+
+// CIWebhook is a subscription for the notifications sent by the controller
+type CIWebhook struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CIWebhookSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:object:generate=true
+
+// CIWebhookList contains a list of CIWebhook
+type CIWebhookList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CIWebhook `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CIWebhook{}, &CIWebhookList{})
+}
+
+// Accepts returns true if the webhook is subscribed to the given event
+func (w CIWebhook) Accepts(event CIWebhookEventType) bool {
+	if len(w.Spec.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Spec.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CleaningScriptMode defines how the cleaning script is combined with the
+// provider cleaning
+// +kubebuilder:validation:Enum=after;replace
+type CleaningScriptMode string
+
+const (
+	// The script is run once the provider cleaning completed
+	CleaningScriptAfter CleaningScriptMode = "after"
+	// The script is run instead of the provider cleaning
+	CleaningScriptReplace CleaningScriptMode = "replace"
+)
+
+const (
+	defaultCleaningScriptTimeout = 10 * time.Minute
+
+	// Max size of the script output stored in the resource status
+	MaxCleaningScriptOutput = 4096
+)
+
+// CleaningScript defines a script run over SSH for cleaning a released resource
+type CleaningScript struct {
+	// The script content, run by the remote user shell
+	Script string `json:"script"`
+
+	// Whether the script is run after or instead of the provider cleaning. Default is after
+	// +optional
+	Mode CleaningScriptMode `json:"mode,omitempty"`
+
+	// The user the script is run as. Default is root
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// The SSH port. Default is 22
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int `json:"port,omitempty"`
+
+	// How long the script can last. Default is 10m
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// CleaningScriptStatus reports the result of the last cleaning script run
+type CleaningScriptStatus struct {
+	// When the script completed
+	Time metav1.Time `json:"time"`
+
+	// Whether the script exited successfully
+	Succeeded bool `json:"succeeded"`
+
+	// The reason of the script failure
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// The tail of the script combined output
+	// +optional
+	Output string `json:"output,omitempty"`
+}
+
+// GetMode returns how the script is combined with the provider cleaning
+func (s CleaningScript) GetMode() CleaningScriptMode {
+	if s.Mode == "" {
+		return CleaningScriptAfter
+	}
+	return s.Mode
+}
+
+// GetUser returns the user the script is run as
+func (s CleaningScript) GetUser() string {
+	if s.User == "" {
+		return "root"
+	}
+	return s.User
+}
+
+// GetPort returns the SSH port
+func (s CleaningScript) GetPort() int {
+	if s.Port > 0 {
+		return s.Port
+	}
+	return 22
+}
+
+// GetTimeout returns how long the script can last
+func (s CleaningScript) GetTimeout() time.Duration {
+	if s.Timeout == nil || s.Timeout.Duration <= 0 {
+		return defaultCleaningScriptTimeout
+	}
+	return s.Timeout.Duration
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Reports whether the pool spend of the current month reached its budget.
+	// Set only when a monthly budget is configured
+	PoolConditionBudgetExceeded = "BudgetExceeded"
+
+	// Reasons of the BudgetExceeded condition
+	ReasonWithinBudget   = "WithinBudget"
+	ReasonBudgetExceeded = "BudgetExceeded"
+)
+
+// Cost defines how the spend of the pool resources is tracked and capped. The
+// amounts are decimal numbers (for example "0.85"), in the currency used by
+// the provider
+type Cost struct {
+	// The hourly price of a resource, used when the provider does not report it
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	// +optional
+	HourlyPrice string `json:"hourlyPrice,omitempty"`
+
+	// The maximum spend of the pool in a calendar month (UTC). Once reached, no
+	// new resource is provisioned until the next month. No limit if not set
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	// +optional
+	MonthlyBudget string `json:"monthlyBudget,omitempty"`
+}
+
+// CostStatus reports the spend of the pool resources in the current month
+type CostStatus struct {
+	// The month the spend refers to, as YYYY-MM (UTC)
+	Month string `json:"month"`
+
+	// The spend accumulated in the month
+	Spent string `json:"spent"`
+
+	// The hourly price of the resources currently held by the pool
+	HourlyRate string `json:"hourlyRate"`
+
+	// When the spend was last updated
+	LastAccounted metav1.Time `json:"lastAccounted"`
+}
+
+// ParseAmount parses a price or a spend amount
+func ParseAmount(amount string) (float64, error) {
+	if amount == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(amount, 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, fmt.Errorf("invalid amount %q, must be a non-negative decimal number", amount)
+	}
+	return v, nil
+}
+
+// FormatAmount formats a price or a spend amount, rounded to four decimals
+func FormatAmount(amount float64) string {
+	return strconv.FormatFloat(math.Round(amount*1e4)/1e4, 'f', -1, 64)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProbeType defines how a resource is probed
+// +kubebuilder:validation:Enum=tcp;http;ssh;cloud-init
+type ProbeType string
+
+// HealthCheckAction defines what happens to a resource failing its health check
+// +kubebuilder:validation:Enum=clean;reprovision
+type HealthCheckAction string
+
+const (
+	// Opens a TCP connection to the resource port
+	ProbeTCP ProbeType = "tcp"
+	// Expects a 2xx response to a GET request
+	ProbeHTTP ProbeType = "http"
+	// Expects a zero exit status from a command run over SSH
+	ProbeSSH ProbeType = "ssh"
+	// Expects the cloud-init boot-finished marker file, checked over SSH
+	ProbeCloudInit ProbeType = "cloud-init"
+
+	// The resource is cleaned before being available again
+	HealthCheckActionClean HealthCheckAction = "clean"
+	// The resource is released and a new one is provisioned
+	HealthCheckActionReprovision HealthCheckAction = "reprovision"
+)
+
+const (
+	// The pool secret field holding the private key used by the ssh probes
+	SSHPrivateKeySecretKey = "ssh-privatekey"
+
+	defaultHealthCheckInterval = 5 * time.Minute
+	defaultProbeTimeout        = 10 * time.Second
+	// The probes are run by the reconcile, so they are kept short
+	maxProbeTimeout = 30 * time.Second
+)
+
+// Probe defines a check run against a resource
+type Probe struct {
+	// The kind of probe
+	Type ProbeType `json:"type"`
+
+	// The port to probe. Default is 22 for tcp, ssh and cloud-init probes, 80 for http probes
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int `json:"port,omitempty"`
+
+	// The path requested by the http probe. Default is /
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// The user for the ssh and cloud-init probes. Default is root
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// The command run by the ssh probe. Default is `true`
+	// +optional
+	Command string `json:"command,omitempty"`
+
+	// How long a single probe can last. Default is 10s, at most 30s
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// HealthCheck defines the probe periodically run against the available
+// resources of a pool
+type HealthCheck struct {
+	Probe `json:",inline"`
+
+	// How often the probe is run. Default is 5m
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Number of consecutive failed probes before taking the resource out of
+	// rotation. Default is 1
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+
+	// What to do with a resource failing the probe. Default is reprovision
+	// +optional
+	Action HealthCheckAction `json:"action,omitempty"`
+}
+
+// ProbeStatus reports the result of the last probe run against a resource
+type ProbeStatus struct {
+	// When the probe was run
+	Time metav1.Time `json:"time"`
+
+	// Whether the probe succeeded
+	Healthy bool `json:"healthy"`
+
+	// The reason of the probe failure
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Number of consecutive failed probes
+	// +optional
+	Failures int `json:"failures,omitempty"`
+}
+
+// GetPort returns the port to probe
+func (p Probe) GetPort() int {
+	if p.Port > 0 {
+		return p.Port
+	}
+	if p.Type == ProbeHTTP {
+		return 80
+	}
+	return 22
+}
+
+// GetTimeout returns how long a single probe can last
+func (p Probe) GetTimeout() time.Duration {
+	if p.Timeout == nil || p.Timeout.Duration <= 0 {
+		return defaultProbeTimeout
+	}
+	return min(p.Timeout.Duration, maxProbeTimeout)
+}
+
+// GetInterval returns how often the probe is run
+func (h HealthCheck) GetInterval() time.Duration {
+	if h.Interval == nil || h.Interval.Duration <= 0 {
+		return defaultHealthCheckInterval
+	}
+	return h.Interval.Duration
+}
+
+// GetFailureThreshold returns the number of consecutive failed probes
+// tolerated before taking the resource out of rotation
+func (h HealthCheck) GetFailureThreshold() int {
+	if h.FailureThreshold <= 0 {
+		return 1
+	}
+	return h.FailureThreshold
+}
+
+// GetAction returns what to do with a resource failing the probe
+func (h HealthCheck) GetAction() HealthCheckAction {
+	if h.Action == "" {
+		return HealthCheckActionReprovision
+	}
+	return h.Action
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HookPoint identifies a point of the resource lifecycle where hooks are run
+// +kubebuilder:validation:Enum=post-provision;pre-acquire;post-release
+type HookPoint string
+
+const (
+	// After the provisioning of a resource completed, before making it available
+	HookPostProvision HookPoint = "post-provision"
+	// Before handing a resource to a job. A failure moves the resource to maintenance
+	HookPreAcquire HookPoint = "pre-acquire"
+	// After a job released a resource, before cleaning it
+	HookPostRelease HookPoint = "post-release"
+)
+
+const (
+	defaultHTTPHookTimeout = 10 * time.Second
+	// The hooks are run by the reconcile, so the calls are kept short
+	maxHTTPHookTimeout = 30 * time.Second
+)
+
+// Hook defines custom logic run at some points of the resource lifecycle.
+// Exactly one of builtin and http must be set
+type Hook struct {
+	// Identifies the hook in the logs and errors
+	Name string `json:"name"`
+
+	// The lifecycle points where the hook is run
+	// +kubebuilder:validation:MinItems=1
+	Points []HookPoint `json:"points"`
+
+	// The name of a hook registered in the operator
+	// +optional
+	Builtin string `json:"builtin,omitempty"`
+
+	// An external endpoint called for running the hook
+	// +optional
+	HTTP *HTTPHook `json:"http,omitempty"`
+}
+
+// HTTPHook POSTs the hook event to an external endpoint, expecting a 2xx response
+type HTTPHook struct {
+	// The endpoint URL
+	URL string `json:"url"`
+
+	// How long the call can last. Default is 10s, at most 30s
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// RunsAt returns true if the hook must be run at the given point
+func (h Hook) RunsAt(point HookPoint) bool {
+	for _, p := range h.Points {
+		if p == point {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTimeout returns how long the call can last
+func (h HTTPHook) GetTimeout() time.Duration {
+	if h.Timeout == nil || h.Timeout.Duration <= 0 {
+		return defaultHTTPHookTimeout
+	}
+	return min(h.Timeout.Duration, maxHTTPHookTimeout)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SizeSchedule overrides the pool size during recurring time windows
+type SizeSchedule struct {
+	// Identifies the schedule in the pool status
+	Name string `json:"name"`
+
+	// Cron expression (minute, hour, day of month, month, day of week) of the
+	// times when the schedule becomes active
+	Start string `json:"start"`
+
+	// How long the schedule stays active after every start
+	Duration metav1.Duration `json:"duration"`
+
+	// The IANA name of the timezone used for evaluating start. Default is UTC
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+
+	// The size of the pool while the schedule is active
+	// +kubebuilder:validation:Minimum=0
+	Size int `json:"size"`
+}
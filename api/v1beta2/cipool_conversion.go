@@ -0,0 +1,244 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+const (
+	// Preserves the v1 providerInfo field, not available in v1beta2
+	ProviderInfoAnnotation = "ofcir.openshift/v1-provider-info"
+
+	// Preserves a v1 providerConfig that cannot be represented by the typed
+	// provider settings (for example, because it contains credentials)
+	ProviderConfigAnnotation = "ofcir.openshift/v1-provider-config"
+)
+
+// The provider settings, as found in the `config` field of the v1 pool secret.
+// They must have the same fields of the typed settings, for being converted
+type libvirtConfigJSON struct {
+	Pool         string `json:"pool,omitempty"`
+	Volume       uint64 `json:"volume,omitempty"`
+	BackingStore string `json:"backing_store,omitempty"`
+	Memory       uint   `json:"memory,omitempty"`
+	Cpus         uint   `json:"cpus,omitempty"`
+	Bridge       string `json:"bridge,omitempty"`
+	Ignition     string `json:"ignition,omitempty"`
+}
+
+type ironicConfigJSON struct {
+	Endpoint string `json:"endpoint,omitempty"`
+	OSCloud  string `json:"oscloud,omitempty"`
+	Image    string `json:"image,omitempty"`
+	SSHKey   string `json:"sshkey,omitempty"`
+}
+
+type equinixConfigJSON struct {
+	ProjectID string   `json:"projectid,omitempty"`
+	Metros    []string `json:"metros,omitempty"`
+	Plan      string   `json:"plan,omitempty"`
+	OS        string   `json:"os,omitempty"`
+	UserData  string   `json:"userdata,omitempty"`
+}
+
+type ibmcloudConfigJSON struct {
+	SSHKey string `json:"sshkey,omitempty"`
+	Preset string `json:"preset,omitempty"`
+	OS     string `json:"os,omitempty"`
+	Script string `json:"script,omitempty"`
+}
+
+// ConvertTo converts this CIPool to the v1 storage version
+func (src *CIPool) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*ofcirv1.CIPool)
+	in := src.DeepCopy()
+
+	dst.ObjectMeta = in.ObjectMeta
+	dst.Status = in.Status
+	dst.Spec = ofcirv1.CIPoolSpec{
+		Provider:                  string(in.Spec.Provider.Type),
+		SecretRef:                 in.Spec.Provider.SecretRef,
+		Priority:                  in.Spec.Priority,
+		Size:                      in.Spec.Size,
+		Timeout:                   in.Spec.Timeout,
+		State:                     in.Spec.State,
+		Type:                      in.Spec.Type,
+		MaxFailures:               in.Spec.MaxFailures,
+		ProvisioningTimeout:       in.Spec.ProvisioningTimeout,
+		CleaningTimeout:           in.Spec.CleaningTimeout,
+		MaxReprovisions:           in.Spec.MaxReprovisions,
+		MaxConcurrentProvisioning: in.Spec.MaxConcurrentProvisioning,
+		MaxConcurrentCleaning:     in.Spec.MaxConcurrentCleaning,
+		HealthCheck:               in.Spec.HealthCheck,
+		ReadinessChecks:           in.Spec.ReadinessChecks,
+		Hooks:                     in.Spec.Hooks,
+		CleaningScript:            in.Spec.CleaningScript,
+		EvictionStrategy:          in.Spec.EvictionStrategy,
+		Autoscaling:               in.Spec.Autoscaling,
+		Schedules:                 in.Spec.Schedules,
+		Cost:                      in.Spec.Cost,
+	}
+
+	config, err := providerConfigJSON(in.Spec.Provider)
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		if raw, ok := dst.Annotations[ProviderConfigAnnotation]; ok {
+			config = []byte(raw)
+		}
+	}
+	if config != nil {
+		dst.Spec.ProviderConfig = &runtime.RawExtension{Raw: config}
+	}
+	dst.Spec.ProviderInfo = dst.Annotations[ProviderInfoAnnotation]
+
+	delete(dst.Annotations, ProviderConfigAnnotation)
+	delete(dst.Annotations, ProviderInfoAnnotation)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+	return nil
+}
+
+// ConvertFrom converts from the v1 storage version to this version
+func (dst *CIPool) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*ofcirv1.CIPool)
+	in := src.DeepCopy()
+
+	dst.ObjectMeta = in.ObjectMeta
+	dst.Status = in.Status
+	dst.Spec = CIPoolSpec{
+		Provider: ProviderSpec{
+			Type:      ProviderType(in.Spec.Provider),
+			SecretRef: in.Spec.SecretRef,
+		},
+		Priority:                  in.Spec.Priority,
+		Size:                      in.Spec.Size,
+		Timeout:                   in.Spec.Timeout,
+		State:                     in.Spec.State,
+		Type:                      in.Spec.Type,
+		MaxFailures:               in.Spec.MaxFailures,
+		ProvisioningTimeout:       in.Spec.ProvisioningTimeout,
+		CleaningTimeout:           in.Spec.CleaningTimeout,
+		MaxReprovisions:           in.Spec.MaxReprovisions,
+		MaxConcurrentProvisioning: in.Spec.MaxConcurrentProvisioning,
+		MaxConcurrentCleaning:     in.Spec.MaxConcurrentCleaning,
+		HealthCheck:               in.Spec.HealthCheck,
+		ReadinessChecks:           in.Spec.ReadinessChecks,
+		Hooks:                     in.Spec.Hooks,
+		CleaningScript:            in.Spec.CleaningScript,
+		EvictionStrategy:          in.Spec.EvictionStrategy,
+		Autoscaling:               in.Spec.Autoscaling,
+		Schedules:                 in.Spec.Schedules,
+		Cost:                      in.Spec.Cost,
+	}
+
+	if in.Spec.ProviderInfo != "" {
+		setAnnotation(dst, ProviderInfoAnnotation, in.Spec.ProviderInfo)
+	}
+	if in.Spec.ProviderConfig != nil && len(in.Spec.ProviderConfig.Raw) > 0 {
+		// Settings that cannot be typed are kept as they are
+		if err := setProviderSettings(&dst.Spec.Provider, in.Spec.ProviderConfig.Raw); err != nil {
+			setAnnotation(dst, ProviderConfigAnnotation, string(in.Spec.ProviderConfig.Raw))
+		}
+	}
+	return nil
+}
+
+// providerConfigJSON returns the settings of the selected provider, in the v1 format
+func providerConfigJSON(p ProviderSpec) ([]byte, error) {
+	var config interface{}
+	switch {
+	case p.Type == ProviderLibvirt && p.Libvirt != nil:
+		config = libvirtConfigJSON(*p.Libvirt)
+	case p.Type == ProviderIronic && p.Ironic != nil:
+		config = ironicConfigJSON(*p.Ironic)
+	case p.Type == ProviderEquinix && p.Equinix != nil:
+		config = equinixConfigJSON(*p.Equinix)
+	case p.Type == ProviderIbmcloud && p.Ibmcloud != nil:
+		config = ibmcloudConfigJSON(*p.Ibmcloud)
+	case p.Type == ProviderAWS && p.AWS != nil:
+		// The aws settings share the v1 format
+		config = p.AWS
+	default:
+		return nil, nil
+	}
+	return json.Marshal(config)
+}
+
+// setProviderSettings fills the typed settings of the selected provider from
+// the v1 format. Unknown fields, like the credentials, are not accepted
+func setProviderSettings(p *ProviderSpec, config []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(config))
+	decoder.DisallowUnknownFields()
+
+	switch p.Type {
+	case ProviderLibvirt:
+		settings := libvirtConfigJSON{}
+		if err := decoder.Decode(&settings); err != nil {
+			return err
+		}
+		libvirt := LibvirtConfig(settings)
+		p.Libvirt = &libvirt
+	case ProviderIronic:
+		settings := ironicConfigJSON{}
+		if err := decoder.Decode(&settings); err != nil {
+			return err
+		}
+		ironic := IronicConfig(settings)
+		p.Ironic = &ironic
+	case ProviderEquinix:
+		settings := equinixConfigJSON{}
+		if err := decoder.Decode(&settings); err != nil {
+			return err
+		}
+		equinix := EquinixConfig(settings)
+		p.Equinix = &equinix
+	case ProviderIbmcloud:
+		settings := ibmcloudConfigJSON{}
+		if err := decoder.Decode(&settings); err != nil {
+			return err
+		}
+		ibmcloud := IbmcloudConfig(settings)
+		p.Ibmcloud = &ibmcloud
+	case ProviderAWS:
+		aws := AWSConfig{}
+		if err := decoder.Decode(&aws); err != nil {
+			return err
+		}
+		p.AWS = &aws
+	default:
+		return fmt.Errorf("provider %s has no typed settings", p.Type)
+	}
+	return nil
+}
+
+func setAnnotation(pool *CIPool, key, value string) {
+	if pool.Annotations == nil {
+		pool.Annotations = map[string]string{}
+	}
+	pool.Annotations[key] = value
+}
@@ -0,0 +1,138 @@
+package v1beta2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+func v1Pool(provider, providerInfo, providerConfig string) *ofcirv1.CIPool {
+	pool := &ofcirv1.CIPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "cipool-test", Namespace: "ofcir-system", Labels: map[string]string{"team": "ci"}},
+		Spec: ofcirv1.CIPoolSpec{
+			Provider:     provider,
+			ProviderInfo: providerInfo,
+			SecretRef:    &corev1.LocalObjectReference{Name: "credentials"},
+			Priority:     1,
+			Size:         3,
+			Timeout:      metav1.Duration{Duration: time.Hour},
+			State:        ofcirv1.StatePoolAvailable,
+			Type:         ofcirv1.TypeCIHost,
+			Autoscaling:  &ofcirv1.Autoscaling{MinSize: 1, MaxSize: 5},
+
+			MaxConcurrentProvisioning: 2,
+			Cost:                      &ofcirv1.Cost{HourlyPrice: "0.85", MonthlyBudget: "500"},
+		},
+		Status: ofcirv1.CIPoolStatus{Size: 3},
+	}
+	if providerConfig != "" {
+		pool.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(providerConfig)}
+	}
+	return pool
+}
+
+func TestConvertFrom(t *testing.T) {
+	cases := []struct {
+		name                string
+		pool                *ofcirv1.CIPool
+		expectedProvider    ProviderSpec
+		expectedAnnotations map[string]string
+	}{
+		{
+			name: "no provider config",
+			pool: v1Pool("fake-provider", "", ""),
+			expectedProvider: ProviderSpec{
+				Type:      ProviderFake,
+				SecretRef: &corev1.LocalObjectReference{Name: "credentials"},
+			},
+		},
+		{
+			name: "libvirt",
+			pool: v1Pool("libvirt", "", `{"pool":"default","backing_store":"/var/lib/libvirt/images/fedora.qcow2","cpus":4}`),
+			expectedProvider: ProviderSpec{
+				Type:      ProviderLibvirt,
+				SecretRef: &corev1.LocalObjectReference{Name: "credentials"},
+				Libvirt:   &LibvirtConfig{Pool: "default", BackingStore: "/var/lib/libvirt/images/fedora.qcow2", Cpus: 4},
+			},
+		},
+		{
+			name: "aws",
+			pool: v1Pool("aws", "", `{"machineSpec":{"regions":[{"name":"us-east-1","instances":[{"type":"m5.metal","amiID":"ami-0123"}]}]}}`),
+			expectedProvider: ProviderSpec{
+				Type:      ProviderAWS,
+				SecretRef: &corev1.LocalObjectReference{Name: "credentials"},
+				AWS: &AWSConfig{MachineSpec: &AWSMachineSpec{
+					Regions: []AWSRegion{{Name: "us-east-1", Instances: []AWSInstance{{Type: "m5.metal", AMIID: "ami-0123"}}}},
+				}},
+			},
+		},
+		{
+			name: "untyped settings and provider info",
+			pool: v1Pool("ironic", "rack-a", `{"endpoint":"https://172.22.0.3:6385","password":"secret"}`),
+			expectedProvider: ProviderSpec{
+				Type:      ProviderIronic,
+				SecretRef: &corev1.LocalObjectReference{Name: "credentials"},
+			},
+			expectedAnnotations: map[string]string{
+				ProviderInfoAnnotation:   "rack-a",
+				ProviderConfigAnnotation: `{"endpoint":"https://172.22.0.3:6385","password":"secret"}`,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dst := &CIPool{}
+			assert.NoError(t, dst.ConvertFrom(tc.pool))
+
+			assert.Equal(t, tc.expectedProvider, dst.Spec.Provider)
+			for k, v := range tc.expectedAnnotations {
+				assert.Equal(t, v, dst.Annotations[k])
+			}
+			assert.Equal(t, tc.pool.Labels, dst.Labels)
+			assert.Equal(t, tc.pool.Spec.Size, dst.Spec.Size)
+			assert.Equal(t, tc.pool.Spec.Autoscaling, dst.Spec.Autoscaling)
+			assert.Equal(t, tc.pool.Status, dst.Status)
+
+			// Converting back must not lose any setting
+			back := &ofcirv1.CIPool{}
+			assert.NoError(t, dst.ConvertTo(back))
+			if tc.pool.Spec.ProviderConfig != nil {
+				assert.JSONEq(t, string(tc.pool.Spec.ProviderConfig.Raw), string(back.Spec.ProviderConfig.Raw))
+				back.Spec.ProviderConfig = tc.pool.Spec.ProviderConfig
+			}
+			assert.Equal(t, tc.pool, back)
+		})
+	}
+}
+
+func TestConvertTo(t *testing.T) {
+	pool := &CIPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "cipool-equinix", Namespace: "ofcir-system"},
+		Spec: CIPoolSpec{
+			Provider: ProviderSpec{
+				Type:    ProviderEquinix,
+				Equinix: &EquinixConfig{ProjectID: "a1b2", Metros: []string{"da", "sv"}, Plan: "c3.small.x86"},
+			},
+			Size:    2,
+			Timeout: metav1.Duration{Duration: time.Hour},
+			State:   ofcirv1.StatePoolAvailable,
+			Type:    ofcirv1.TypeCIHost,
+		},
+	}
+
+	dst := &ofcirv1.CIPool{}
+	assert.NoError(t, pool.ConvertTo(dst))
+
+	assert.Equal(t, "equinix", dst.Spec.Provider)
+	assert.Nil(t, dst.Spec.SecretRef)
+	assert.Empty(t, dst.Spec.ProviderInfo)
+	assert.JSONEq(t, `{"projectid":"a1b2","metros":["da","sv"],"plan":"c3.small.x86"}`, string(dst.Spec.ProviderConfig.Raw))
+	assert.Nil(t, dst.Annotations)
+}
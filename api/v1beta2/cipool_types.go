@@ -0,0 +1,156 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+// CIPoolSpec defines the desired state of CIPool
+type CIPoolSpec struct {
+	// The provider used by the pool to manage the resources, and its settings
+	Provider ProviderSpec `json:"provider"`
+
+	// Used for selecting an eligible pool
+	Priority int `json:"priority"`
+
+	// Desired number of instances maintained by the current pool
+	Size int `json:"size"`
+
+	// Specify how long a CIR instance will be allowed to remain in the inuse state
+	Timeout metav1.Duration `json:"timeout"`
+
+	// Required state of the pool
+	State ofcirv1.CIPoolState `json:"state"`
+
+	// The type of the resources managed by the pool
+	Type ofcirv1.CIResourceType `json:"type"`
+
+	// How many consecutive failures are tolerated while provisioning or cleaning
+	// a resource, before moving it to the error state. Default is 5
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxFailures int `json:"maxFailures,omitempty"`
+
+	// How long a resource is allowed to wait for being provisioned. Once expired,
+	// the resource is released and provisioned again. No deadline if not set
+	// +optional
+	ProvisioningTimeout *metav1.Duration `json:"provisioningTimeout,omitempty"`
+
+	// How long a resource is allowed to wait for being cleaned. Once expired,
+	// the resource is released and provisioned again. No deadline if not set
+	// +optional
+	CleaningTimeout *metav1.Duration `json:"cleaningTimeout,omitempty"`
+
+	// How many consecutive times a resource can be provisioned again after a
+	// deadline expiration, before moving it to the error state. Default is 3
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxReprovisions int `json:"maxReprovisions,omitempty"`
+
+	// How many resources of the pool can be provisioned at the same time. The
+	// other ones wait in the provisioning state until a slot is freed. No limit
+	// if not set
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConcurrentProvisioning int `json:"maxConcurrentProvisioning,omitempty"`
+
+	// How many resources of the pool can be cleaned at the same time. The other
+	// ones wait in the cleaning state until a slot is freed. No limit if not set
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConcurrentCleaning int `json:"maxConcurrentCleaning,omitempty"`
+
+	// Probe periodically run against the available resources. Resources failing
+	// it are taken out of rotation. No probe if not set
+	// +optional
+	HealthCheck *ofcirv1.HealthCheck `json:"healthCheck,omitempty"`
+
+	// Checks run once the provider reported a resource as provisioned or
+	// cleaned. The resource is made available only after all of them succeeded.
+	// The time spent waiting is bounded by provisioningTimeout and cleaningTimeout
+	// +optional
+	ReadinessChecks []ofcirv1.Probe `json:"readinessChecks,omitempty"`
+
+	// Custom logic run at some points of the resources lifecycle
+	// +optional
+	Hooks []ofcirv1.Hook `json:"hooks,omitempty"`
+
+	// Script run over SSH for cleaning the released resources, authenticating
+	// with the ssh-privatekey field of the pool secret. Not used by fallback pools
+	// +optional
+	CleaningScript *ofcirv1.CleaningScript `json:"cleaningScript,omitempty"`
+
+	// The order used for selecting the resources to be removed when the pool is
+	// shrunk. The idle resources are always removed before the ones in use.
+	// Default is newest
+	// +optional
+	EvictionStrategy ofcirv1.EvictionStrategy `json:"evictionStrategy,omitempty"`
+
+	// Lets the pool size follow the demand, within the given bounds. When set,
+	// size is ignored
+	// +optional
+	Autoscaling *ofcirv1.Autoscaling `json:"autoscaling,omitempty"`
+
+	// Recurring time windows overriding size. When more than one is active, the
+	// first one listed wins. Ignored when autoscaling is set
+	// +optional
+	Schedules []ofcirv1.SizeSchedule `json:"schedules,omitempty"`
+
+	// Tracks the spend of the pool resources, and optionally caps it with a
+	// monthly budget
+	// +optional
+	Cost *ofcirv1.Cost `json:"cost,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:object:generate=true
+//+kubebuilder:resource:shortName=cip
+//+kubebuilder:subresource:status
+//+kubebuilder:unservedversion
+//+kubebuilder:printcolumn:name="Provider",type="string",JSONPath=".spec.provider.type",description="The provider used by the pool to manage the resources"
+//+kubebuilder:printcolumn:name="Priority",type="integer",JSONPath=".spec.priority",description="The priority of the pool"
+//+kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state",description="The current state"
+//+kubebuilder:printcolumn:name="Size",type="integer",JSONPath=".status.size",description="The current size of the pool"
+//+kubebuilder:printcolumn:name="Req Size",type="integer",JSONPath=".spec.size",description="The requested size of the pool"
+//+kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type",description="The type of the pool"
+
+// CIPool is the Schema for the cipools API. Compared to v1, the provider settings
+// are typed fields of the pool, while the credentials are kept in the pool secret
+type CIPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CIPoolSpec           `json:"spec,omitempty"`
+	Status ofcirv1.CIPoolStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:object:generate=true
+
+// CIPoolList contains a list of CIPool
+type CIPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CIPool `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CIPool{}, &CIPoolList{})
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ProviderType identifies the provider managing the pool resources
+// +kubebuilder:validation:Enum=fake-provider;libvirt;ironic;equinix;ibmcloud;aws
+type ProviderType string
+
+const (
+	ProviderFake     ProviderType = "fake-provider"
+	ProviderLibvirt  ProviderType = "libvirt"
+	ProviderIronic   ProviderType = "ironic"
+	ProviderEquinix  ProviderType = "equinix"
+	ProviderIbmcloud ProviderType = "ibmcloud"
+	ProviderAWS      ProviderType = "aws"
+)
+
+// ProviderSpec selects the provider of the pool. At most one of the provider
+// settings can be set, the one matching type
+// +kubebuilder:validation:XValidation:rule="!has(self.libvirt) || self.type == 'libvirt'",message="libvirt can be set only for the libvirt provider"
+// +kubebuilder:validation:XValidation:rule="!has(self.ironic) || self.type == 'ironic'",message="ironic can be set only for the ironic provider"
+// +kubebuilder:validation:XValidation:rule="!has(self.equinix) || self.type == 'equinix'",message="equinix can be set only for the equinix provider"
+// +kubebuilder:validation:XValidation:rule="!has(self.ibmcloud) || self.type == 'ibmcloud'",message="ibmcloud can be set only for the ibmcloud provider"
+// +kubebuilder:validation:XValidation:rule="!has(self.aws) || self.type == 'aws'",message="aws can be set only for the aws provider"
+type ProviderSpec struct {
+	// The kind of provider
+	Type ProviderType `json:"type"`
+
+	// Reference to the secret holding the provider credentials. Default is
+	// <pool name>-secret
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// Settings of the libvirt provider
+	// +optional
+	Libvirt *LibvirtConfig `json:"libvirt,omitempty"`
+
+	// Settings of the ironic provider. The username, password and cloudyaml
+	// credentials are read from the secret
+	// +optional
+	Ironic *IronicConfig `json:"ironic,omitempty"`
+
+	// Settings of the equinix provider. The token is read from the secret
+	// +optional
+	Equinix *EquinixConfig `json:"equinix,omitempty"`
+
+	// Settings of the ibmcloud provider. The apikey is read from the secret
+	// +optional
+	Ibmcloud *IbmcloudConfig `json:"ibmcloud,omitempty"`
+
+	// Settings of the aws provider. The accessKey and secretAccessKey are read
+	// from the secret
+	// +optional
+	AWS *AWSConfig `json:"aws,omitempty"`
+}
+
+// LibvirtConfig defines the virtual machines created by the libvirt provider
+type LibvirtConfig struct {
+	// The storage pool used for the volumes
+	// +optional
+	Pool string `json:"pool,omitempty"`
+
+	// The volume capacity, in GiB
+	// +optional
+	Volume uint64 `json:"volume,omitempty"`
+
+	// The qcow2 image backing the volumes
+	// +optional
+	BackingStore string `json:"backingStore,omitempty"`
+
+	// The amount of memory, in GiB
+	// +optional
+	Memory uint `json:"memory,omitempty"`
+
+	// The number of vcpus
+	// +optional
+	Cpus uint `json:"cpus,omitempty"`
+
+	// The name of the bridge the machines are attached to
+	// +optional
+	Bridge string `json:"bridge,omitempty"`
+
+	// The absolute path of the ignition file
+	// +optional
+	Ignition string `json:"ignition,omitempty"`
+}
+
+// IronicConfig defines how the ironic nodes are provisioned
+type IronicConfig struct {
+	// The ironic API endpoint, when not using keystone
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// The name of the cloud in the clouds.yaml credentials
+	// +optional
+	OSCloud string `json:"osCloud,omitempty"`
+
+	// The URL of the qcow2 image, or the id of the glance image, provisioned on the nodes
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// The public ssh key provisioned on the nodes
+	// +optional
+	SSHKey string `json:"sshKey,omitempty"`
+}
+
+// EquinixConfig defines the servers created by the equinix provider
+type EquinixConfig struct {
+	// The project the servers are created in
+	// +optional
+	ProjectID string `json:"projectID,omitempty"`
+
+	// The metros where the servers can be located
+	// +optional
+	Metros []string `json:"metros,omitempty"`
+
+	// The server plan
+	// +optional
+	Plan string `json:"plan,omitempty"`
+
+	// The operating system installed on the servers
+	// +optional
+	OS string `json:"os,omitempty"`
+
+	// Base64 encoded cloud-init user data
+	// +optional
+	UserData string `json:"userData,omitempty"`
+}
+
+// IbmcloudConfig defines the servers ordered by the ibmcloud provider
+type IbmcloudConfig struct {
+	// The public ssh key installed on the servers
+	// +optional
+	SSHKey string `json:"sshKey,omitempty"`
+
+	// The server preset
+	// +optional
+	Preset string `json:"preset,omitempty"`
+
+	// The operating system installed on the servers
+	// +optional
+	OS string `json:"os,omitempty"`
+
+	// The URL of the provisioning script
+	// +optional
+	Script string `json:"script,omitempty"`
+}
+
+// AWSConfig defines the instances created by the aws provider
+type AWSConfig struct {
+	// Base64 encoded cloud-init user data
+	// +optional
+	UserData string `json:"userData,omitempty"`
+
+	// Where and how the instances are created
+	// +optional
+	MachineSpec *AWSMachineSpec `json:"machineSpec,omitempty"`
+}
+
+// AWSMachineSpec defines where and how the aws instances are created
+type AWSMachineSpec struct {
+	// The regions tried, in order, when creating an instance
+	// +optional
+	Regions []AWSRegion `json:"regions,omitempty"`
+
+	// The root device of the instances
+	// +optional
+	Device *AWSBlockDevice `json:"device,omitempty"`
+}
+
+// AWSRegion defines the instances that can be created in a region
+type AWSRegion struct {
+	// The region name
+	Name string `json:"name"`
+
+	// The key pair installed on the instances
+	// +optional
+	KeyPairName string `json:"keyPairName,omitempty"`
+
+	// The security group of the instances
+	// +optional
+	SecurityGroupID string `json:"securityGroupID,omitempty"`
+
+	// The subnets tried, in order. Using subnets from different availability
+	// zones is recommended
+	// +optional
+	SubnetIDs []string `json:"subnetIDs,omitempty"`
+
+	// The instance types tried, in order
+	// +optional
+	Instances []AWSInstance `json:"instances,omitempty"`
+}
+
+// AWSInstance defines an instance type and its image
+type AWSInstance struct {
+	// The instance type
+	Type string `json:"type"`
+
+	// The image the instance is created from
+	AMIID string `json:"amiID"`
+}
+
+// AWSBlockDevice defines the root device of the aws instances
+type AWSBlockDevice struct {
+	// The logical device name, e.g. /dev/xvda
+	// +optional
+	DeviceName string `json:"deviceName,omitempty"`
+
+	// The device size, in GiB
+	// +optional
+	DeviceSize int32 `json:"deviceSize,omitempty"`
+
+	// The volume type, e.g. gp2 or gp3
+	// +optional
+	DeviceType string `json:"deviceType,omitempty"`
+}
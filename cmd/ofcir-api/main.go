@@ -1,19 +1,31 @@
 package main
 
 import (
+	"context"
 	"flag"
 
 	"github.com/openshift/ofcir/pkg/server"
+	"github.com/openshift/ofcir/pkg/tracing"
+	"github.com/openshift/ofcir/pkg/utils"
 )
 
 func main() {
-	var kubeconfig, port, namespace string
+	var kubeconfig, port, namespaces string
+	var tracingOpts tracing.Options
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
 	flag.StringVar(&port, "port", "8087", "server port")
-	flag.StringVar(&namespace, "namespace", "ofcir-system", "Namespace to look for CIPool and CIR resources")
+	flag.StringVar(&namespaces, "namespaces", "ofcir-system", "Comma separated namespaces to look for CIPool and CIR resources, each one with its own tokens (empty for all namespaces)")
+	flag.StringVar(&namespaces, "namespace", "ofcir-system", "Deprecated: use --namespaces")
+	tracingOpts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
-	srv := server.NewOfcirAPI(port, namespace)
+	shutdownTracing, err := tracing.Setup(context.Background(), "ofcir-api", tracingOpts)
+	if err != nil {
+		panic(err.Error())
+	}
+	defer shutdownTracing(context.Background())
+
+	srv := server.NewOfcirAPI(port, utils.ParseNamespaces(namespaces))
 	if err := srv.Init(kubeconfig); err != nil {
 		panic(err.Error())
 	}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/openshift/ofcir/controllers"
+)
+
+func main() {
+	var format, update string
+	flag.StringVar(&format, "format", "dot", "output format of the CIResource state diagram (dot or mermaid)")
+	flag.StringVar(&update, "update", "", "markdown file whose generated mermaid diagram is replaced, instead of printing it")
+	flag.Parse()
+
+	fsm := controllers.NewCIResourceFSM(logr.Discard())
+
+	if update != "" {
+		if err := updateMermaid(update, fsm.Mermaid()); err != nil {
+			fmt.Fprintf(os.Stderr, "cannot update %s: %v\n", update, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	switch format {
+	case "dot":
+		fmt.Print(fsm.DOT())
+	case "mermaid":
+		fmt.Print(fsm.Mermaid())
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format: %s\n", format)
+		os.Exit(1)
+	}
+}
+
+func updateMermaid(path string, diagram string) error {
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	updated, err := controllers.UpdateMermaidBlock(string(doc), diagram)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(updated), 0644)
+}
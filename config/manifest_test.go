@@ -53,6 +53,8 @@ func TestDefaultManifestsExcludeKubeRBACProxy(t *testing.T) {
 		"containerPort: 8443",
 		"containerPort: 8087",
 		"--health-probe-bind-address=:8081",
+		"serviceAccountName: ofcir-api",
+		"name: ofcir-api-role",
 	)
 }
 
@@ -40,7 +40,7 @@ func cipoolWithSecret() (*cipoolBuilder, *corev1.Secret) {
 	cip := cipool()
 	cipSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      cip.Name,
+			Name:      cip.GetSecretName(),
 			Namespace: cip.Namespace,
 		},
 	}
@@ -70,6 +70,11 @@ func (cp *cipoolBuilder) priority(value int) *cipoolBuilder {
 	return cp
 }
 
+func (cp *cipoolBuilder) state(value ofcirv1.CIPoolState) *cipoolBuilder {
+	cp.Spec.State = value
+	return cp
+}
+
 // cirBuilder allows to build a CIResource instance using a fluent interface
 type cirBuilder struct {
 	ofcirv1.CIResource
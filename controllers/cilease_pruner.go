@@ -0,0 +1,94 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+// How often the expired lease records are looked for
+const defaultLeasePruneInterval = 1 * time.Hour
+
+// CILeasePruner periodically removes the lease records older than the
+// configured retention, in all the watched namespaces
+type CILeasePruner struct {
+	client.Client
+
+	// How long the lease records are kept, zero means forever
+	Retention time.Duration
+}
+
+// Start runs the pruning until the context is cancelled
+func (p *CILeasePruner) Start(ctx context.Context) error {
+	if p.Retention == 0 {
+		return nil
+	}
+	logger := log.FromContext(ctx).WithName("cilease-pruner")
+
+	ticker := time.NewTicker(defaultLeasePruneInterval)
+	defer ticker.Stop()
+	for {
+		if err := p.pruneLeases(ctx, time.Now(), logger); err != nil {
+			logger.Error(err, "error while pruning leases")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection makes only the leader remove the leases
+func (p *CILeasePruner) NeedLeaderElection() bool {
+	return true
+}
+
+// pruneLeases removes the lease records released before the retention
+func (p *CILeasePruner) pruneLeases(ctx context.Context, now time.Time, logger logr.Logger) error {
+	leases := &ofcirv1.CILeaseList{}
+	if err := p.List(ctx, leases); err != nil {
+		return err
+	}
+
+	for _, l := range leases.Items {
+		if now.Sub(l.Spec.ReleasedAt.Time) <= p.Retention {
+			continue
+		}
+		logger.Info("Removing expired lease", "CILease", l.Name, "Namespace", l.Namespace)
+		if err := p.Delete(ctx, &l); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetupWithManager adds the pruner to the Manager
+func (p *CILeasePruner) SetupWithManager(mgr ctrl.Manager) error {
+	return mgr.Add(p)
+}
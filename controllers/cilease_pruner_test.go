@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCILeasePruner(t *testing.T) {
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	lease := func(name string, age time.Duration) client.Object {
+		return &ofcirv1.CILease{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultTestNs},
+			Spec: ofcirv1.CILeaseSpec{
+				CIResource: "cir-0",
+				AcquiredAt: metav1.NewTime(now.Add(-age - time.Hour)),
+				ReleasedAt: metav1.NewTime(now.Add(-age)),
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		retention time.Duration
+		expected  []string
+	}{
+		{
+			name:      "expired leases are removed",
+			retention: 24 * time.Hour,
+			expected:  []string{"recent"},
+		},
+		{
+			name:      "no lease expired",
+			retention: 90 * 24 * time.Hour,
+			expected:  []string{"old", "recent"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			assert.NoError(t, ofcirv1.AddToScheme(scheme))
+			c := fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(lease("old", 30*24*time.Hour), lease("recent", time.Hour)).Build()
+
+			p := CILeasePruner{Client: c, Retention: tt.retention}
+			assert.NoError(t, p.pruneLeases(context.TODO(), now, logr.Discard()))
+
+			leases := &ofcirv1.CILeaseList{}
+			assert.NoError(t, c.List(context.TODO(), leases))
+			var names []string
+			for _, l := range leases.Items {
+				names = append(names, l.Name)
+			}
+			assert.ElementsMatch(t, tt.expected, names)
+		})
+	}
+}
+
+func TestCILeasePrunerDisabled(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, ofcirv1.AddToScheme(scheme))
+	old := &ofcirv1.CILease{
+		ObjectMeta: metav1.ObjectMeta{Name: "old", Namespace: defaultTestNs},
+		Spec:       ofcirv1.CILeaseSpec{ReleasedAt: metav1.NewTime(time.Now().AddDate(-1, 0, 0))},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(old).Build()
+
+	// Without a retention the pruner returns right away, keeping every lease
+	p := CILeasePruner{Client: c}
+	assert.NoError(t, p.Start(context.TODO()))
+	assert.NoError(t, c.Get(context.TODO(), client.ObjectKeyFromObject(old), &ofcirv1.CILease{}))
+}
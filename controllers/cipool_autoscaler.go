@@ -0,0 +1,90 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+// autoscale returns the size required by the pool autoscaler, and the reason of
+// the decision when it differs from the current desired size
+func autoscale(pool *ofcirv1.CIPool, poolCirs []ofcirv1.CIResource, now time.Time) (int, string) {
+	as := pool.Spec.Autoscaling
+
+	current := pool.Spec.Size
+	var lastScale time.Time
+	if st := pool.Status.Autoscaling; st != nil {
+		current = st.DesiredSize
+		if st.LastScaleTime != nil {
+			lastScale = st.LastScaleTime.Time
+		}
+	}
+	bounded := func(size int) int {
+		return min(max(size, as.MinSize), max(as.MaxSize, as.MinSize))
+	}
+
+	idle, provisioning := 0, 0
+	for _, c := range poolCirs {
+		if isSelectedForEviction(c) {
+			continue
+		}
+		switch c.Status.State {
+		case ofcirv1.StateAvailable:
+			if c.Spec.State == ofcirv1.StateAvailable {
+				idle++
+			}
+		case ofcirv1.StateNone, ofcirv1.StateProvisioning, ofcirv1.StateProvisioningWait:
+			provisioning++
+		}
+	}
+
+	if size := bounded(current); size != current {
+		return size, fmt.Sprintf("size %d out of the [%d, %d] bounds", current, as.MinSize, as.MaxSize)
+	}
+
+	// The resources being provisioned will be available soon
+	if missing := as.TargetAvailable - idle - provisioning; missing > 0 && current < as.MaxSize {
+		return bounded(current + missing), fmt.Sprintf("%d available resources, below the target of %d", idle, as.TargetAvailable)
+	}
+
+	if acquireRejectedSince(pool, lastScale) && provisioning == 0 && current < as.MaxSize {
+		return bounded(current + 1), "acquire requests rejected"
+	}
+
+	if excess := idle - as.TargetAvailable; excess > 0 && current > as.MinSize && now.Sub(lastScale) >= as.GetScaleDownDelay() {
+		return bounded(current - excess), fmt.Sprintf("%d available resources, above the target of %d", idle, as.TargetAvailable)
+	}
+
+	return current, ""
+}
+
+// acquireRejectedSince returns true if the API could not satisfy an acquire
+// request for the pool after the given time
+func acquireRejectedSince(pool *ofcirv1.CIPool, since time.Time) bool {
+	value, ok := pool.GetAnnotations()[ofcirv1.AcquireRejectedAnnotation]
+	if !ok {
+		return false
+	}
+	rejected, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false
+	}
+	return rejected.After(since)
+}
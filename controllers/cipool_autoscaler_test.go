@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAutoscale(t *testing.T) {
+	now := time.Now()
+	recently := metav1.NewTime(now.Add(-time.Minute))
+	longAgo := metav1.NewTime(now.Add(-time.Hour))
+
+	cirs := func(states ...ofcirv1.CIResourceState) []ofcirv1.CIResource {
+		var result []ofcirv1.CIResource
+		for i, s := range states {
+			result = append(result, *cir(fmt.Sprintf("cir-%d", i)).currentState(s).requiredState(s).build())
+		}
+		return result
+	}
+
+	tests := []struct {
+		name           string
+		size           int
+		status         *ofcirv1.AutoscalingStatus
+		rejected       *metav1.Time
+		cirs           []ofcirv1.CIResource
+		expectedSize   int
+		expectedReason string
+	}{
+		{
+			name:           "initial size out of bounds",
+			size:           10,
+			cirs:           cirs(),
+			expectedSize:   6,
+			expectedReason: "size 10 out of the [2, 6] bounds",
+		},
+		{
+			name:           "below the target",
+			status:         &ofcirv1.AutoscalingStatus{DesiredSize: 3, LastScaleTime: &recently},
+			cirs:           cirs(ofcirv1.StateInUse, ofcirv1.StateInUse, ofcirv1.StateAvailable),
+			expectedSize:   4,
+			expectedReason: "1 available resources, below the target of 2",
+		},
+		{
+			name:           "below the target, bounded",
+			status:         &ofcirv1.AutoscalingStatus{DesiredSize: 5, LastScaleTime: &recently},
+			cirs:           cirs(ofcirv1.StateInUse, ofcirv1.StateInUse, ofcirv1.StateInUse, ofcirv1.StateInUse, ofcirv1.StateInUse),
+			expectedSize:   6,
+			expectedReason: "0 available resources, below the target of 2",
+		},
+		{
+			name:         "below the target, at max size",
+			status:       &ofcirv1.AutoscalingStatus{DesiredSize: 6, LastScaleTime: &recently},
+			cirs:         cirs(ofcirv1.StateInUse, ofcirv1.StateInUse, ofcirv1.StateInUse, ofcirv1.StateInUse, ofcirv1.StateInUse, ofcirv1.StateInUse),
+			expectedSize: 6,
+		},
+		{
+			name:         "target reached with the resources being provisioned",
+			status:       &ofcirv1.AutoscalingStatus{DesiredSize: 4, LastScaleTime: &recently},
+			cirs:         cirs(ofcirv1.StateInUse, ofcirv1.StateInUse, ofcirv1.StateAvailable, ofcirv1.StateProvisioningWait),
+			expectedSize: 4,
+		},
+		{
+			name:           "acquire rejected",
+			status:         &ofcirv1.AutoscalingStatus{DesiredSize: 4, LastScaleTime: &longAgo},
+			rejected:       &recently,
+			cirs:           cirs(ofcirv1.StateInUse, ofcirv1.StateInUse, ofcirv1.StateAvailable, ofcirv1.StateAvailable),
+			expectedSize:   5,
+			expectedReason: "acquire requests rejected",
+		},
+		{
+			name:         "acquire rejected before the last scaling",
+			status:       &ofcirv1.AutoscalingStatus{DesiredSize: 4, LastScaleTime: &recently},
+			rejected:     &longAgo,
+			cirs:         cirs(ofcirv1.StateInUse, ofcirv1.StateInUse, ofcirv1.StateAvailable, ofcirv1.StateAvailable),
+			expectedSize: 4,
+		},
+		{
+			name:           "above the target",
+			status:         &ofcirv1.AutoscalingStatus{DesiredSize: 5, LastScaleTime: &longAgo},
+			cirs:           cirs(ofcirv1.StateInUse, ofcirv1.StateAvailable, ofcirv1.StateAvailable, ofcirv1.StateAvailable, ofcirv1.StateAvailable),
+			expectedSize:   3,
+			expectedReason: "4 available resources, above the target of 2",
+		},
+		{
+			name:         "above the target, cooling down",
+			status:       &ofcirv1.AutoscalingStatus{DesiredSize: 5, LastScaleTime: &recently},
+			cirs:         cirs(ofcirv1.StateInUse, ofcirv1.StateAvailable, ofcirv1.StateAvailable, ofcirv1.StateAvailable, ofcirv1.StateAvailable),
+			expectedSize: 5,
+		},
+		{
+			name:           "above the target, bounded",
+			status:         &ofcirv1.AutoscalingStatus{DesiredSize: 4, LastScaleTime: &longAgo},
+			cirs:           cirs(ofcirv1.StateAvailable, ofcirv1.StateAvailable, ofcirv1.StateAvailable, ofcirv1.StateAvailable),
+			expectedSize:   2,
+			expectedReason: "4 available resources, above the target of 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := cipool().size(tt.size).build()
+			pool.Spec.Autoscaling = &ofcirv1.Autoscaling{
+				MinSize:         2,
+				MaxSize:         6,
+				TargetAvailable: 2,
+				ScaleDownDelay:  &metav1.Duration{Duration: 30 * time.Minute},
+			}
+			pool.Status.Autoscaling = tt.status
+			if tt.rejected != nil {
+				pool.Annotations = map[string]string{ofcirv1.AcquireRejectedAnnotation: tt.rejected.UTC().Format(time.RFC3339)}
+			}
+
+			size, reason := autoscale(pool, tt.cirs, now)
+			assert.Equal(t, tt.expectedSize, size)
+			assert.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
@@ -20,27 +20,32 @@ import (
 	"context"
 	"fmt"
 	"sort"
-	"strconv"
-	"strings"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	"github.com/go-logr/logr"
 	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/openshift/ofcir/pkg/notifier"
 )
 
 // Additional permissions required by the controller
-//+kubebuilder:rbac:groups="",namespace=ofcir-system,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
 
 const (
 	defaultCIPoolRetryDelay = time.Minute * 1
@@ -50,11 +55,17 @@ const (
 type CIPoolReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Sends the relevant changes to the subscribed webhooks, if set
+	Notifier *notifier.Notifier
+
+	// Records the autoscaling decisions as events, if set
+	Recorder events.EventRecorder
 }
 
-//+kubebuilder:rbac:groups=ofcir.openshift,namespace=ofcir-system,resources=cipools,verbs=get;list;watch;create;update;patch;delete;deletecollection
-//+kubebuilder:rbac:groups=ofcir.openshift,namespace=ofcir-system,resources=cipools/status,verbs=get;update;patch
-//+kubebuilder:rbac:groups=ofcir.openshift,namespace=ofcir-system,resources=cipools/finalizers,verbs=update
+//+kubebuilder:rbac:groups=ofcir.openshift,resources=cipools,verbs=get;list;watch;create;update;patch;delete;deletecollection
+//+kubebuilder:rbac:groups=ofcir.openshift,resources=cipools/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=ofcir.openshift,resources=cipools/finalizers,verbs=update
 
 // Reconcile handles changes to the CIPool type
 func (r CIPoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -85,19 +96,15 @@ func (r CIPoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		}
 	} else {
 		// Delete has been requested
-		cirs := &ofcirv1.CIResourceList{}
-		r.List(context.TODO(), cirs, client.InNamespace(pool.Namespace))
-
-		poolCirs := []ofcirv1.CIResource{}
-		for _, c := range cirs.Items {
-			if c.Spec.PoolRef.Name == pool.Name {
-				poolCirs = append(poolCirs, c)
-			}
+		poolCirs, err := listPoolResources(ctx, r.Client, pool)
+		if err != nil {
+			logger.Error(err, "failed to list the pool CIResources")
+			return ctrl.Result{}, err
 		}
 
 		// Still some resources to be deleted
 		if len(poolCirs) > 0 {
-			_, err = r.deleteCIResources(0, poolCirs, pool.Namespace, logger)
+			_, err = r.deleteCIResources(pool, 0, poolCirs, logger)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
@@ -119,8 +126,9 @@ func (r CIPoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	// Check if a state update is required
 	if pool.Status.State != pool.Spec.State {
 		switch pool.Spec.State {
-		case ofcirv1.StatePoolAvailable, ofcirv1.StatePoolOffline:
+		case ofcirv1.StatePoolAvailable, ofcirv1.StatePoolOffline, ofcirv1.StatePoolDraining:
 			pool.Status.State = pool.Spec.State
+			pool.Status.InUse = 0
 			if err = r.savePoolStatus(pool); err != nil {
 				logger.Error(err, "error while updating status")
 			}
@@ -131,24 +139,26 @@ func (r CIPoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		return ctrl.Result{}, err
 	}
 
+	if err = r.checkPoolSecret(ctx, pool, logger); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The resources cost money whatever the pool state
+	if err = r.trackCost(ctx, pool, logger); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// A draining pool is not resized anymore, just waits for its resources to be released
+	if pool.Status.State == ofcirv1.StatePoolDraining {
+		return r.drainPool(pool, logger)
+	}
+
 	// Check if the pool is offline, in such case let's skip the reconciliation
 	if pool.Status.State == ofcirv1.StatePoolOffline {
 		logger.Info("pool is offline, skipping")
 		return ctrl.Result{RequeueAfter: defaultCIPoolRetryDelay}, nil
 	}
 
-	// Retrieve the pool secret, if defined
-	poolSecretKey := types.NamespacedName{
-		Namespace: pool.Namespace,
-		Name:      fmt.Sprintf("%s-secret", pool.Name),
-	}
-	poolSecret := v1.Secret{}
-	if err := r.Get(context.Background(), poolSecretKey, &poolSecret); err != nil {
-		if !errors.IsNotFound(err) {
-			return ctrl.Result{}, err
-		}
-	}
-
 	// Pool is available
 	isDirty, err := r.manageCIResourcesFor(pool, logger)
 	if err != nil {
@@ -165,105 +175,296 @@ func (r CIPoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 }
 
 func (r *CIPoolReconciler) manageCIResourcesFor(pool *ofcirv1.CIPool, logger logr.Logger) (bool, error) {
-	// Retrieve all cirs
-	allCirs := &ofcirv1.CIResourceList{}
-	err := r.List(context.TODO(), allCirs, client.InNamespace(pool.Namespace))
+	// Retrieve the pool cirs
+	poolCirs, err := listPoolResources(context.TODO(), r.Client, pool)
 	if err != nil {
-		logger.Error(err, fmt.Sprintf("failed to list CIResources in namespace: %s", pool.Namespace))
+		logger.Error(err, "failed to list the pool CIResources")
 		return false, err
 	}
 
-	sort.SliceStable(allCirs.Items, func(i, j int) bool {
-		return allCirs.Items[i].Name < allCirs.Items[j].Name
-	})
+	r.adoptCIResources(pool, poolCirs, logger)
 
-	// Filters out cirs not belonging to the current pool
-	var poolCirs []ofcirv1.CIResource
-	for _, c := range allCirs.Items {
-		if c.Spec.PoolRef.Name == pool.Name {
-			poolCirs = append(poolCirs, c)
+	// Update status if required with the current effective number of resources
+	pendingEvictions := 0
+	for _, c := range poolCirs {
+		if isSelectedForEviction(c) && isInUse(c) {
+			pendingEvictions++
+		}
+	}
+	queuedProvisioning := provisioningSlots.queued(pool, poolCirs)
+	queuedCleaning := cleaningSlots.queued(pool, poolCirs)
+	statusChanged := pool.Status.Size != len(poolCirs) || pool.Status.PendingEvictions != pendingEvictions ||
+		pool.Status.QueuedProvisioning != queuedProvisioning || pool.Status.QueuedCleaning != queuedCleaning
+	pool.Status.Size = len(poolCirs)
+	pool.Status.PendingEvictions = pendingEvictions
+	pool.Status.QueuedProvisioning = queuedProvisioning
+	pool.Status.QueuedCleaning = queuedCleaning
+
+	var targetSize int
+	var activeSchedule string
+	if pool.Spec.Autoscaling != nil {
+		var reason string
+		targetSize, reason = autoscale(pool, poolCirs, time.Now())
+		if reason != "" {
+			r.recordScaling(pool, targetSize, reason, logger)
+			statusChanged = true
+		}
+	} else {
+		targetSize, activeSchedule = scheduledSize(pool, time.Now(), logger)
+		if pool.Status.Autoscaling != nil {
+			pool.Status.Autoscaling = nil
+			statusChanged = true
 		}
 	}
+	if pool.Status.EffectiveSize != targetSize || pool.Status.ActiveSchedule != activeSchedule {
+		if pool.Status.ActiveSchedule != activeSchedule {
+			logger.Info("Active schedule changed", "From", pool.Status.ActiveSchedule, "To", activeSchedule, "Size", targetSize)
+		}
+		pool.Status.EffectiveSize = targetSize
+		pool.Status.ActiveSchedule = activeSchedule
+		statusChanged = true
+	}
 
-	// Update status if required with the current effective number of resources
-	if pool.Status.Size != len(poolCirs) {
-		pool.Status.Size = len(poolCirs)
+	if statusChanged {
 		if err = r.savePoolStatus(pool); err != nil {
 			logger.Error(err, "error while updating status")
 			return false, err
 		}
 	}
 
-	if pool.Spec.Size == len(poolCirs) {
-		return false, nil
+	if targetSize == len(poolCirs) {
+		return false, r.reportSizeUnreachable(pool, 0, 0, nil, logger)
 	}
 
 	numCirSelected := 0
 
-	if pool.Spec.Size > len(poolCirs) {
-		logger.Info("Adding resources to the pool", "Expected", pool.Spec.Size, "Found", len(poolCirs))
+	if targetSize > len(poolCirs) {
+		logger.Info("Adding resources to the pool", "Expected", targetSize, "Found", len(poolCirs))
 
-		baseCirNo := r.getHighestResourceNumeral(allCirs.Items, logger) + 1
+		numCirRequired := targetSize - len(poolCirs)
 
-		for i := baseCirNo; i < baseCirNo+(pool.Spec.Size-len(poolCirs)); i++ {
-			logger.Info("Creating new CIResource", "CIResource", i)
-			if err = r.createCIResource(pool, i, logger); err != nil {
-				logger.Error(err, "error while creating new CIResource", "CIResource", i)
+		var createErr error
+		for i := 0; i < numCirRequired; i++ {
+			var cir *ofcirv1.CIResource
+			if cir, createErr = r.createCIResource(pool); createErr != nil {
+				logger.Error(createErr, "error while creating new CIResource")
 				continue
 			}
+			logger.Info("Created new CIResource", "CIResource", cir.Name)
 			numCirSelected++
 		}
+
+		if err = r.reportSizeUnreachable(pool, numCirRequired-numCirSelected, numCirRequired, createErr, logger); err != nil {
+			return false, err
+		}
 	} else {
-		numCirSelected, err = r.deleteCIResources(pool.Spec.Size, poolCirs, pool.Namespace, logger)
+		numCirSelected, err = r.deleteCIResources(pool, targetSize, poolCirs, logger)
 		if err != nil {
 			return false, err
 		}
+		if err = r.reportSizeUnreachable(pool, 0, 0, nil, logger); err != nil {
+			return false, err
+		}
 	}
 
 	return numCirSelected > 0, err
 }
 
-func (r *CIPoolReconciler) deleteCIResources(targetSize int, poolCirs []ofcirv1.CIResource, poolNamespace string, logger logr.Logger) (int, error) {
-	logger.Info("Removing resources from the pool", "Expected", targetSize, "Found", len(poolCirs))
+// reportSizeUnreachable updates the SizeUnreachable condition with the number of resources
+// that could not be created. The webhooks are notified only when the shortfall starts or
+// changes, not on every attempt
+func (r *CIPoolReconciler) reportSizeUnreachable(pool *ofcirv1.CIPool, missing int, required int, createErr error, logger logr.Logger) error {
+	// The condition is reported once the pool failed to grow at least once
+	if missing == 0 && meta.FindStatusCondition(pool.Status.Conditions, ofcirv1.PoolConditionSizeUnreachable) == nil {
+		return nil
+	}
 
-	numCirSelected := 0
+	condition := sizeUnreachableCondition(pool, missing, required)
+	if !meta.SetStatusCondition(&pool.Status.Conditions, condition) {
+		return nil
+	}
+	logger.Info("Pool size condition changed", "Status", condition.Status, "Reason", condition.Reason)
+	if missing > 0 {
+		r.Notifier.Notify(context.TODO(), notifier.Event{
+			Type:      ofcirv1.EventPoolSizeUnreachable,
+			Namespace: pool.Namespace,
+			Pool:      pool.Name,
+			Message:   fmt.Sprintf("created %d of %d required resources: %v", required-missing, required, createErr),
+		})
+	}
 
-	// Select candidates for eviction starting from the newest resources
-	for i := len(poolCirs) - 1; i >= 0; i-- {
-		cir := poolCirs[i]
+	if err := r.savePoolStatus(pool); err != nil {
+		logger.Error(err, "error while updating status")
+		return err
+	}
+	return nil
+}
 
-		switch cir.Status.State {
-		case ofcirv1.StateAvailable, ofcirv1.StateMaintenance, ofcirv1.StateError:
-			labels := cir.GetLabels()
-			if labels == nil {
-				labels = make(map[string]string)
-			}
-			if _, found := labels[ofcirv1.EvictionLabel]; found {
-				continue
-			}
-			logger.Info("CIResource selected for eviction", "Name", cir.Name)
+// sizeUnreachableCondition reports how many of the required resources the pool
+// could not create
+func sizeUnreachableCondition(pool *ofcirv1.CIPool, missing int, required int) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               ofcirv1.PoolConditionSizeUnreachable,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: pool.Generation,
+		Reason:             ofcirv1.ReasonSizeReachable,
+		Message:            "the pool resources were created",
+	}
+	if missing > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ofcirv1.ReasonCreationFailed
+		condition.Message = fmt.Sprintf("could not create %d of %d required resources", missing, required)
+	}
+	return condition
+}
 
-			labels[ofcirv1.EvictionLabel] = "true"
-			cir.SetLabels(labels)
+// recordScaling stores the autoscaler decision in the pool status, and reports it as an event
+func (r *CIPoolReconciler) recordScaling(pool *ofcirv1.CIPool, size int, reason string, logger logr.Logger) {
+	previous := pool.Spec.Size
+	if pool.Status.Autoscaling != nil {
+		previous = pool.Status.Autoscaling.DesiredSize
+	}
+	logger.Info("Autoscaling the pool", "From", previous, "To", size, "Reason", reason)
 
-			if err := r.Update(context.TODO(), &cir); err != nil {
-				logger.Error(err, "error while selecting CIResource to be removed, skipping it", "CIResource", cir.Name)
-				continue
+	now := metav1.Now()
+	pool.Status.Autoscaling = &ofcirv1.AutoscalingStatus{
+		DesiredSize:   size,
+		LastScaleTime: &now,
+		Reason:        reason,
+	}
+
+	if r.Recorder == nil {
+		return
+	}
+	eventReason := "ScaledUp"
+	if size < previous {
+		eventReason = "ScaledDown"
+	}
+	r.Recorder.Eventf(pool, nil, v1.EventTypeNormal, eventReason, "Autoscale", "Desired size changed from %d to %d: %s", previous, size, reason)
+}
+
+// checkPoolSecret updates the SecretReady condition of the pool, reporting
+// a missing secret or an invalid provider configuration
+func (r *CIPoolReconciler) checkPoolSecret(ctx context.Context, pool *ofcirv1.CIPool, logger logr.Logger) error {
+	secret, found, err := getPoolSecret(ctx, r.Client, pool)
+	if err != nil {
+		logger.Error(err, "could not get CIPool secret", "Secret", pool.GetSecretName())
+		return err
+	}
+
+	condition := secretReadyCondition(pool, secret, found)
+	if !meta.SetStatusCondition(&pool.Status.Conditions, condition) {
+		return nil
+	}
+	logger.Info("Pool secret condition changed", "Status", condition.Status, "Reason", condition.Reason)
+	if condition.Status == metav1.ConditionFalse && r.Recorder != nil {
+		r.Recorder.Eventf(pool, nil, v1.EventTypeWarning, condition.Reason, "CheckSecret", condition.Message)
+	}
+
+	if err := r.savePoolStatus(pool); err != nil {
+		logger.Error(err, "error while updating status")
+		return err
+	}
+	return nil
+}
+
+// drainPool keeps track of the pool resources still in use, and moves the pool
+// offline once all of them have been released
+func (r *CIPoolReconciler) drainPool(pool *ofcirv1.CIPool, logger logr.Logger) (ctrl.Result, error) {
+	poolCirs, err := listPoolResources(context.TODO(), r.Client, pool)
+	if err != nil {
+		logger.Error(err, "failed to list the pool CIResources")
+		return ctrl.Result{}, err
+	}
+
+	inUse := 0
+	for _, c := range poolCirs {
+		// A resource requested but not yet in use must be waited too
+		if isInUse(c) {
+			inUse++
+		}
+	}
+
+	if inUse > 0 {
+		logger.Info("pool is draining", "InUse", inUse)
+		if pool.Status.InUse != inUse {
+			pool.Status.InUse = inUse
+			if err := r.savePoolStatus(pool); err != nil {
+				logger.Error(err, "error while updating status")
+				return ctrl.Result{}, err
 			}
+		}
+		return ctrl.Result{RequeueAfter: defaultCIPoolRetryDelay}, nil
+	}
 
-			// Check if enough instances have been selected for eviction
-			numCirSelected++
+	logger.Info("pool drained, moving it offline")
+	pool.Spec.State = ofcirv1.StatePoolOffline
+	if err := r.Update(context.TODO(), pool); err != nil {
+		return ctrl.Result{}, err
+	}
+	pool.Status.State = ofcirv1.StatePoolOffline
+	pool.Status.InUse = 0
+	if err := r.savePoolStatus(pool); err != nil {
+		logger.Error(err, "error while updating status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
 
+// deleteCIResources selects the resources to be removed for reaching the target size,
+// according to the pool eviction strategy. The idle resources are preferred, while
+// the ones in use are deleted only after being released
+func (r *CIPoolReconciler) deleteCIResources(pool *ofcirv1.CIPool, targetSize int, poolCirs []ofcirv1.CIResource, logger logr.Logger) (int, error) {
+	logger.Info("Removing resources from the pool", "Expected", targetSize, "Found", len(poolCirs))
+
+	numCirRequired := len(poolCirs) - targetSize
+	var idle, inUse []ofcirv1.CIResource
+	for _, cir := range poolCirs {
+		// Resources already selected are still part of the pool until their removal completes
+		if isSelectedForEviction(cir) {
+			numCirRequired--
+			continue
+		}
+
+		switch {
+		case isAcquiring(cir):
+			// The resource may be already handed to a job, so it's evaluated once in use
+			logger.Info("CIResource ignored for eviction, acquisition in progress", "CIResource", cir.Name)
+		case isInUse(cir):
+			inUse = append(inUse, cir)
+		case cir.Status.State == ofcirv1.StateAvailable, cir.Status.State == ofcirv1.StateMaintenance, cir.Status.State == ofcirv1.StateError:
+			idle = append(idle, cir)
 		default:
 			logger.Info("CIResource ignored for eviction", "CIResource", cir.Name, "State", cir.Status.State)
 		}
+	}
+
+	sortForEviction(idle, pool.GetEvictionStrategy())
+	sortForEviction(inUse, pool.GetEvictionStrategy())
 
-		if numCirSelected >= len(poolCirs)-targetSize {
+	numCirSelected := 0
+	for _, cir := range append(idle, inUse...) {
+		if numCirSelected >= numCirRequired {
 			break
 		}
+
+		logger.Info("CIResource selected for eviction", "Name", cir.Name, "State", cir.Status.State)
+		labels := cir.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[ofcirv1.EvictionLabel] = "true"
+		cir.SetLabels(labels)
+
+		if err := r.Update(context.TODO(), &cir); err != nil {
+			logger.Error(err, "error while selecting CIResource to be removed, skipping it", "CIResource", cir.Name)
+			continue
+		}
+		numCirSelected++
 	}
 
-	err := r.DeleteAllOf(context.TODO(), &ofcirv1.CIResource{}, client.InNamespace(poolNamespace), client.MatchingLabels{ofcirv1.EvictionLabel: "true"})
+	// The finalizer keeps the resources in use until they get released
+	err := r.DeleteAllOf(context.TODO(), &ofcirv1.CIResource{}, client.InNamespace(pool.Namespace), client.MatchingLabels{ofcirv1.EvictionLabel: "true"})
 	if err != nil {
 		logger.Error(err, "error while batch deleting CIResources")
 		return numCirSelected, err
@@ -272,12 +473,69 @@ func (r *CIPoolReconciler) deleteCIResources(targetSize int, poolCirs []ofcirv1.
 	return numCirSelected, nil
 }
 
-func (r *CIPoolReconciler) createCIResource(pool *ofcirv1.CIPool, cirNo int, logger logr.Logger) error {
+// isSelectedForEviction returns true if the resource is going to be removed
+func isSelectedForEviction(cir ofcirv1.CIResource) bool {
+	_, found := cir.GetLabels()[ofcirv1.EvictionLabel]
+	return found || !cir.DeletionTimestamp.IsZero()
+}
+
+// isInUse returns true if the resource is used by a job, or it has just been acquired
+func isInUse(cir ofcirv1.CIResource) bool {
+	return cir.Status.State == ofcirv1.StateInUse || cir.Spec.State == ofcirv1.StateInUse
+}
+
+// isAcquiring returns true if the resource has been requested by a job, but it's
+// not in use yet
+func isAcquiring(cir ofcirv1.CIResource) bool {
+	return cir.Spec.State == ofcirv1.StateInUse && cir.Status.State != ofcirv1.StateInUse
+}
+
+// sortForEviction orders the resources according to the eviction strategy, the
+// first ones being the first to be removed
+func sortForEviction(cirs []ofcirv1.CIResource, strategy ofcirv1.EvictionStrategy) {
+	newer := func(a, b ofcirv1.CIResource) bool {
+		if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+			return b.CreationTimestamp.Before(&a.CreationTimestamp)
+		}
+		return a.Name > b.Name
+	}
+
+	sort.SliceStable(cirs, func(i, j int) bool {
+		switch strategy {
+		case ofcirv1.EvictOldest:
+			return newer(cirs[j], cirs[i])
+		case ofcirv1.EvictLeastHealthy:
+			if ei, ej := cirs[i].Status.State == ofcirv1.StateError, cirs[j].Status.State == ofcirv1.StateError; ei != ej {
+				return ei
+			}
+			if ui, uj := unhealthiness(cirs[i]), unhealthiness(cirs[j]); ui != uj {
+				return ui > uj
+			}
+		}
+		return newer(cirs[i], cirs[j])
+	})
+}
+
+// unhealthiness scores how much a resource has been unreliable recently
+func unhealthiness(cir ofcirv1.CIResource) int {
+	score := cir.Status.Failures + cir.Status.Reprovisions
+	if hc := cir.Status.HealthCheck; hc != nil && !hc.Healthy {
+		score += hc.Failures
+	}
+	return score
+}
+
+// createCIResource adds a new resource to the pool. Its name is generated by the
+// API server from the pool name, so that it cannot collide with the existing ones
+func (r *CIPoolReconciler) createCIResource(pool *ofcirv1.CIPool) (*ofcirv1.CIResource, error) {
 
 	cir := &ofcirv1.CIResource{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("cir-%04d", cirNo),
-			Namespace: pool.Namespace,
+			GenerateName: fmt.Sprintf("%s-", pool.Name),
+			Namespace:    pool.Namespace,
+			Labels: map[string]string{
+				ofcirv1.PoolLabel: pool.Name,
+			},
 		},
 		Spec: ofcirv1.CIResourceSpec{
 			PoolRef: v1.LocalObjectReference{
@@ -288,30 +546,38 @@ func (r *CIPoolReconciler) createCIResource(pool *ofcirv1.CIPool, cirNo int, log
 			Type:  pool.Spec.Type,
 		},
 	}
+	if err := controllerutil.SetControllerReference(pool, cir, r.Client.Scheme()); err != nil {
+		return nil, err
+	}
 
-	return r.Create(context.TODO(), cir)
+	return cir, r.Create(context.TODO(), cir)
 }
 
-func (r *CIPoolReconciler) getHighestResourceNumeral(cirs []ofcirv1.CIResource, logger logr.Logger) int {
-
-	highestNumeral := 0
-	for _, cir := range cirs {
-		lastIndex := strings.LastIndex(cir.Name, "-")
-		if lastIndex == -1 {
-			logger.Info("CIResource name malformed, skipping", "CIResource", cir.Name)
+// adoptCIResources sets the pool as the controller owner, and the pool label, on
+// the resources created by the previous versions. They are not renamed
+func (r *CIPoolReconciler) adoptCIResources(pool *ofcirv1.CIPool, poolCirs []ofcirv1.CIResource, logger logr.Logger) {
+	for i := range poolCirs {
+		cir := &poolCirs[i]
+		if metav1.GetControllerOf(cir) != nil && cir.Labels[ofcirv1.PoolLabel] == pool.Name {
 			continue
 		}
-		currentNumeral, err := strconv.Atoi(cir.Name[lastIndex+1:])
-		if err != nil {
-			logger.Info("CIResource name malformed, skipping", "CIResource", cir.Name)
-			continue
+
+		logger.Info("Adopting CIResource", "CIResource", cir.Name)
+		if metav1.GetControllerOf(cir) == nil {
+			if err := controllerutil.SetControllerReference(pool, cir, r.Client.Scheme()); err != nil {
+				logger.Error(err, "error while adopting CIResource", "CIResource", cir.Name)
+				continue
+			}
 		}
-		if highestNumeral < currentNumeral {
-			highestNumeral = currentNumeral
+		if cir.Labels == nil {
+			cir.Labels = make(map[string]string)
 		}
-	}
+		cir.Labels[ofcirv1.PoolLabel] = pool.Name
 
-	return highestNumeral
+		if err := r.Update(context.TODO(), cir); err != nil {
+			logger.Error(err, "error while adopting CIResource", "CIResource", cir.Name)
+		}
+	}
 }
 
 func (r *CIPoolReconciler) savePoolStatus(pool *ofcirv1.CIPool) error {
@@ -323,11 +589,40 @@ func (r *CIPoolReconciler) savePoolStatus(pool *ofcirv1.CIPool) error {
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *CIPoolReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Allows listing the resources of a pool without scanning the whole namespace
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &ofcirv1.CIResource{}, poolRefField, poolRefIndexer); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(controller.Options{
 			// The controller will perform batch create/delete on CIResources
 			MaxConcurrentReconciles: 1,
 		}).
 		For(&ofcirv1.CIPool{}).
+		Owns(&ofcirv1.CIResource{}, builder.WithPredicates(ownedResourcePredicate())).
+		Watches(&v1.Secret{}, handler.EnqueueRequestsFromMapFunc(requestsForSecretPools(mgr.GetClient()))).
 		Complete(r)
 }
+
+// ownedResourcePredicate filters out the CIResource status updates not changing
+// their state, since they are not relevant for the pool
+func ownedResourcePredicate() predicate.Predicate {
+	return predicate.Or(
+		predicate.GenerationChangedPredicate{},
+		predicate.LabelChangedPredicate{},
+		predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldCir, ok := e.ObjectOld.(*ofcirv1.CIResource)
+				if !ok {
+					return false
+				}
+				newCir, ok := e.ObjectNew.(*ofcirv1.CIResource)
+				if !ok {
+					return false
+				}
+				return oldCir.Status.State != newCir.Status.State
+			},
+		},
+	)
+}
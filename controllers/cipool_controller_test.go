@@ -2,14 +2,30 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
 	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/openshift/ofcir/pkg/notifier"
 	"github.com/openshift/ofcir/pkg/reconcilertest"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 func TestCIPoolController(t *testing.T) {
@@ -47,13 +63,488 @@ func TestCIPoolController(t *testing.T) {
 					return obj.Status.Size == 5 && len(cirs.Items) == 5
 				}, "wait for 5 CIResources"),
 		},
+		{
+			name: "pool owns its resources",
+			testCase: newCIPoolScenario().
+				Setup(scenarioPoolWithForeignCir).
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					return obj.Status.Size == 2
+				}, "wait for 2 CIResources").
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIPool) {
+					var cirs ofcirv1.CIResourceList
+					assert.NoError(t, client.List(context.Background(), &cirs))
+					assert.Len(t, cirs.Items, 3)
+
+					for _, c := range cirs.Items {
+						owner := metav1.GetControllerOf(&c)
+						if c.Spec.PoolRef.Name != obj.Name {
+							assert.Nil(t, owner, c.Name)
+							continue
+						}
+						// The existing resource is adopted without being renamed, the new one is
+						// created with the owner reference and a name generated from the pool one
+						assert.NotNil(t, owner, c.Name)
+						assert.Equal(t, obj.Name, owner.Name)
+						assert.Equal(t, obj.UID, owner.UID)
+						assert.Equal(t, obj.Name, c.Labels[ofcirv1.PoolLabel], c.Name)
+						if c.Name != "cir-0" {
+							assert.True(t, strings.HasPrefix(c.Name, "cipool-test-"), c.Name)
+						}
+					}
+
+					// The pool resources can be selected by label
+					assert.NoError(t, client.List(context.Background(), &cirs, poolSelector(obj.Name)))
+					assert.Len(t, cirs.Items, 2)
+				}),
+		},
+		{
+			name: "pool draining",
+			testCase: newCIPoolScenario().
+				Setup(scenarioDrainingPool).
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					return obj.Status.State == ofcirv1.StatePoolDraining && obj.Status.InUse == 2
+				}, "wait for the in use resources to be reported").
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIPool) {
+					cir := &ofcirv1.CIResource{}
+					assert.NoError(t, client.Get(context.Background(), types.NamespacedName{Name: "cir-1", Namespace: obj.Namespace}, cir))
+					cir.Spec.State = ofcirv1.StateAvailable
+					assert.NoError(t, client.Update(context.Background(), cir))
+					cir.Status.State = ofcirv1.StateCleaning
+					assert.NoError(t, client.Status().Update(context.Background(), cir))
+				}, "release one resource").
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					return obj.Status.State == ofcirv1.StatePoolDraining && obj.Status.InUse == 1
+				}, "wait for one resource in use").
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIPool) {
+					cir := &ofcirv1.CIResource{}
+					assert.NoError(t, client.Get(context.Background(), types.NamespacedName{Name: "cir-2", Namespace: obj.Namespace}, cir))
+					cir.Spec.State = ofcirv1.StateAvailable
+					assert.NoError(t, client.Update(context.Background(), cir))
+				}, "cancel the pending request").
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					var cirs ofcirv1.CIResourceList
+					client.List(context.Background(), &cirs)
+
+					// The pool was not resized while draining
+					return obj.Status.State == ofcirv1.StatePoolOffline && obj.Spec.State == ofcirv1.StatePoolOffline &&
+						obj.Status.InUse == 0 && len(cirs.Items) == 3
+				}, "wait for the pool to be offline"),
+		},
+		{
+			name: "autoscaled pool",
+			testCase: newCIPoolScenario().
+				Setup(scenarioWithEmptyPool).
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					return obj.Status.State == ofcirv1.StatePoolAvailable
+				}, "start with an empty pool").
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIPool) {
+					obj.Spec.Autoscaling = &ofcirv1.Autoscaling{MinSize: 2, MaxSize: 5, TargetAvailable: 1}
+					assert.NoError(t, client.Update(context.Background(), obj))
+				}, "enable autoscaling").
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					var cirs ofcirv1.CIResourceList
+					client.List(context.Background(), &cirs)
+
+					return obj.Status.Autoscaling != nil && obj.Status.Autoscaling.DesiredSize == 2 && len(cirs.Items) == 2
+				}, "wait for the minimum size").
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIPool) {
+					assert.Equal(t, "size 0 out of the [2, 5] bounds", obj.Status.Autoscaling.Reason)
+					assert.Equal(t, 2, obj.Status.EffectiveSize)
+					assert.Equal(t, 0, obj.Spec.Size)
+				}),
+		},
+		{
+			name: "shrinking a busy pool",
+			testCase: newCIPoolScenario().
+				Setup(scenarioBusyPool).
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					return obj.Status.PendingEvictions == 1
+				}, "wait for the pending eviction").
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIPool) {
+					var cirs ofcirv1.CIResourceList
+					assert.NoError(t, client.List(context.Background(), &cirs))
+
+					// The idle resource is selected first, then the newest in use one. Both
+					// are kept by the finalizer until the CIResource controller removes them
+					assert.Len(t, cirs.Items, 3)
+					assert.True(t, cirs.Items[0].DeletionTimestamp.IsZero())
+					assert.False(t, cirs.Items[1].DeletionTimestamp.IsZero())
+					assert.False(t, cirs.Items[2].DeletionTimestamp.IsZero())
+					assert.Equal(t, 3, obj.Status.Size)
+				}),
+		},
+		{
+			name: "shrinking a pool with an acquisition in progress",
+			testCase: newCIPoolScenario().
+				Setup(scenarioAcquiringPool).
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					return obj.Status.PendingEvictions == 1
+				}, "wait for the pending eviction").
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIPool) {
+					var cirs ofcirv1.CIResourceList
+					assert.NoError(t, client.List(context.Background(), &cirs))
+
+					// The resource being acquired is skipped, even if the pool size is not reached
+					assert.Len(t, cirs.Items, 3)
+					assert.False(t, cirs.Items[0].DeletionTimestamp.IsZero())
+					assert.False(t, cirs.Items[1].DeletionTimestamp.IsZero())
+					assert.True(t, cirs.Items[2].DeletionTimestamp.IsZero())
+					assert.NotContains(t, cirs.Items[2].Labels, ofcirv1.EvictionLabel)
+				}),
+		},
+		{
+			name: "queued provisioning and cleaning",
+			testCase: newCIPoolScenario().
+				Setup(scenarioThrottledPool).
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					return obj.Status.Size == 5
+				}, "wait for the pool status").
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIPool) {
+					// One resource is being provisioned, so only one of the two waiting can start
+					assert.Equal(t, 1, obj.Status.QueuedProvisioning)
+					assert.Equal(t, 0, obj.Status.QueuedCleaning)
+				}),
+		},
+		{
+			name: "pool secret condition",
+			testCase: newCIPoolScenario().
+				Setup(scenarioWithEmptyPool).
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					return meta.IsStatusConditionTrue(obj.Status.Conditions, ofcirv1.PoolConditionSecretReady)
+				}, "wait for the secret to be found").
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIPool) {
+					secret := &corev1.Secret{}
+					assert.NoError(t, client.Get(context.Background(), types.NamespacedName{Name: "cipool-test-secret", Namespace: obj.Namespace}, secret))
+					secret.Data = map[string][]byte{ofcirv1.ProviderConfigSecretKey: []byte(`{"endpoint": `)}
+					assert.NoError(t, client.Update(context.Background(), secret))
+				}, "rotate the secret with a broken config").
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					condition := meta.FindStatusCondition(obj.Status.Conditions, ofcirv1.PoolConditionSecretReady)
+					return condition != nil && condition.Reason == ofcirv1.ReasonInvalidConfig
+				}, "wait for the invalid config").
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIPool) {
+					obj.Spec.SecretRef = &corev1.LocalObjectReference{Name: "missing"}
+					assert.NoError(t, client.Update(context.Background(), obj))
+				}, "reference a missing secret").
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					condition := meta.FindStatusCondition(obj.Status.Conditions, ofcirv1.PoolConditionSecretReady)
+					return condition != nil && condition.Reason == ofcirv1.ReasonSecretNotFound
+				}, "wait for the missing secret").
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIPool) {
+					condition := meta.FindStatusCondition(obj.Status.Conditions, ofcirv1.PoolConditionSecretReady)
+					assert.Equal(t, metav1.ConditionFalse, condition.Status)
+					assert.Equal(t, "secret missing not found, the provider is not configured", condition.Message)
+				}),
+		},
+		{
+			name: "pool budget condition",
+			testCase: newCIPoolScenario().
+				Setup(scenarioPoolOverBudget).
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					return meta.IsStatusConditionTrue(obj.Status.Conditions, ofcirv1.PoolConditionBudgetExceeded)
+				}, "wait for the budget to be exceeded").
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIPool) {
+					assert.Equal(t, "1.5", obj.Status.Cost.HourlyRate)
+					obj.Spec.Cost = nil
+					assert.NoError(t, client.Update(context.Background(), obj))
+				}, "remove the budget").
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIPool) bool {
+					return meta.FindStatusCondition(obj.Status.Conditions, ofcirv1.PoolConditionBudgetExceeded) == nil
+				}, "wait for the budget condition to be removed"),
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, tc.testCase.Test)
 	}
 }
 
+func TestRequestsForSecret(t *testing.T) {
+	cip, secret := cipoolWithSecret()
+	other := cipool().name("other")
+	other.Spec.SecretRef = &corev1.LocalObjectReference{Name: secret.Name}
+	unrelated := cipool().name("unrelated")
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, ofcirv1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		cip.build(), other.build(), unrelated.build(), secret,
+		cir("cir-0").pool(cip.Name).build(),
+		cir("cir-1").pool(other.Name).build(),
+		cir("cir-2").pool(unrelated.Name).build(),
+	).Build()
+
+	pools := requestsForSecretPools(c)(context.Background(), secret)
+	assert.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: defaultTestNs, Name: cip.Name}},
+		{NamespacedName: types.NamespacedName{Namespace: defaultTestNs, Name: "other"}},
+	}, pools)
+
+	cirs := requestsForSecretResources(c)(context.Background(), secret)
+	assert.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: defaultTestNs, Name: "cir-0"}},
+		{NamespacedName: types.NamespacedName{Namespace: defaultTestNs, Name: "cir-1"}},
+	}, cirs)
+}
+
 func newCIPoolScenario() reconcilertest.Scenario[CIPoolReconciler, ofcirv1.CIPool, *ofcirv1.CIPool] {
 	return reconcilertest.New[CIPoolReconciler, ofcirv1.CIPool]().
-		WithSchemes(ofcirv1.AddToScheme, corev1.AddToScheme)
+		WithSchemes(ofcirv1.AddToScheme, corev1.AddToScheme).
+		WithIndex(&ofcirv1.CIResource{}, poolRefField, poolRefIndexer)
+}
+
+func poolSelector(pool string) client.ListOption {
+	return client.MatchingLabels{ofcirv1.PoolLabel: pool}
+}
+
+func scenarioPoolWithForeignCir() []client.Object {
+	cip, secret := cipoolWithSecret()
+	cip.size(2)
+	cip.UID = "cipool-test-uid"
+	cip.Finalizers = []string{ofcirv1.OfcirFinalizer}
+
+	return []client.Object{
+		cip.build(), secret,
+		cir("cir-0").pool(cip.Name).build(),
+		cir("cir-1").pool("other").build(),
+	}
+}
+
+func scenarioDrainingPool() []client.Object {
+	cip, secret := cipoolWithSecret()
+	cip.size(5).state(ofcirv1.StatePoolDraining)
+	cip.Finalizers = []string{ofcirv1.OfcirFinalizer}
+
+	return []client.Object{
+		cip.build(), secret,
+		cir("cir-0").pool(cip.Name).currentState(ofcirv1.StateAvailable).requiredState(ofcirv1.StateAvailable).build(),
+		cir("cir-1").pool(cip.Name).currentState(ofcirv1.StateInUse).requiredState(ofcirv1.StateInUse).build(),
+		cir("cir-2").pool(cip.Name).currentState(ofcirv1.StateAvailable).requiredState(ofcirv1.StateInUse).build(),
+	}
+}
+
+func scenarioThrottledPool() []client.Object {
+	cip, secret := cipoolWithSecret()
+	cip.size(5)
+	cip.Spec.MaxConcurrentProvisioning = 2
+	cip.Spec.MaxConcurrentCleaning = 2
+	cip.Finalizers = []string{ofcirv1.OfcirFinalizer}
+
+	objects := []client.Object{cip.build(), secret}
+	for i, state := range []ofcirv1.CIResourceState{ofcirv1.StateProvisioningWait, ofcirv1.StateProvisioning, ofcirv1.StateProvisioning, ofcirv1.StateCleaning, ofcirv1.StateAvailable} {
+		objects = append(objects, cir(fmt.Sprintf("cir-%d", i)).pool(cip.Name).currentState(state).build())
+	}
+	return objects
+}
+
+func scenarioPoolOverBudget() []client.Object {
+	cip, secret := cipoolWithSecret()
+	cip.size(1)
+	cip.Spec.Cost = &ofcirv1.Cost{HourlyPrice: "1.5", MonthlyBudget: "100"}
+	cip.Status.Cost = &ofcirv1.CostStatus{
+		Month:         time.Now().UTC().Format("2006-01"),
+		Spent:         "100.5",
+		HourlyRate:    "1.5",
+		LastAccounted: metav1.Now(),
+	}
+	cip.Finalizers = []string{ofcirv1.OfcirFinalizer}
+
+	provisioned := cir("cir-0").pool(cip.Name).currentState(ofcirv1.StateAvailable).build()
+	provisioned.Status.ResourceId = "i-0123"
+	return []client.Object{cip.build(), secret, provisioned}
+}
+
+func scenarioBusyPool() []client.Object {
+	cip, secret := cipoolWithSecret()
+	cip.size(1)
+	cip.Finalizers = []string{ofcirv1.OfcirFinalizer}
+
+	objects := []client.Object{cip.build(), secret}
+	for i, state := range []ofcirv1.CIResourceState{ofcirv1.StateInUse, ofcirv1.StateInUse, ofcirv1.StateAvailable} {
+		cir := cir(fmt.Sprintf("cir-%d", i)).pool(cip.Name).currentState(state).requiredState(state)
+		cir.Finalizers = []string{ofcirv1.OfcirFinalizer}
+		objects = append(objects, cir.build())
+	}
+	return objects
+}
+
+func scenarioAcquiringPool() []client.Object {
+	cip, secret := cipoolWithSecret()
+	cip.size(1)
+	cip.Finalizers = []string{ofcirv1.OfcirFinalizer}
+
+	objects := []client.Object{cip.build(), secret}
+	for i, state := range []ofcirv1.CIResourceState{ofcirv1.StateAvailable, ofcirv1.StateInUse, ofcirv1.StateAvailable} {
+		cir := cir(fmt.Sprintf("cir-%d", i)).pool(cip.Name).currentState(state).requiredState(state)
+		cir.Finalizers = []string{ofcirv1.OfcirFinalizer}
+		objects = append(objects, cir.build())
+	}
+	// Requested by a job, but still available. Being the newest, it would be the
+	// first in use resource selected
+	objects[4].(*ofcirv1.CIResource).Spec.State = ofcirv1.StateInUse
+	return objects
+}
+
+func TestSortForEviction(t *testing.T) {
+	now := metav1.Now()
+	older := metav1.NewTime(now.Add(-time.Hour))
+
+	cirs := func() []ofcirv1.CIResource {
+		unhealthy := cir("cir-0").currentState(ofcirv1.StateAvailable).build()
+		unhealthy.CreationTimestamp = older
+		unhealthy.Status.HealthCheck = &ofcirv1.ProbeStatus{Healthy: false, Failures: 2}
+		broken := cir("cir-1").currentState(ofcirv1.StateError).build()
+		broken.CreationTimestamp = older
+		healthy := cir("cir-2").currentState(ofcirv1.StateAvailable).build()
+		healthy.CreationTimestamp = now
+		reprovisioned := cir("cir-3").currentState(ofcirv1.StateAvailable).build()
+		reprovisioned.CreationTimestamp = now
+		reprovisioned.Status.Reprovisions = 1
+		return []ofcirv1.CIResource{*unhealthy, *broken, *healthy, *reprovisioned}
+	}
+
+	tests := []struct {
+		strategy ofcirv1.EvictionStrategy
+		expected []string
+	}{
+		{
+			strategy: "",
+			expected: []string{"cir-3", "cir-2", "cir-1", "cir-0"},
+		},
+		{
+			strategy: ofcirv1.EvictNewest,
+			expected: []string{"cir-3", "cir-2", "cir-1", "cir-0"},
+		},
+		{
+			strategy: ofcirv1.EvictOldest,
+			expected: []string{"cir-0", "cir-1", "cir-2", "cir-3"},
+		},
+		{
+			strategy: ofcirv1.EvictLeastHealthy,
+			expected: []string{"cir-1", "cir-0", "cir-3", "cir-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			items := cirs()
+			sortForEviction(items, ofcirv1.CIPool{Spec: ofcirv1.CIPoolSpec{EvictionStrategy: tt.strategy}}.GetEvictionStrategy())
+
+			var names []string
+			for _, c := range items {
+				names = append(names, c.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestPoolSizeUnreachableNotified(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	cip, secret := cipoolWithSecret()
+	cip.size(2)
+	cip.Finalizers = []string{ofcirv1.OfcirFinalizer}
+	cip.Status.State = ofcirv1.StatePoolAvailable
+	webhook := &ofcirv1.CIWebhook{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook", Namespace: defaultTestNs},
+		Spec:       ofcirv1.CIWebhookSpec{URL: server.URL},
+	}
+
+	failCreation := true
+	scheme := runtime.NewScheme()
+	assert.NoError(t, ofcirv1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(cip.build(), secret, webhook).
+		WithStatusSubresource(cip.build()).
+		WithIndex(&ofcirv1.CIResource{}, poolRefField, poolRefIndexer).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if _, ok := obj.(*ofcirv1.CIResource); ok && failCreation {
+					return fmt.Errorf("quota exceeded")
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		}).Build()
+
+	n := notifier.NewNotifier(c, logr.Discard())
+	r := CIPoolReconciler{Client: c, Notifier: n}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: cip.Namespace, Name: cip.Name}}
+	pool := &ofcirv1.CIPool{}
+
+	// The shortfall is notified once, not on every reconcile
+	for i := 0; i < 3; i++ {
+		_, err := r.Reconcile(context.Background(), req)
+		assert.NoError(t, err)
+	}
+	n.Wait()
+	assert.Equal(t, int32(1), requests.Load())
+	assert.NoError(t, c.Get(context.Background(), req.NamespacedName, pool))
+	condition := meta.FindStatusCondition(pool.Status.Conditions, ofcirv1.PoolConditionSizeUnreachable)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "could not create 2 of 2 required resources", condition.Message)
+
+	failCreation = false
+	_, err := r.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	n.Wait()
+	assert.Equal(t, int32(1), requests.Load())
+	assert.NoError(t, c.Get(context.Background(), req.NamespacedName, pool))
+	condition = meta.FindStatusCondition(pool.Status.Conditions, ofcirv1.PoolConditionSizeUnreachable)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, ofcirv1.ReasonSizeReachable, condition.Reason)
+}
+
+func TestOwnedResourcePredicate(t *testing.T) {
+	old := cir("cir-0").currentState(ofcirv1.StateAvailable).build()
+	old.Generation = 1
+
+	tests := []struct {
+		name     string
+		update   func(c *ofcirv1.CIResource)
+		expected bool
+	}{
+		{
+			name: "status refresh",
+			update: func(c *ofcirv1.CIResource) {
+				now := metav1.Now()
+				c.Status.LastUpdated = &now
+				c.Status.HealthCheck = &ofcirv1.ProbeStatus{Healthy: true}
+			},
+			expected: false,
+		},
+		{
+			name: "state change",
+			update: func(c *ofcirv1.CIResource) {
+				c.Status.State = ofcirv1.StateInUse
+			},
+			expected: true,
+		},
+		{
+			name: "spec change",
+			update: func(c *ofcirv1.CIResource) {
+				c.Spec.State = ofcirv1.StateInUse
+				c.Generation++
+			},
+			expected: true,
+		},
+		{
+			name: "label change",
+			update: func(c *ofcirv1.CIResource) {
+				c.Labels = map[string]string{"foo": "bar"}
+			},
+			expected: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := old.DeepCopy()
+			tt.update(updated)
+			assert.Equal(t, tt.expected, ownedResourcePredicate().Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated}))
+		})
+	}
 }
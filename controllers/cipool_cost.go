@@ -0,0 +1,158 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+// How often the spend of a pool is updated, unless the price of its resources changes
+const defaultCostAccountingDelay = 5 * time.Minute
+
+// trackCost updates the spend of the pool resources in the pool status, and
+// the BudgetExceeded condition when a monthly budget is set
+func (r *CIPoolReconciler) trackCost(ctx context.Context, pool *ofcirv1.CIPool, logger logr.Logger) error {
+	poolCirs, err := listPoolResources(ctx, r.Client, pool)
+	if err != nil {
+		logger.Error(err, "failed to list the pool CIResources")
+		return err
+	}
+
+	statusChanged := accountCost(pool, poolCirs, time.Now())
+
+	if condition, ok := budgetCondition(pool); ok {
+		wasExceeded := pool.IsOverBudget()
+		if meta.SetStatusCondition(&pool.Status.Conditions, condition) {
+			statusChanged = true
+		}
+		if exceeded := condition.Status == metav1.ConditionTrue; exceeded != wasExceeded {
+			logger.Info("Pool budget condition changed", "Status", condition.Status, "Reason", condition.Reason)
+			if exceeded && r.Recorder != nil {
+				r.Recorder.Eventf(pool, nil, v1.EventTypeWarning, condition.Reason, "TrackCost", condition.Message)
+			}
+		}
+	} else if meta.RemoveStatusCondition(&pool.Status.Conditions, ofcirv1.PoolConditionBudgetExceeded) {
+		statusChanged = true
+	}
+
+	if !statusChanged {
+		return nil
+	}
+	if err := r.savePoolStatus(pool); err != nil {
+		logger.Error(err, "error while updating status")
+		return err
+	}
+	return nil
+}
+
+// accountCost adds to the pool status the spend since the last accounting, at the
+// hourly rate of that time, and records the current rate. The spend restarts from
+// zero every calendar month (UTC). Returns true if the status was changed
+func accountCost(pool *ofcirv1.CIPool, poolCirs []ofcirv1.CIResource, now time.Time) bool {
+	now = now.UTC()
+	month := now.Format("2006-01")
+	rate := ofcirv1.FormatAmount(hourlyRate(pool, poolCirs))
+
+	cost := pool.Status.Cost
+	if cost == nil {
+		// Nothing worth reporting yet
+		if rate == "0" && pool.Spec.Cost == nil {
+			return false
+		}
+		pool.Status.Cost = &ofcirv1.CostStatus{Month: month, Spent: "0", HourlyRate: rate, LastAccounted: metav1.NewTime(now)}
+		return true
+	}
+
+	elapsed := now.Sub(cost.LastAccounted.Time)
+	if cost.Month == month && cost.HourlyRate == rate && elapsed < defaultCostAccountingDelay {
+		return false
+	}
+
+	spent, _ := ofcirv1.ParseAmount(cost.Spent)
+	if cost.Month != month {
+		// Only the time elapsed in the current month is accounted
+		spent = 0
+		elapsed = min(elapsed, now.Sub(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)))
+	}
+	previousRate, _ := ofcirv1.ParseAmount(cost.HourlyRate)
+	spent += previousRate * max(elapsed, 0).Hours()
+
+	pool.Status.Cost = &ofcirv1.CostStatus{
+		Month:         month,
+		Spent:         ofcirv1.FormatAmount(spent),
+		HourlyRate:    rate,
+		LastAccounted: metav1.NewTime(now),
+	}
+	return true
+}
+
+// hourlyRate returns the hourly price of the resources currently held by the pool
+// on the provider. The price reported by the provider wins over the pool one
+func hourlyRate(pool *ofcirv1.CIPool, poolCirs []ofcirv1.CIResource) float64 {
+	rate := 0.0
+	for _, cir := range poolCirs {
+		if cir.Status.ResourceId == "" || cir.Status.ResourceId == ofcirv1.FallbackResourceID {
+			continue
+		}
+		price, err := ofcirv1.ParseAmount(cir.Status.HourlyPrice)
+		if err != nil || cir.Status.HourlyPrice == "" {
+			price = pool.GetHourlyPrice()
+		}
+		rate += price
+	}
+	return rate
+}
+
+// budgetCondition reports whether the pool spend reached its monthly budget. False
+// if the pool has no budget
+func budgetCondition(pool *ofcirv1.CIPool) (metav1.Condition, bool) {
+	budget, ok := pool.GetMonthlyBudget()
+	if !ok {
+		return metav1.Condition{}, false
+	}
+
+	spent := 0.0
+	month := time.Now().UTC().Format("2006-01")
+	if pool.Status.Cost != nil {
+		spent, _ = ofcirv1.ParseAmount(pool.Status.Cost.Spent)
+		month = pool.Status.Cost.Month
+	}
+
+	condition := metav1.Condition{
+		Type:               ofcirv1.PoolConditionBudgetExceeded,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: pool.Generation,
+		Reason:             ofcirv1.ReasonWithinBudget,
+		Message:            fmt.Sprintf("spent %s of the %s monthly budget in %s", ofcirv1.FormatAmount(spent), pool.Spec.Cost.MonthlyBudget, month),
+	}
+	if spent >= budget {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ofcirv1.ReasonBudgetExceeded
+		condition.Message = fmt.Sprintf("spent %s of the %s monthly budget in %s, no new resource is provisioned until the next month",
+			ofcirv1.FormatAmount(spent), pool.Spec.Cost.MonthlyBudget, month)
+	}
+	return condition, true
+}
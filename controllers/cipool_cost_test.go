@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAccountCost(t *testing.T) {
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	hourAgo := metav1.NewTime(now.Add(-time.Hour))
+	minuteAgo := metav1.NewTime(now.Add(-time.Minute))
+
+	// Resources holding a provider resource, with the given reported prices
+	cirs := func(prices ...string) []ofcirv1.CIResource {
+		var result []ofcirv1.CIResource
+		for i, p := range prices {
+			c := cir(fmt.Sprintf("cir-%d", i)).currentState(ofcirv1.StateAvailable).build()
+			c.Status.ResourceId = fmt.Sprintf("id-%d", i)
+			c.Status.HourlyPrice = p
+			result = append(result, *c)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name            string
+		cost            *ofcirv1.Cost
+		status          *ofcirv1.CostStatus
+		cirs            []ofcirv1.CIResource
+		expectedChanged bool
+		expected        *ofcirv1.CostStatus
+	}{
+		{
+			name: "no price known",
+			cirs: cirs("", ""),
+		},
+		{
+			name:            "first accounting",
+			cost:            &ofcirv1.Cost{HourlyPrice: "0.5"},
+			cirs:            cirs("", "2"),
+			expectedChanged: true,
+			expected:        &ofcirv1.CostStatus{Month: "2026-10", Spent: "0", HourlyRate: "2.5", LastAccounted: metav1.NewTime(now)},
+		},
+		{
+			name:     "accounted recently",
+			cost:     &ofcirv1.Cost{HourlyPrice: "0.5"},
+			status:   &ofcirv1.CostStatus{Month: "2026-10", Spent: "10", HourlyRate: "1", LastAccounted: minuteAgo},
+			cirs:     cirs("", ""),
+			expected: &ofcirv1.CostStatus{Month: "2026-10", Spent: "10", HourlyRate: "1", LastAccounted: minuteAgo},
+		},
+		{
+			name:            "rate changed",
+			cost:            &ofcirv1.Cost{HourlyPrice: "0.5"},
+			status:          &ofcirv1.CostStatus{Month: "2026-10", Spent: "10", HourlyRate: "1", LastAccounted: minuteAgo},
+			cirs:            cirs("", "", ""),
+			expectedChanged: true,
+			expected:        &ofcirv1.CostStatus{Month: "2026-10", Spent: "10.0167", HourlyRate: "1.5", LastAccounted: metav1.NewTime(now)},
+		},
+		{
+			name:            "periodic accounting",
+			status:          &ofcirv1.CostStatus{Month: "2026-10", Spent: "10", HourlyRate: "1.25", LastAccounted: hourAgo},
+			cirs:            cirs("1.25"),
+			expectedChanged: true,
+			expected:        &ofcirv1.CostStatus{Month: "2026-10", Spent: "11.25", HourlyRate: "1.25", LastAccounted: metav1.NewTime(now)},
+		},
+		{
+			name:            "new month",
+			status:          &ofcirv1.CostStatus{Month: "2026-09", Spent: "480", HourlyRate: "2", LastAccounted: metav1.NewTime(time.Date(2026, time.September, 30, 12, 0, 0, 0, time.UTC))},
+			cirs:            cirs("2"),
+			expectedChanged: true,
+			expected:        &ofcirv1.CostStatus{Month: "2026-10", Spent: "840", HourlyRate: "2", LastAccounted: metav1.NewTime(now)},
+		},
+		{
+			name: "fallback resources not provisioned",
+			cost: &ofcirv1.Cost{HourlyPrice: "3"},
+			cirs: func() []ofcirv1.CIResource {
+				result := cirs("", "")
+				result[0].Status.ResourceId = ofcirv1.FallbackResourceID
+				result[1].Status.ResourceId = ""
+				return result
+			}(),
+			expectedChanged: true,
+			expected:        &ofcirv1.CostStatus{Month: "2026-10", Spent: "0", HourlyRate: "0", LastAccounted: metav1.NewTime(now)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := cipool().build()
+			pool.Spec.Cost = tt.cost
+			pool.Status.Cost = tt.status
+
+			assert.Equal(t, tt.expectedChanged, accountCost(pool, tt.cirs, now))
+			assert.Equal(t, tt.expected, pool.Status.Cost)
+		})
+	}
+}
+
+func TestBudgetCondition(t *testing.T) {
+	pool := cipool().build()
+
+	_, ok := budgetCondition(pool)
+	assert.False(t, ok, "no budget")
+
+	pool.Spec.Cost = &ofcirv1.Cost{MonthlyBudget: "500"}
+	pool.Status.Cost = &ofcirv1.CostStatus{Month: "2026-10", Spent: "499.5"}
+	condition, ok := budgetCondition(pool)
+	assert.True(t, ok)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, ofcirv1.ReasonWithinBudget, condition.Reason)
+	assert.Equal(t, "spent 499.5 of the 500 monthly budget in 2026-10", condition.Message)
+
+	pool.Status.Cost.Spent = "500.25"
+	condition, _ = budgetCondition(pool)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, ofcirv1.ReasonBudgetExceeded, condition.Reason)
+	assert.Equal(t, "spent 500.25 of the 500 monthly budget in 2026-10, no new resource is provisioned until the next month", condition.Message)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/openshift/ofcir/pkg/schedule"
+)
+
+// scheduledSize returns the size required by the first active schedule of the pool,
+// and its name. The pool size is returned if no schedule is active
+func scheduledSize(pool *ofcirv1.CIPool, now time.Time, logger logr.Logger) (int, string) {
+	for _, s := range pool.Spec.Schedules {
+		cron, err := schedule.Parse(s.Start)
+		if err != nil {
+			logger.Error(err, "invalid schedule, skipping it", "Schedule", s.Name)
+			continue
+		}
+
+		loc := time.UTC
+		if s.Timezone != "" {
+			if loc, err = time.LoadLocation(s.Timezone); err != nil {
+				logger.Error(err, "invalid schedule timezone, skipping it", "Schedule", s.Name)
+				continue
+			}
+		}
+
+		if cron.ActiveAt(now, s.Duration.Duration, loc) {
+			return s.Size, s.Name
+		}
+	}
+	return pool.Spec.Size, ""
+}
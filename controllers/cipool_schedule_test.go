@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScheduledSize(t *testing.T) {
+	// 2026-10-19 is a Monday
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	pool := cipool().size(10).build()
+	pool.Spec.Schedules = []ofcirv1.SizeSchedule{
+		{Name: "invalid", Start: "0 6 * *", Duration: metav1.Duration{Duration: 24 * time.Hour}, Size: 1},
+		{Name: "unknown-timezone", Start: "* * * * *", Duration: metav1.Duration{Duration: time.Hour}, Timezone: "Mars/Olympus", Size: 2},
+		{Name: "weekdays", Start: "0 6 * * 1-5", Duration: metav1.Duration{Duration: 14 * time.Hour}, Size: 40},
+		{Name: "rome-mornings", Start: "0 7 * * *", Duration: metav1.Duration{Duration: 3 * time.Hour}, Timezone: "Europe/Rome", Size: 20},
+	}
+
+	tests := []struct {
+		name             string
+		time             time.Time
+		expectedSize     int
+		expectedSchedule string
+	}{
+		{
+			name:         "no active schedule",
+			time:         monday.Add(3 * time.Hour),
+			expectedSize: 10,
+		},
+		{
+			name:             "timezone schedule active",
+			time:             monday.Add(5 * time.Hour),
+			expectedSize:     20,
+			expectedSchedule: "rome-mornings",
+		},
+		{
+			name:             "first active schedule wins",
+			time:             monday.Add(7 * time.Hour),
+			expectedSize:     40,
+			expectedSchedule: "weekdays",
+		},
+		{
+			name:         "weekend",
+			time:         monday.AddDate(0, 0, 5).Add(12 * time.Hour),
+			expectedSize: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, name := scheduledSize(pool, tt.time, logr.Discard())
+			assert.Equal(t, tt.expectedSize, size)
+			assert.Equal(t, tt.expectedSchedule, name)
+		})
+	}
+}
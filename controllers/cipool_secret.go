@@ -0,0 +1,152 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/openshift/ofcir/pkg/providers"
+)
+
+// getPoolSecret retrieves the secret referenced by the pool. A missing secret is
+// not an error: an empty one is returned, and found is false
+func getPoolSecret(ctx context.Context, c client.Reader, pool *ofcirv1.CIPool) (*v1.Secret, bool, error) {
+	poolSecretKey := types.NamespacedName{
+		Namespace: pool.Namespace,
+		Name:      pool.GetSecretName(),
+	}
+	poolSecret := &v1.Secret{}
+	if err := c.Get(ctx, poolSecretKey, poolSecret); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, false, err
+		}
+		return &v1.Secret{}, false, nil
+	}
+	return poolSecret, true, nil
+}
+
+// secretReadyCondition reports whether the pool secret exists, and whether its
+// provider configuration (if any) passes the provider validation
+func secretReadyCondition(pool *ofcirv1.CIPool, secret *v1.Secret, found bool) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               ofcirv1.PoolConditionSecretReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: pool.Generation,
+		Reason:             ofcirv1.ReasonSecretFound,
+		Message:            fmt.Sprintf("secret %s found", pool.GetSecretName()),
+	}
+
+	if !found {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ofcirv1.ReasonSecretNotFound
+		condition.Message = fmt.Sprintf("secret %s not found, the provider is not configured", pool.GetSecretName())
+		return condition
+	}
+
+	if err := providers.ValidatePoolConfig(pool, secret.Data); err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ofcirv1.ReasonInvalidConfig
+		condition.Message = fmt.Sprintf("invalid `%s` field of secret %s: %v", ofcirv1.ProviderConfigSecretKey, pool.GetSecretName(), err)
+		if pool.Spec.ProviderConfig != nil {
+			condition.Message = fmt.Sprintf("invalid provider config of secret %s and spec.providerConfig: %v", pool.GetSecretName(), err)
+		}
+	}
+	return condition
+}
+
+// poolsForSecret returns the pools referencing the given secret
+func poolsForSecret(ctx context.Context, c client.Reader, secret client.Object) ([]ofcirv1.CIPool, error) {
+	pools := &ofcirv1.CIPoolList{}
+	if err := c.List(ctx, pools, client.InNamespace(secret.GetNamespace())); err != nil {
+		return nil, err
+	}
+
+	var referencing []ofcirv1.CIPool
+	for _, p := range pools.Items {
+		if p.GetSecretName() == secret.GetName() {
+			referencing = append(referencing, p)
+		}
+	}
+	return referencing, nil
+}
+
+// requestsForSecretPools maps a secret to the reconcile requests of the pools
+// referencing it, so that a credentials rotation is immediately picked up
+func requestsForSecretPools(c client.Reader) func(context.Context, client.Object) []reconcile.Request {
+	return func(ctx context.Context, secret client.Object) []reconcile.Request {
+		pools, err := poolsForSecret(ctx, c, secret)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "cannot list the pools referencing the secret", "Secret", secret.GetName())
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, p := range pools {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: p.Namespace, Name: p.Name},
+			})
+		}
+		return requests
+	}
+}
+
+// requestsForSecretResources maps a secret to the reconcile requests of the
+// resources belonging to the pools referencing it
+func requestsForSecretResources(c client.Reader) func(context.Context, client.Object) []reconcile.Request {
+	return func(ctx context.Context, secret client.Object) []reconcile.Request {
+		logger := log.FromContext(ctx)
+
+		pools, err := poolsForSecret(ctx, c, secret)
+		if err != nil {
+			logger.Error(err, "cannot list the pools referencing the secret", "Secret", secret.GetName())
+			return nil
+		}
+		if len(pools) == 0 {
+			return nil
+		}
+		poolNames := map[string]bool{}
+		for _, p := range pools {
+			poolNames[p.Name] = true
+		}
+
+		cirs := &ofcirv1.CIResourceList{}
+		if err := c.List(ctx, cirs, client.InNamespace(secret.GetNamespace())); err != nil {
+			logger.Error(err, "cannot list the resources of the pools referencing the secret", "Secret", secret.GetName())
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, cir := range cirs.Items {
+			if poolNames[cir.Spec.PoolRef.Name] {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: cir.Namespace, Name: cir.Name},
+				})
+			}
+		}
+		return requests
+	}
+}
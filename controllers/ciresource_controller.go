@@ -28,21 +28,31 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/go-logr/logr"
 	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/openshift/ofcir/pkg/notifier"
+	"github.com/openshift/ofcir/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // CIResourceReconciler reconciles a CIResource object
 type CIResourceReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Sends the relevant changes to the subscribed webhooks, if set
+	Notifier *notifier.Notifier
 }
 
-//+kubebuilder:rbac:groups=ofcir.openshift,namespace=ofcir-system,resources=ciresources,verbs=get;list;watch;create;update;patch;delete;deletecollection
-//+kubebuilder:rbac:groups=ofcir.openshift,namespace=ofcir-system,resources=ciresources/status,verbs=get;update;patch
-//+kubebuilder:rbac:groups=ofcir.openshift,namespace=ofcir-system,resources=ciresources/finalizers,verbs=update
+//+kubebuilder:rbac:groups=ofcir.openshift,resources=ciresources,verbs=get;list;watch;create;update;patch;delete;deletecollection
+//+kubebuilder:rbac:groups=ofcir.openshift,resources=ciresources/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=ofcir.openshift,resources=ciresources/finalizers,verbs=update
+//+kubebuilder:rbac:groups=ofcir.openshift,resources=cileases,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=ofcir.openshift,resources=ciwebhooks,verbs=get;list;watch
 
 // Reconcile handles changes to the CIResource type
 func (r CIResourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -60,16 +70,57 @@ func (r CIResourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	logger.Info("started", "State", cir.Status.State)
 
+	// Continue the trace started by the API while the requested state change is in progress
+	if cir.Spec.State != cir.Status.State {
+		ctx = tracing.Extract(ctx, cir)
+	}
+	ctx, span := tracer.Start(ctx, "CIResource.Reconcile", trace.WithAttributes(attribute.String("ofcir.cir", cir.Name)))
+	defer span.End()
+
+	// The removal of a resource in error was explicitly requested
+	if cir.Status.State == ofcirv1.StateError && cir.Spec.State == ofcirv1.StateDelete && cir.DeletionTimestamp.IsZero() {
+		logger.Info("deleting resource in error")
+		return ctrl.Result{}, r.Delete(ctx, cir)
+	}
+
 	pool, poolSecret, err := r.getPool(cir, logger)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// Keep track of the previous state, to detect when a lease ends
+	prevState := cir.Status.State
+	prevRequestedState := cir.Spec.State
+	inUseSince := cir.Status.StateChanged
+
 	fsm := NewCIResourceFSM(logger)
-	isDirty, isStatusDirty, retryAfter, err := fsm.Process(cir, pool, poolSecret)
+	fsm.poolResources = func(pool *ofcirv1.CIPool) ([]ofcirv1.CIResource, error) {
+		return listPoolResources(ctx, r.Client, pool)
+	}
+	isDirty, isStatusDirty, retryAfter, err := fsm.Process(ctx, cir, pool, poolSecret)
+
+	// A released resource always moves from in use to cleaning, or to delete if evicted.
+	// The lease is recorded before saving the new state, so that it's retried on failure.
+	// A refused acquisition never reached the job, so it's not recorded
+	if err == nil && isStatusDirty && prevState == ofcirv1.StateInUse && (cir.Status.State == ofcirv1.StateCleaning || cir.Status.State == ofcirv1.StateDelete) {
+		var annotationsChanged bool
+		if cir.Status.AcquireFailed {
+			annotationsChanged = clearLeaseAnnotations(cir)
+		} else if annotationsChanged, err = r.recordLease(ctx, cir, pool, inUseSince); err != nil {
+			logger.Error(err, "could not record lease")
+		}
+		isDirty = isDirty || annotationsChanged
+	}
+
 	if err == nil {
 		if isDirty {
+			// The update would overwrite the status changes, if any
+			status := cir.Status.DeepCopy()
 			err = r.updateResource(cir)
+			if err == nil && isStatusDirty {
+				cir.Status = *status
+				err = r.updateStatus(cir)
+			}
 		} else if isStatusDirty {
 			err = r.updateStatus(cir)
 		}
@@ -81,6 +132,7 @@ func (r CIResourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	if isDirty || isStatusDirty {
 		logger.Info("changed", "State", cir.Status.State)
+		r.notify(ctx, cir, prevState, prevRequestedState)
 	}
 
 	return ctrl.Result{RequeueAfter: retryAfter}, nil
@@ -97,21 +149,104 @@ func (r *CIResourceReconciler) getPool(cir *ofcirv1.CIResource, logger logr.Logg
 	}
 
 	// Retrieve the pool secret, if defined
-	poolSecretKey := types.NamespacedName{
-		Namespace: pool.Namespace,
-		Name:      fmt.Sprintf("%s-secret", pool.Name),
-	}
-	poolSecret := &v1.Secret{}
-	if err := r.Get(context.Background(), poolSecretKey, poolSecret); err != nil {
-		if !errors.IsNotFound(err) {
-			logger.Error(err, "could not get CIPool secret", "Secret", cir.Spec.PoolRef)
-			return nil, nil, err
-		}
+	poolSecret, _, err := getPoolSecret(context.Background(), r.Client, pool)
+	if err != nil {
+		logger.Error(err, "could not get CIPool secret", "Secret", pool.GetSecretName())
+		return nil, nil, err
 	}
 
 	return pool, poolSecret, nil
 }
 
+// notify sends to the webhooks the CIResource changes worth an event
+func (r *CIResourceReconciler) notify(ctx context.Context, cir *ofcirv1.CIResource, prevState ofcirv1.CIResourceState, prevRequestedState ofcirv1.CIResourceState) {
+	event := notifier.Event{
+		Namespace:  cir.Namespace,
+		Pool:       cir.Spec.PoolRef.Name,
+		CIResource: cir.Name,
+		ResourceId: cir.Status.ResourceId,
+		State:      string(cir.Status.State),
+	}
+
+	switch {
+	case cir.Status.State == ofcirv1.StateError && prevState != ofcirv1.StateError:
+		event.Type = ofcirv1.EventResourceError
+		event.Message = fmt.Sprintf("resource entered the error state from %s: %s", cir.Status.FailedState, cir.Status.LastError)
+	case prevState == ofcirv1.StateInUse && prevRequestedState == ofcirv1.StateInUse && cir.Spec.State == ofcirv1.StateAvailable && !cir.Status.AcquireFailed:
+		// Only the controller releases a resource on its own, when the pool timeout is hit.
+		// A fallback resource refused for the pool budget was never handed to the job
+		event.Type = ofcirv1.EventResourceTimeout
+		event.Message = "resource released after reaching the pool timeout"
+	default:
+		return
+	}
+
+	r.Notifier.Notify(ctx, event)
+}
+
+// recordLease stores the usage record of a CIResource that was just released, using
+// the lease annotations set by the API when the resource was acquired. The lease name
+// is derived from the time the resource entered the in use state, so that recording it
+// again after a failed update is harmless. The lease annotations are removed from the
+// resource, returns true if it must be updated
+func (r *CIResourceReconciler) recordLease(ctx context.Context, cir *ofcirv1.CIResource, pool *ofcirv1.CIPool, inUseSince *metav1.Time) (bool, error) {
+	annotations := cir.GetAnnotations()
+
+	// The lease starts when the resource moved to in use, not when the API
+	// accepted the request
+	acquiredAt := metav1.Now()
+	if inUseSince != nil {
+		acquiredAt = *inUseSince
+	}
+
+	lease := &ofcirv1.CILease{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cir.Namespace,
+			Labels: map[string]string{
+				ofcirv1.PoolLabel: pool.Name,
+			},
+		},
+		Spec: ofcirv1.CILeaseSpec{
+			CIResource:       cir.Name,
+			PoolRef:          cir.Spec.PoolRef,
+			Provider:         pool.Spec.Provider,
+			Type:             cir.Spec.Type,
+			ResourceId:       cir.Status.ResourceId,
+			TokenFingerprint: annotations[ofcirv1.LeaseTokenAnnotation],
+			Job:              annotations[ofcirv1.LeaseJobAnnotation],
+			AcquiredAt:       acquiredAt,
+			ReleasedAt:       metav1.Now(),
+		},
+	}
+	if inUseSince != nil {
+		lease.Name = fmt.Sprintf("%s-%d", cir.Name, inUseSince.Unix())
+	} else {
+		lease.GenerateName = fmt.Sprintf("%s-", cir.Name)
+	}
+	if err := r.Create(ctx, lease); err != nil && !errors.IsAlreadyExists(err) {
+		return false, err
+	}
+
+	return clearLeaseAnnotations(cir), nil
+}
+
+// clearLeaseAnnotations removes the lease annotations set by the API, since they
+// must not be reused by the next acquisition. Returns true if any was removed
+func clearLeaseAnnotations(cir *ofcirv1.CIResource) bool {
+	annotations := cir.GetAnnotations()
+	isDirty := false
+	for _, a := range []string{ofcirv1.LeaseAcquiredAnnotation, ofcirv1.LeaseTokenAnnotation, ofcirv1.LeaseJobAnnotation} {
+		if _, ok := annotations[a]; ok {
+			delete(annotations, a)
+			isDirty = true
+		}
+	}
+	if isDirty {
+		cir.SetAnnotations(annotations)
+	}
+	return isDirty
+}
+
 func (r *CIResourceReconciler) updateResource(cir *ofcirv1.CIResource) error {
 	return r.Update(context.TODO(), cir)
 }
@@ -127,6 +262,7 @@ func (r *CIResourceReconciler) updateStatus(cir *ofcirv1.CIResource) error {
 func (r *CIResourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&ofcirv1.CIResource{}).
+		Watches(&v1.Secret{}, handler.EnqueueRequestsFromMapFunc(requestsForSecretResources(mgr.GetClient()))).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 1,
 		}).
@@ -2,14 +2,27 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
 	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/openshift/ofcir/pkg/notifier"
 	"github.com/openshift/ofcir/pkg/reconcilertest"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 func TestCIResourceCreationAndAcquisition(t *testing.T) {
@@ -102,6 +115,13 @@ func TestCIResourceCreationAndAcquisition(t *testing.T) {
 				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIResource) bool {
 					return obj.Status.State == ofcirv1.StateCleaning
 				}).
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIResource) {
+					var leases ofcirv1.CILeaseList
+					assert.NoError(t, client.List(context.Background(), &leases))
+					assert.Len(t, leases.Items, 1)
+					assert.Equal(t, obj.Name, leases.Items[0].Spec.CIResource)
+					assert.Equal(t, obj.Spec.PoolRef.Name, leases.Items[0].Labels[ofcirv1.PoolLabel])
+				}, "a lease is recorded for the released resource").
 				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIResource) bool {
 					return obj.Status.State == ofcirv1.StateCleaningWait
 				}).
@@ -180,7 +200,7 @@ func TestCIResourceReconcilerFallbacks(t *testing.T) {
 				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIResource) {
 					// A premature release can happen if the provider didn't provisioned yet the instance,
 					// thus the CIR resource is still marked with the dummy fallback id
-					assert.Equal(t, fallbackResourceID, obj.Status.ResourceId)
+					assert.Equal(t, ofcirv1.FallbackResourceID, obj.Status.ResourceId)
 					obj.Spec.State = ofcirv1.StateAvailable
 					client.Update(context.Background(), obj)
 				}, "release the resource before getting provisioned by the provider").
@@ -188,15 +208,187 @@ func TestCIResourceReconcilerFallbacks(t *testing.T) {
 					return obj.Status.State == ofcirv1.StateAvailable
 				}).Case(),
 		},
+		{
+			name: "resource in error is removed when requested",
+			testCase: newCIResourceScenario().
+				Setup(func() []client.Object {
+					cip, secret := cipoolWithSecret()
+					cir := cir("cir-0").pool(cip.Name).
+						currentState(ofcirv1.StateError).
+						requiredState(ofcirv1.StateError).build()
+					cir.Finalizers = []string{ofcirv1.OfcirFinalizer}
+					cir.Status.ResourceId = "dummy-1"
+
+					return []client.Object{cir, cip.build(), secret}
+				}).
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIResource) bool {
+					return obj.Status.State == ofcirv1.StateError
+				}).
+				Then(func(t *testing.T, client client.Client, obj *ofcirv1.CIResource) {
+					obj.Spec.State = ofcirv1.StateDelete
+					client.Update(context.Background(), obj)
+				}, "request the removal").
+				ReconcileUntil(func(client client.Client, obj *ofcirv1.CIResource) bool {
+					return obj == nil
+				}, "wait for cir to be removed").Case(),
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, tc.testCase.Test)
 	}
 }
 
+func TestCIResourceLeaseRetried(t *testing.T) {
+	cip, secret := cipoolWithSecret()
+	inUseSince := metav1.NewTime(time.Now().Add(-2 * time.Hour).Truncate(time.Second))
+	lastUpdated := metav1.NewTime(time.Now().Add(-time.Minute))
+	obj := cir("cir-0").pool(cip.Name).currentState(ofcirv1.StateInUse).requiredState(ofcirv1.StateAvailable).build()
+	obj.Finalizers = []string{ofcirv1.OfcirFinalizer}
+	// The API accepted the request earlier than the resource moved to in use
+	obj.Annotations = map[string]string{
+		ofcirv1.LeaseTokenAnnotation:    "fingerprint",
+		ofcirv1.LeaseAcquiredAnnotation: inUseSince.Add(-10 * time.Minute).UTC().Format(time.RFC3339),
+	}
+	obj.Status.ResourceId = "dummy-1"
+	obj.Status.StateChanged = &inUseSince
+	obj.Status.LastUpdated = &lastUpdated
+
+	// The first lease creation and the first status update fail
+	failLease, failStatus := true, true
+	scheme := runtime.NewScheme()
+	assert.NoError(t, ofcirv1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(cip.build(), secret, obj).
+		WithStatusSubresource(obj).
+		WithIndex(&ofcirv1.CIResource{}, poolRefField, poolRefIndexer).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if _, ok := obj.(*ofcirv1.CILease); ok && failLease {
+					failLease = false
+					return fmt.Errorf("lease creation failed")
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				if failStatus {
+					failStatus = false
+					return fmt.Errorf("status update failed")
+				}
+				return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+			},
+		}).Build()
+
+	r := &CIResourceReconciler{Client: c}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: obj.Namespace, Name: obj.Name}}
+	current := &ofcirv1.CIResource{}
+
+	_, err := r.Reconcile(context.Background(), req)
+	assert.ErrorContains(t, err, "lease creation failed")
+	assert.NoError(t, c.Get(context.Background(), req.NamespacedName, current))
+	assert.Equal(t, ofcirv1.StateInUse, current.Status.State, "the release is not saved without a lease")
+
+	_, err = r.Reconcile(context.Background(), req)
+	assert.ErrorContains(t, err, "status update failed")
+
+	_, err = r.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(context.Background(), req.NamespacedName, current))
+	assert.Equal(t, ofcirv1.StateCleaning, current.Status.State)
+	assert.NotContains(t, current.Annotations, ofcirv1.LeaseTokenAnnotation)
+	assert.NotContains(t, current.Annotations, ofcirv1.LeaseAcquiredAnnotation)
+
+	var leases ofcirv1.CILeaseList
+	assert.NoError(t, c.List(context.Background(), &leases))
+	assert.Len(t, leases.Items, 1, "the lease is recorded once")
+	assert.Equal(t, "fingerprint", leases.Items[0].Spec.TokenFingerprint)
+	assert.True(t, inUseSince.Equal(&leases.Items[0].Spec.AcquiredAt), "the lease starts when the resource entered the in use state")
+	assert.True(t, leases.Items[0].Spec.ReleasedAt.After(lastUpdated.Time))
+}
+
+func TestCIResourceFallbackRefusedNotReleased(t *testing.T) {
+	tests := []struct {
+		name             string
+		overBudget       bool
+		resourceId       string
+		expectedTimeouts int32
+		expectedLeases   int
+	}{
+		{
+			name:             "pool timeout",
+			resourceId:       "i-0123",
+			expectedTimeouts: 1,
+			expectedLeases:   1,
+		},
+		{
+			name:       "fallback refused for the budget",
+			overBudget: true,
+			resourceId: ofcirv1.FallbackResourceID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+			}))
+			defer server.Close()
+
+			cip, secret := cipoolWithSecret()
+			cip.priority(-1)
+			cip.Spec.Timeout = metav1.Duration{Duration: time.Hour}
+			if tt.overBudget {
+				cip.Status.Conditions = []metav1.Condition{{Type: ofcirv1.PoolConditionBudgetExceeded, Status: metav1.ConditionTrue, Reason: ofcirv1.ReasonBudgetExceeded}}
+			}
+			webhook := &ofcirv1.CIWebhook{
+				ObjectMeta: metav1.ObjectMeta{Name: "webhook", Namespace: defaultTestNs},
+				Spec:       ofcirv1.CIWebhookSpec{URL: server.URL},
+			}
+			inUseSince := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+			obj := cir("cir-0").pool(cip.Name).currentState(ofcirv1.StateInUse).requiredState(ofcirv1.StateInUse).build()
+			obj.Finalizers = []string{ofcirv1.OfcirFinalizer}
+			obj.Annotations = map[string]string{ofcirv1.LeaseTokenAnnotation: "fingerprint"}
+			obj.Status.ResourceId = tt.resourceId
+			obj.Status.StateChanged = &inUseSince
+			if tt.resourceId != ofcirv1.FallbackResourceID {
+				obj.Status.Address = "192.168.1.1"
+			}
+
+			scheme := runtime.NewScheme()
+			assert.NoError(t, ofcirv1.AddToScheme(scheme))
+			assert.NoError(t, corev1.AddToScheme(scheme))
+			c := fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(cip.build(), secret, obj, webhook).
+				WithStatusSubresource(obj).
+				WithIndex(&ofcirv1.CIResource{}, poolRefField, poolRefIndexer).
+				Build()
+
+			n := notifier.NewNotifier(c, logr.Discard())
+			r := &CIResourceReconciler{Client: c, Notifier: n}
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: obj.Namespace, Name: obj.Name}}
+			for i := 0; i < 2; i++ {
+				_, err := r.Reconcile(context.Background(), req)
+				assert.NoError(t, err)
+			}
+			n.Wait()
+
+			current := &ofcirv1.CIResource{}
+			assert.NoError(t, c.Get(context.Background(), req.NamespacedName, current))
+			assert.Equal(t, ofcirv1.StateAvailable, current.Spec.State)
+			assert.NotContains(t, current.Annotations, ofcirv1.LeaseTokenAnnotation)
+			assert.Equal(t, tt.expectedTimeouts, requests.Load())
+
+			var leases ofcirv1.CILeaseList
+			assert.NoError(t, c.List(context.Background(), &leases))
+			assert.Len(t, leases.Items, tt.expectedLeases)
+		})
+	}
+}
+
 func newCIResourceScenario() reconcilertest.Scenario[CIResourceReconciler, ofcirv1.CIResource, *ofcirv1.CIResource] {
 	return reconcilertest.New[CIResourceReconciler, ofcirv1.CIResource]().
-		WithSchemes(ofcirv1.AddToScheme, corev1.AddToScheme)
+		WithSchemes(ofcirv1.AddToScheme, corev1.AddToScheme).
+		WithIndex(&ofcirv1.CIResource{}, poolRefField, poolRefIndexer)
 }
 
 func scenarioPoolWithSingleCir() []client.Object {
@@ -1,28 +1,57 @@
 package controllers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/go-logr/logr"
 	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/openshift/ofcir/pkg/healthcheck"
+	"github.com/openshift/ofcir/pkg/hooks"
 	"github.com/openshift/ofcir/pkg/providers"
+	"github.com/openshift/ofcir/pkg/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+var tracer = otel.Tracer("github.com/openshift/ofcir/controllers")
+
 const (
-	fallbackResourceID              = "000-fallback-dummy-000" // dummy ID for fallback resource
 	defaultCirRetryDelay            = time.Minute * 1
 	defaultCirProvisioningWaitDelay = time.Second * 30
+	maxCirFailureDelay              = time.Minute * 30
+	defaultCirDriftCheckDelay       = time.Minute * 10
+	defaultCirSlotWaitDelay         = time.Second * 15
+	defaultCirScriptPollDelay       = time.Second * 10
+	defaultCirReadinessTimeout      = time.Minute * 1
 )
 
+// Events used to retry the failed step when leaving the error state
+var retryEvents = map[ofcirv1.CIResourceState]string{
+	ofcirv1.StateProvisioning:     "on-retry-provisioning",
+	ofcirv1.StateProvisioningWait: "on-retry-provisioning-wait",
+	ofcirv1.StateCleaning:         "on-retry-cleaning",
+	ofcirv1.StateCleaningWait:     "on-retry-cleaning-wait",
+}
+
 func NewCIResourceFSM(logger logr.Logger) *CIResourceFSM {
 	fsm := &CIResourceFSM{
-		states:      make(map[ofcirv1.CIResourceState]fsmState),
-		logger:      logger,
-		debuglogger: logger.V(1),
+		states:           make(map[ofcirv1.CIResourceState]fsmState),
+		logger:           logger,
+		debuglogger:      logger.V(1),
+		newProvider:      providers.NewProvider,
+		probe:            healthcheck.Probe,
+		hooks:            hooks.DefaultRegistry,
+		scripts:          defaultScriptRunner,
+		readinessTimeout: defaultCirReadinessTimeout,
 	}
 
 	fsm.State(ofcirv1.StateNone,
@@ -32,35 +61,56 @@ func NewCIResourceFSM(logger logr.Logger) *CIResourceFSM {
 	fsm.State(ofcirv1.StateProvisioning,
 		fsm.handleStateProvisioning,
 		Transition("on-provisioning-requested", ofcirv1.StateProvisioningWait),
-		Transition("fallback-available", ofcirv1.StateAvailable))
+		Transition("fallback-available", ofcirv1.StateAvailable),
+		Transition("on-error", ofcirv1.StateError))
 
 	fsm.State(ofcirv1.StateProvisioningWait,
 		fsm.handleStateProvisioningWait,
-		Transition("on-provisioning-complete", ofcirv1.StateAvailable))
+		Transition("on-provisioning-complete", ofcirv1.StateAvailable).WithHook(ofcirv1.HookPostProvision),
+		Transition("on-provisioning-timeout", ofcirv1.StateProvisioning),
+		Transition("on-error", ofcirv1.StateError))
 
 	fsm.State(ofcirv1.StateAvailable,
 		fsm.handleStateAvailable,
 		Transition("on-maintenance", ofcirv1.StateMaintenance),
-		Transition("acquired", ofcirv1.StateInUse),
+		Transition("acquired", ofcirv1.StateInUse).WithHook(ofcirv1.HookPreAcquire),
+		Transition("on-health-check-failed", ofcirv1.StateCleaning),
+		Transition("on-health-check-reprovision", ofcirv1.StateProvisioning),
+		Transition("on-resource-missing", ofcirv1.StateProvisioning),
 		Transition("on-delete", ofcirv1.StateDelete))
 
 	fsm.State(ofcirv1.StateMaintenance,
 		fsm.handleStateMaintenance,
 		Transition("on-maintenance-complete", ofcirv1.StateAvailable),
+		Transition("on-resource-missing", ofcirv1.StateProvisioning),
 		Transition("on-delete", ofcirv1.StateDelete))
 
 	fsm.State(ofcirv1.StateInUse,
 		fsm.handleStateInUse,
-		Transition("released", ofcirv1.StateCleaning),
-		Transition("fallback-provisioning", ofcirv1.StateProvisioning))
+		Transition("released", ofcirv1.StateCleaning).WithHook(ofcirv1.HookPostRelease),
+		Transition("fallback-provisioning", ofcirv1.StateProvisioning),
+		Transition("fallback-refused", ofcirv1.StateCleaning),
+		Transition("on-delete", ofcirv1.StateDelete))
 
 	fsm.State(ofcirv1.StateCleaning,
 		fsm.handleStateCleaning,
-		Transition("on-cleaning-requested", ofcirv1.StateCleaningWait))
+		Transition("on-cleaning-requested", ofcirv1.StateCleaningWait),
+		Transition("on-error", ofcirv1.StateError))
 
 	fsm.State(ofcirv1.StateCleaningWait,
 		fsm.handleStateCleaningWait,
-		Transition("on-cleaning-complete", ofcirv1.StateAvailable))
+		Transition("on-cleaning-complete", ofcirv1.StateAvailable),
+		Transition("on-cleaning-timeout", ofcirv1.StateProvisioning),
+		Transition("on-error", ofcirv1.StateError))
+
+	fsm.State(ofcirv1.StateError,
+		fsm.handleStateError,
+		Transition(retryEvents[ofcirv1.StateProvisioning], ofcirv1.StateProvisioning),
+		Transition(retryEvents[ofcirv1.StateProvisioningWait], ofcirv1.StateProvisioningWait),
+		Transition(retryEvents[ofcirv1.StateCleaning], ofcirv1.StateCleaning),
+		Transition(retryEvents[ofcirv1.StateCleaningWait], ofcirv1.StateCleaningWait),
+		Transition("on-reprovision", ofcirv1.StateProvisioning),
+		Transition("on-delete", ofcirv1.StateDelete))
 
 	fsm.State(ofcirv1.StateDelete,
 		fsm.handleStateDelete)
@@ -87,10 +137,18 @@ func (f *CIResourceFSM) handleStateProvisioning(context CIResourceFSMContext) (t
 	// If a fallback resource is not requested then let's move it directly to the available state,
 	// otherwise a normal provisioning phase is kicked off
 	if context.CIPool.IsFallbackPool() && context.CIResource.Spec.State != ofcirv1.StateInUse {
-		context.CIResource.Status.ResourceId = fallbackResourceID
+		context.CIResource.Status.ResourceId = ofcirv1.FallbackResourceID
 		return f.TriggerEvent("fallback-available")
 	}
 
+	if context.CIPool.IsOverBudget() {
+		return f.refuseProvisioning(context)
+	}
+
+	if wait, err := f.waitForSlot(context, provisioningSlots); wait || err != nil {
+		return defaultCirSlotWaitDelay, err
+	}
+
 	resource, err := context.Provider.Acquire(context.CIPool.Spec.Size, context.CIPool.Name, string(context.CIPool.Spec.Type))
 	if err != nil {
 		return 0, err
@@ -113,12 +171,25 @@ func (f *CIResourceFSM) handleStateProvisioningWait(context CIResourceFSMContext
 	if isReady {
 		context.CIResource.Status.Address = resource.Address
 		context.CIResource.Status.Extra = resource.Metadata
+		if resource.HourlyPrice > 0 {
+			context.CIResource.Status.HourlyPrice = ofcirv1.FormatAmount(resource.HourlyPrice)
+		}
+		isReady = f.readinessChecksPassed(context)
+	}
 
+	if isReady {
 		f.logger.Info("resource was provisioned", "Id", context.CIResource.Status.ResourceId, "Address", context.CIResource.Status.Address)
-		f.TriggerEvent("on-provisioning-complete")
+		if _, err := f.TriggerEvent("on-provisioning-complete"); err != nil {
+			return 0, err
+		}
 		return 0, nil
 	}
 
+	if stateExpired(context.CIResource, context.CIPool.Spec.ProvisioningTimeout) {
+		return f.reprovision(context, "on-provisioning-timeout",
+			fmt.Sprintf("provisioning not completed within %s", context.CIPool.Spec.ProvisioningTimeout.Duration))
+	}
+
 	f.logger.Info("waiting for new resource to be provisioned", "Id", context.CIResource.Status.ResourceId)
 	return defaultCirProvisioningWaitDelay, nil
 }
@@ -131,19 +202,172 @@ func (f *CIResourceFSM) handleStateAvailable(context CIResourceFSMContext) (time
 	}
 
 	if context.CIResource.Spec.State == context.CIResource.Status.State {
-		return defaultCirRetryDelay, nil
+		if f.resourceMissing(context) {
+			return f.reprovision(context, "on-resource-missing", "resource not found on the provider")
+		}
+		return f.checkHealth(context)
 	}
 
 	switch context.CIResource.Spec.State {
 	case ofcirv1.StateMaintenance:
 		return f.TriggerEvent("on-maintenance")
 	case ofcirv1.StateInUse:
-		return f.TriggerEvent("acquired")
+		retryAfter, err := f.TriggerEvent("acquired")
+		if errors.As(err, &hookError{}) {
+			return f.refuseAcquire(context, err)
+		}
+		return retryAfter, err
 	}
 
 	return defaultCirRetryDelay, nil
 }
 
+// refuseAcquire does not hand out a resource that failed its pre-acquire hooks,
+// and keeps it out of rotation until an explicit action is requested. The API
+// already returned the resource to the job, so the refusal is reported in the
+// status for it
+func (f *CIResourceFSM) refuseAcquire(context CIResourceFSMContext, err error) (time.Duration, error) {
+	cir := context.CIResource
+	f.logger.Info("pre-acquire hooks failed, moving resource to maintenance", "Id", cir.Status.ResourceId, "Reason", err.Error())
+
+	cir.Spec.State = ofcirv1.StateMaintenance
+	f.UpdateResourceOnly()
+	retryAfter, triggerErr := f.TriggerEvent("on-maintenance")
+	cir.Status.LastError = err.Error()
+	cir.Status.AcquireFailed = true
+	return retryAfter, triggerErr
+}
+
+// resourceMissing periodically asks the provider for the current details of an
+// idle resource, refreshing its address if changed. Returns true if the resource
+// was removed on the provider side
+func (f *CIResourceFSM) resourceMissing(context CIResourceFSMContext) bool {
+	cir := context.CIResource
+
+	describer, ok := context.Provider.(providers.Describer)
+	if !ok || cir.Status.ResourceId == "" || cir.Status.ResourceId == ofcirv1.FallbackResourceID {
+		return false
+	}
+	if cir.Status.ProviderSynced != nil && time.Since(cir.Status.ProviderSynced.Time) < defaultCirDriftCheckDelay {
+		return false
+	}
+
+	now := metav1.Now()
+	cir.Status.ProviderSynced = &now
+	f.statusDirty = true
+
+	resource, err := describer.Describe(cir.Status.ResourceId)
+	if err != nil {
+		if errors.As(err, &providers.ResourceNotFoundError{}) {
+			f.logger.Info("resource not found on the provider", "Id", cir.Status.ResourceId)
+			return true
+		}
+		// Not a reason for taking the resource out of rotation
+		f.logger.Info("unable to describe resource", "Id", cir.Status.ResourceId, "Error", err.Error())
+		return false
+	}
+
+	if resource.Address != "" && resource.Address != cir.Status.Address {
+		f.logger.Info("resource address changed", "Id", cir.Status.ResourceId, "Old", cir.Status.Address, "New", resource.Address)
+		cir.Status.Address = resource.Address
+	}
+	return false
+}
+
+// readinessChecksPassed runs the pool readiness checks against a resource the
+// provider reported as provisioned or cleaned. Returns true if all of them succeeded
+func (f *CIResourceFSM) readinessChecksPassed(context CIResourceFSMContext) bool {
+	cir := context.CIResource
+	checks := context.CIPool.Spec.ReadinessChecks
+	if len(checks) == 0 {
+		return true
+	}
+
+	last := cir.Status.Readiness
+	result := &ofcirv1.ProbeStatus{Time: metav1.Now(), Healthy: true}
+	// The checks are run by the reconcile, so all together they cannot last
+	// longer than the readiness timeout
+	deadline := time.Now().Add(f.readinessTimeout)
+	for _, check := range checks {
+		err := fmt.Errorf("not run, the readiness checks did not complete within %s", f.readinessTimeout)
+		if remaining := time.Until(deadline); remaining > 0 {
+			if remaining < check.GetTimeout() {
+				check.Timeout = &metav1.Duration{Duration: remaining}
+			}
+			err = f.probe(check, cir.Status.Address, poolPrivateKey(context))
+		}
+		if err != nil {
+			result.Healthy = false
+			result.Message = fmt.Sprintf("%s check failed: %s", check.Type, err)
+			result.Failures = 1
+			// Only the failures since the current state was entered are consecutive
+			if last != nil && !last.Healthy && (cir.Status.StateChanged == nil || last.Time.After(cir.Status.StateChanged.Time)) {
+				result.Failures += last.Failures
+			}
+			break
+		}
+	}
+	cir.Status.Readiness = result
+	f.statusDirty = true
+
+	if !result.Healthy {
+		f.logger.Info("resource not ready yet", "Id", cir.Status.ResourceId, "Reason", result.Message)
+	}
+	return result.Healthy
+}
+
+// poolPrivateKey returns the private key used by the ssh probes, if any
+func poolPrivateKey(context CIResourceFSMContext) []byte {
+	if context.CIPoolSecret == nil {
+		return nil
+	}
+	return context.CIPoolSecret.Data[ofcirv1.SSHPrivateKeySecretKey]
+}
+
+// checkHealth periodically probes an available resource, and takes it out of
+// rotation once the pool failure threshold is reached
+func (f *CIResourceFSM) checkHealth(context CIResourceFSMContext) (time.Duration, error) {
+	cir := context.CIResource
+	check := context.CIPool.Spec.HealthCheck
+	if check == nil || cir.Status.Address == "" {
+		return defaultCirRetryDelay, nil
+	}
+
+	last := cir.Status.HealthCheck
+	if last != nil {
+		if next := time.Until(last.Time.Add(check.GetInterval())); next > 0 {
+			return min(next, defaultCirRetryDelay), nil
+		}
+	}
+
+	result := &ofcirv1.ProbeStatus{Time: metav1.Now(), Healthy: true}
+	if err := f.probe(check.Probe, cir.Status.Address, poolPrivateKey(context)); err != nil {
+		result.Healthy = false
+		result.Message = err.Error()
+		result.Failures = 1
+		// Only the failures since the resource became available are consecutive
+		if last != nil && !last.Healthy && (cir.Status.StateChanged == nil || last.Time.After(cir.Status.StateChanged.Time)) {
+			result.Failures += last.Failures
+		}
+	}
+	cir.Status.HealthCheck = result
+	f.statusDirty = true
+
+	if result.Healthy || result.Failures < check.GetFailureThreshold() {
+		if !result.Healthy {
+			f.logger.Info("health check failed", "Id", cir.Status.ResourceId, "Failures", result.Failures, "Reason", result.Message)
+		}
+		return min(check.GetInterval(), defaultCirRetryDelay), nil
+	}
+
+	reason := fmt.Sprintf("health check failed: %s", result.Message)
+	if check.GetAction() == ofcirv1.HealthCheckActionClean {
+		f.logger.Info("unhealthy resource, cleaning it", "Id", cir.Status.ResourceId, "Reason", reason)
+		return f.TriggerEvent("on-health-check-failed")
+	}
+	return f.reprovision(context, "on-health-check-reprovision", reason)
+}
+
 func (f *CIResourceFSM) handleStateMaintenance(context CIResourceFSMContext) (time.Duration, error) {
 
 	//check for deletion
@@ -152,6 +376,9 @@ func (f *CIResourceFSM) handleStateMaintenance(context CIResourceFSMContext) (ti
 	}
 
 	if context.CIResource.Spec.State == context.CIResource.Status.State {
+		if f.resourceMissing(context) {
+			return f.reprovision(context, "on-resource-missing", "resource not found on the provider")
+		}
 		return defaultCirRetryDelay, nil
 	}
 
@@ -167,14 +394,21 @@ func (f *CIResourceFSM) handleStateInUse(context CIResourceFSMContext) (time.Dur
 
 	switch context.CIResource.Spec.State {
 	case ofcirv1.StateAvailable:
+		// A resource evicted while in use is not cleaned, since it's going to be removed
+		if !context.CIResource.ObjectMeta.DeletionTimestamp.IsZero() {
+			return f.TriggerEvent("on-delete")
+		}
 		return f.TriggerEvent("released")
 	case ofcirv1.StateInUse:
 		// A fallback resource has been requested, so it must be provisioned
-		if context.CIPool.IsFallbackPool() && context.CIResource.Status.Address == "" && context.CIResource.Status.ResourceId == fallbackResourceID {
-			return f.TriggerEvent("fallback-provisioning")
+		if context.CIPool.IsFallbackPool() && context.CIResource.Status.Address == "" && context.CIResource.Status.ResourceId == ofcirv1.FallbackResourceID {
+			if !context.CIPool.IsOverBudget() {
+				return f.TriggerEvent("fallback-provisioning")
+			}
+			return f.refuseFallback(context)
 		}
-		// CIR's can only be held "inuse" for a limited amount of time
-		if time.Since(context.CIResource.Status.LastUpdated.Time) > context.CIPool.Spec.Timeout.Duration {
+		// CIR's can only be held "inuse" for a limited amount of time since their acquisition
+		if inUseSince := context.CIResource.StateSince(); !inUseSince.IsZero() && time.Since(inUseSince) > context.CIPool.Spec.Timeout.Duration {
 			f.logger.Info("releasing resource, max duration hit", "Id", context.CIResource.Status.ResourceId)
 			context.CIResource.Spec.State = ofcirv1.StateAvailable
 			return f.UpdateResourceOnly()
@@ -186,11 +420,15 @@ func (f *CIResourceFSM) handleStateInUse(context CIResourceFSMContext) (time.Dur
 
 func (f *CIResourceFSM) handleStateCleaning(context CIResourceFSMContext) (time.Duration, error) {
 
+	if wait, err := f.waitForSlot(context, cleaningSlots); wait || err != nil {
+		return defaultCirSlotWaitDelay, err
+	}
+
 	// If it's a fallback resource, let's clean and release it immediately
 	if context.CIPool.IsFallbackPool() {
 
 		// Do not delete a fallback resource that was not yet created
-		if context.CIResource.Status.ResourceId != fallbackResourceID {
+		if context.CIResource.Status.ResourceId != ofcirv1.FallbackResourceID {
 			err := context.Provider.Release(context.CIResource.Status.ResourceId)
 			if err != nil && !errors.As(err, &providers.ResourceNotFoundError{}) {
 				return defaultCIPoolRetryDelay, err
@@ -200,9 +438,12 @@ func (f *CIResourceFSM) handleStateCleaning(context CIResourceFSMContext) (time.
 		context.CIResource.Status.Address = ""
 		context.CIResource.Status.Extra = ""
 		context.CIResource.Status.ProviderInfo = ""
-		context.CIResource.Status.ResourceId = fallbackResourceID
-	} else if err := context.Provider.Clean(context.CIResource.Status.ResourceId); err != nil {
-		return defaultCIPoolRetryDelay, err
+		context.CIResource.Status.HourlyPrice = ""
+		context.CIResource.Status.ResourceId = ofcirv1.FallbackResourceID
+	} else if !replacesProviderCleaning(context.CIPool) {
+		if err := context.Provider.Clean(context.CIResource.Status.ResourceId); err != nil {
+			return defaultCIPoolRetryDelay, err
+		}
 	}
 	return f.TriggerEvent("on-cleaning-requested")
 }
@@ -213,20 +454,239 @@ func (f *CIResourceFSM) handleStateCleaningWait(context CIResourceFSMContext) (t
 		return f.TriggerEvent("on-cleaning-complete")
 	}
 
-	isCleaned, err := context.Provider.CleanCompleted(context.CIResource.Status.ResourceId)
-	if err != nil {
-		return defaultCIPoolRetryDelay, err
+	isCleaned := true
+	if !replacesProviderCleaning(context.CIPool) {
+		var err error
+		isCleaned, err = context.Provider.CleanCompleted(context.CIResource.Status.ResourceId)
+		if err != nil {
+			return defaultCIPoolRetryDelay, err
+		}
 	}
 
+	scriptDone := false
 	if isCleaned {
+		var err error
+		scriptDone, err = f.runCleaningScript(context)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if scriptDone && f.readinessChecksPassed(context) {
 		f.logger.Info("resource was cleaned", "Id", context.CIResource.Status.ResourceId, "Address", context.CIResource.Status.Address)
 		return f.TriggerEvent("on-cleaning-complete")
 	}
 
+	if stateExpired(context.CIResource, context.CIPool.Spec.CleaningTimeout) {
+		return f.reprovision(context, "on-cleaning-timeout",
+			fmt.Sprintf("cleaning not completed within %s", context.CIPool.Spec.CleaningTimeout.Duration))
+	}
+
+	if isCleaned && !scriptDone {
+		f.logger.Info("waiting for cleaning script", "Id", context.CIResource.Status.ResourceId)
+		return defaultCirScriptPollDelay, nil
+	}
 	f.logger.Info("waiting for resource to be cleaned", "Id", context.CIResource.Status.ResourceId)
 	return defaultCirProvisioningWaitDelay, nil
 }
 
+// refuseProvisioning keeps the resource in its current state while the pool
+// is over its monthly budget, reporting the reason in the resource status
+func (f *CIResourceFSM) refuseProvisioning(context CIResourceFSMContext) (time.Duration, error) {
+	cir := context.CIResource
+	reason := budgetRefusedReason(context.CIPool)
+	if cir.Status.LastError != reason {
+		f.logger.Info("pool over budget, not provisioning resource", "Id", cir.Status.ResourceId)
+		cir.Status.LastError = reason
+		f.statusDirty = true
+	}
+	return defaultCirRetryDelay, nil
+}
+
+// refuseFallback returns right away to the pool a fallback resource acquired while
+// the pool exceeded its budget, since it cannot be provisioned. It was never handed
+// to the job, so it's not released: the post-release hooks are not run. The
+// acquisition is reported as failed until the resource changes state again
+func (f *CIResourceFSM) refuseFallback(context CIResourceFSMContext) (time.Duration, error) {
+	cir := context.CIResource
+	f.logger.Info("pool over budget, returning resource without provisioning it", "Id", cir.Status.ResourceId)
+
+	cir.Spec.State = ofcirv1.StateAvailable
+	f.UpdateResourceOnly()
+	retryAfter, err := f.TriggerEvent("fallback-refused")
+	cir.Status.LastError = budgetRefusedReason(context.CIPool)
+	cir.Status.AcquireFailed = true
+	return retryAfter, err
+}
+
+func budgetRefusedReason(pool *ofcirv1.CIPool) string {
+	return fmt.Sprintf("provisioning refused, pool %s exceeded its monthly budget", pool.Name)
+}
+
+// waitForSlot returns true if the resource must wait for other resources of the
+// pool to complete the given operation, as limited by the pool
+func (f *CIResourceFSM) waitForSlot(context CIResourceFSMContext, q slotQueue) (bool, error) {
+	if q.limit(context.CIPool) <= 0 || f.poolResources == nil {
+		return false, nil
+	}
+
+	poolCirs, err := f.poolResources(context.CIPool)
+	if err != nil {
+		return false, fmt.Errorf("cannot list the pool resources: %w", err)
+	}
+	if q.hasSlot(context.CIPool, context.CIResource, poolCirs) {
+		return false, nil
+	}
+
+	f.logger.Info("waiting for a free slot", "Id", context.CIResource.Status.ResourceId, "State", context.CIResource.Status.State, "Limit", q.limit(context.CIPool))
+	return true, nil
+}
+
+// replacesProviderCleaning returns true if the pool cleaning script must be run
+// instead of the provider cleaning
+func replacesProviderCleaning(pool *ofcirv1.CIPool) bool {
+	return pool.Spec.CleaningScript != nil && pool.Spec.CleaningScript.GetMode() == ofcirv1.CleaningScriptReplace
+}
+
+// runCleaningScript runs the pool cleaning script over SSH, if any, and stores its
+// output in the resource status. The script is run in the background only once
+// per cleaning, unless it fails. Returns true once the script succeeded
+func (f *CIResourceFSM) runCleaningScript(context CIResourceFSMContext) (bool, error) {
+	cir := context.CIResource
+	cleaning := context.CIPool.Spec.CleaningScript
+	if cleaning == nil {
+		return true, nil
+	}
+
+	last := cir.Status.CleaningScript
+	if last != nil && last.Succeeded && (cir.Status.StateChanged == nil || !last.Time.Before(cir.Status.StateChanged)) {
+		return true, nil
+	}
+
+	if cir.Status.Address == "" {
+		return false, fmt.Errorf("cannot run the cleaning script, resource %s has no address", cir.Status.ResourceId)
+	}
+	key := poolPrivateKey(context)
+	if len(key) == 0 {
+		return false, fmt.Errorf("cannot run the cleaning script, the pool secret does not contain the `%s` field", ofcirv1.SSHPrivateKeySecretKey)
+	}
+
+	var since time.Time
+	if cir.Status.StateChanged != nil {
+		since = cir.Status.StateChanged.Time
+	}
+	done, output, err := f.scripts.poll(f.ctx, client.ObjectKeyFromObject(cir), since,
+		f.cleaningScript(cir.Status.ResourceId, cir.Status.Address, *cleaning, key))
+	if !done {
+		return false, nil
+	}
+
+	// Keep only the tail of the output, where the errors usually are
+	if len(output) > ofcirv1.MaxCleaningScriptOutput {
+		output = output[len(output)-ofcirv1.MaxCleaningScriptOutput:]
+	}
+	result := &ofcirv1.CleaningScriptStatus{Time: metav1.Now(), Succeeded: err == nil, Output: output}
+	if err != nil {
+		err = fmt.Errorf("cleaning script failed: %w", err)
+		result.Message = err.Error()
+	}
+	cir.Status.CleaningScript = result
+	f.statusDirty = true
+	return err == nil, err
+}
+
+// cleaningScript returns the run of the cleaning script on the given resource
+func (f *CIResourceFSM) cleaningScript(id string, address string, cleaning ofcirv1.CleaningScript, key []byte) scriptFunc {
+	return func(ctx context.Context) (string, error) {
+		f.logger.Info("running cleaning script", "Id", id, "Address", address)
+		return runScript(ctx, address, cleaning, key)
+	}
+}
+
+func runScript(ctx context.Context, address string, cleaning ofcirv1.CleaningScript, key []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, cleaning.GetTimeout())
+	defer cancel()
+
+	output, err := utils.RunSSHCommand(ctx, address, cleaning.GetPort(), cleaning.GetUser(), key, cleaning.Script)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("not completed within %s", cleaning.GetTimeout())
+	}
+	return output, err
+}
+
+// reprovision releases the current resource and starts over from the provisioning
+// state, unless it was already reprovisioned too many times
+func (f *CIResourceFSM) reprovision(context CIResourceFSMContext, event string, reason string) (time.Duration, error) {
+	cir := context.CIResource
+
+	if cir.Status.Reprovisions >= context.CIPool.GetMaxReprovisions() {
+		f.logger.Info("too many reprovisions, moving resource to error", "Id", cir.Status.ResourceId, "Reason", reason)
+		return f.moveToError(reason)
+	}
+
+	f.logger.Info("reprovisioning resource", "Id", cir.Status.ResourceId, "Reason", reason)
+	if err := context.Provider.Release(cir.Status.ResourceId); err != nil && !errors.As(err, &providers.ResourceNotFoundError{}) {
+		return defaultCirRetryDelay, err
+	}
+
+	cir.Status.ResourceId = ""
+	cir.Status.Address = ""
+	cir.Status.Extra = ""
+	cir.Status.ProviderInfo = ""
+	cir.Status.HourlyPrice = ""
+	cir.Status.Reprovisions++
+	cir.Status.ReprovisionReason = reason
+	return f.TriggerEvent(event)
+}
+
+// stateExpired returns true if the resource stayed in the current state for longer than timeout
+func stateExpired(cir *ofcirv1.CIResource, timeout *metav1.Duration) bool {
+	if timeout == nil || timeout.Duration <= 0 || cir.Status.StateChanged == nil {
+		return false
+	}
+	return time.Since(cir.Status.StateChanged.Time) > timeout.Duration
+}
+
+func (f *CIResourceFSM) handleStateError(context CIResourceFSMContext) (time.Duration, error) {
+
+	//check for deletion
+	if !context.CIResource.ObjectMeta.DeletionTimestamp.IsZero() {
+		return f.TriggerEvent("on-delete")
+	}
+
+	switch context.CIResource.Spec.State {
+	case ofcirv1.StateAvailable:
+		// Retry the step that was failing
+		event, ok := retryEvents[context.CIResource.Status.FailedState]
+		if !ok {
+			event = "on-reprovision"
+		}
+		f.logger.Info("retrying failed resource", "Id", context.CIResource.Status.ResourceId, "FailedState", context.CIResource.Status.FailedState)
+		return f.TriggerEvent(event)
+
+	case ofcirv1.StateProvisioning:
+		// Throw away the current resource, and start from scratch
+		if id := context.CIResource.Status.ResourceId; id != "" && id != ofcirv1.FallbackResourceID {
+			err := context.Provider.Release(id)
+			if err != nil && !errors.As(err, &providers.ResourceNotFoundError{}) {
+				return defaultCirRetryDelay, err
+			}
+		}
+		f.logger.Info("reprovisioning failed resource", "Id", context.CIResource.Status.ResourceId)
+
+		context.CIResource.Spec.State = ofcirv1.StateAvailable
+		context.CIResource.Status.ResourceId = ""
+		context.CIResource.Status.Address = ""
+		context.CIResource.Status.Extra = ""
+		context.CIResource.Status.ProviderInfo = ""
+		context.CIResource.Status.HourlyPrice = ""
+		f.UpdateResourceOnly()
+		return f.TriggerEvent("on-reprovision")
+	}
+
+	return defaultCirRetryDelay, nil
+}
+
 func (f *CIResourceFSM) handleStateDelete(context CIResourceFSMContext) (time.Duration, error) {
 
 	f.logger.Info("removing resource", "Id", context.CIResource.Status.ResourceId)
@@ -234,7 +694,7 @@ func (f *CIResourceFSM) handleStateDelete(context CIResourceFSMContext) (time.Du
 	if controllerutil.ContainsFinalizer(context.CIResource, ofcirv1.OfcirFinalizer) {
 
 		// don't call the provider if the resource is a fallback dummy
-		if context.CIPool.IsFallbackPool() && context.CIResource.Status.ResourceId == fallbackResourceID {
+		if context.CIPool.IsFallbackPool() && context.CIResource.Status.ResourceId == ofcirv1.FallbackResourceID {
 			controllerutil.RemoveFinalizer(context.CIResource, ofcirv1.OfcirFinalizer)
 			return f.UpdateResourceOnly()
 		}
@@ -258,40 +718,49 @@ func (f *CIResourceFSM) handleStateDelete(context CIResourceFSMContext) (time.Du
 // CIResourceFSMHandler is used to handle a state process
 type CIResourceFSMHandler func(context CIResourceFSMContext) (time.Duration, error)
 
-// CIResourceFSMGuard is used when moving from one state to another one
-type CIResourceFSMGuard func(context CIResourceFSMContext) bool
-
 type fsmTransition struct {
 	eventId string
 	dst     ofcirv1.CIResourceState
-	guard   CIResourceFSMGuard
+	hook    ofcirv1.HookPoint
 }
 
-// Triggers the transition from one state to another one. A guard condition could be specified
-// to allow/deny the transition
-func Transition(eventId string, dst ofcirv1.CIResourceState, guard ...CIResourceFSMGuard) *fsmTransition {
-	t := &fsmTransition{
+// Triggers the transition from one state to another one
+func Transition(eventId string, dst ofcirv1.CIResourceState) *fsmTransition {
+	return &fsmTransition{
 		eventId: eventId,
 		dst:     dst,
 	}
+}
 
-	if len(guard) == 1 {
-		t.guard = guard[0]
-	}
-
+// WithHook runs the operator and pool hooks for the given point before the
+// transition. A failing hook blocks the transition, so the hooks are also used
+// for guarding it
+func (t *fsmTransition) WithHook(point ofcirv1.HookPoint) *fsmTransition {
+	t.hook = point
 	return t
 }
 
+// hookError is returned by TriggerEvent when a hook blocked the transition
+type hookError struct {
+	error
+}
+
+func (e hookError) Unwrap() error {
+	return e.error
+}
+
 type fsmState struct {
 	id          ofcirv1.CIResourceState
 	transitions map[string]fsmTransition
+	events      []string // the transitions events, in declaration order
 	onEntry     CIResourceFSMHandler
 }
 
 type CIResourceFSMContext struct {
-	CIResource *ofcirv1.CIResource
-	CIPool     *ofcirv1.CIPool
-	Provider   providers.Provider
+	CIResource   *ofcirv1.CIResource
+	CIPool       *ofcirv1.CIPool
+	CIPoolSecret *v1.Secret
+	Provider     providers.Provider
 }
 
 type CIResourceFSM struct {
@@ -303,7 +772,18 @@ type CIResourceFSM struct {
 	statusDirty    bool
 	resourceDirty  bool
 	states         map[ofcirv1.CIResourceState]fsmState
-	beforeAnyState CIResourceFSMHandler
+	order          []ofcirv1.CIResourceState
+	span           trace.Span
+	newProvider    func(*ofcirv1.CIPool, *v1.Secret, logr.Logger) (providers.Provider, error)
+	hooks          *hooks.Registry
+	ctx            context.Context
+	probe          func(ofcirv1.Probe, string, []byte) error
+	scripts        *scriptRunner
+	// How long the readiness checks of a reconcile can last
+	readinessTimeout time.Duration
+	// Lists the resources of a pool, for enforcing its concurrency limits. No
+	// limit is enforced if not set
+	poolResources func(*ofcirv1.CIPool) ([]ofcirv1.CIResource, error)
 }
 
 func (f *CIResourceFSM) State(id ofcirv1.CIResourceState, onEntry CIResourceFSMHandler, transitions ...*fsmTransition) *CIResourceFSM {
@@ -315,29 +795,98 @@ func (f *CIResourceFSM) State(id ofcirv1.CIResourceState, onEntry CIResourceFSMH
 
 	for _, t := range transitions {
 		state.transitions[t.eventId] = *t
+		state.events = append(state.events, t.eventId)
+	}
+	if _, ok := f.states[id]; !ok {
+		f.order = append(f.order, id)
 	}
 	f.states[id] = state
 
 	return f
 }
 
-// This handler will be invoked before evaluating any selected state
-func (f *CIResourceFSM) BeforeAnyState(before CIResourceFSMHandler) {
-	f.beforeAnyState = before
+// FSMTransition describes a transition allowed by the CIResourceFSM
+type FSMTransition struct {
+	Event string
+	Src   ofcirv1.CIResourceState
+	Dst   ofcirv1.CIResourceState
+	// The pool hooks run before the transition, if any
+	Hook ofcirv1.HookPoint
+}
+
+// States returns the states of the fsm, in declaration order
+func (f *CIResourceFSM) States() []ofcirv1.CIResourceState {
+	return append([]ofcirv1.CIResourceState{}, f.order...)
+}
+
+// Transitions returns all the transitions of the fsm, grouped by source state
+// in declaration order
+func (f *CIResourceFSM) Transitions() []FSMTransition {
+	var transitions []FSMTransition
+	for _, id := range f.order {
+		state := f.states[id]
+		for _, event := range state.events {
+			t := state.transitions[event]
+			transitions = append(transitions, FSMTransition{
+				Event: t.eventId,
+				Src:   id,
+				Dst:   t.dst,
+				Hook:  t.hook,
+			})
+		}
+	}
+	return transitions
+}
+
+// runHooks executes the operator hooks and the ones configured in the pool
+// for the given point
+func (f *CIResourceFSM) runHooks(point ofcirv1.HookPoint) error {
+	cir := f.currentContext.CIResource
+	pool := f.currentContext.CIPool
+	if len(pool.Spec.Hooks) > 0 && point != hooks.PointBeforeAnyState {
+		f.logger.Info("running hooks", "id", cir.Status.ResourceId, "point", point)
+	}
+	return f.hooks.Run(f.ctx, pool.Spec.Hooks, hooks.Event{
+		Point:      point,
+		Namespace:  cir.Namespace,
+		Pool:       pool.Name,
+		CIResource: cir.Name,
+		ResourceId: cir.Status.ResourceId,
+		Address:    cir.Status.Address,
+	})
+}
+
+func (f *CIResourceFSM) Process(ctx context.Context, cir *ofcirv1.CIResource, cipool *ofcirv1.CIPool, cipoolSecret *v1.Secret) (bool, bool, time.Duration, error) {
+
+	ctx, span := tracer.Start(ctx, "CIResourceFSM.Process", trace.WithAttributes(
+		attribute.String("ofcir.cir", cir.Name),
+		attribute.String("ofcir.state", string(cir.Status.State)),
+	))
+	defer span.End()
+
+	resourceDirty, statusDirty, retryAfter, err := f.process(ctx, cir, cipool, cipoolSecret)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return resourceDirty, statusDirty, retryAfter, err
 }
 
-func (f *CIResourceFSM) Process(cir *ofcirv1.CIResource, cipool *ofcirv1.CIPool, cipoolSecret *v1.Secret) (bool, bool, time.Duration, error) {
+func (f *CIResourceFSM) process(ctx context.Context, cir *ofcirv1.CIResource, cipool *ofcirv1.CIPool, cipoolSecret *v1.Secret) (bool, bool, time.Duration, error) {
 
-	provider, err := providers.NewProvider(cipool, cipoolSecret, f.logger)
+	provider, err := f.newProvider(cipool, cipoolSecret, f.logger)
 	if err != nil {
 		return false, false, time.Duration(0), fmt.Errorf("error in provider factory: %w", err)
 	}
 
 	context := CIResourceFSMContext{
-		CIResource: cir,
-		CIPool:     cipool,
-		Provider:   provider,
+		CIResource:   cir,
+		CIPool:       cipool,
+		CIPoolSecret: cipoolSecret,
+		Provider:     providers.WithTracing(ctx, provider, cipool.Spec.Provider),
 	}
+	f.span = trace.SpanFromContext(ctx)
+	f.ctx = ctx
 
 	state, ok := f.states[context.CIResource.Status.State]
 	if !ok {
@@ -346,12 +895,9 @@ func (f *CIResourceFSM) Process(cir *ofcirv1.CIResource, cipool *ofcirv1.CIPool,
 	f.currentState = &state
 	f.currentContext = context
 
-	// Evaluate main handler before managing state ones
-	if f.beforeAnyState != nil {
-		retryAfter, err := f.beforeAnyState(context)
-		if retryAfter != 0 || err != nil {
-			return f.resourceDirty, f.statusDirty, retryAfter, fmt.Errorf("error in main handler: %w", err)
-		}
+	// Evaluate the operator hooks before managing the state
+	if err := f.runHooks(hooks.PointBeforeAnyState); err != nil {
+		return f.resourceDirty, f.statusDirty, defaultCirRetryDelay, err
 	}
 
 	f.debuglogger.Info("state -->", "state", state.id)
@@ -359,12 +905,62 @@ func (f *CIResourceFSM) Process(cir *ofcirv1.CIResource, cipool *ofcirv1.CIPool,
 
 	if err != nil {
 		f.logger.Error(err, "error caught while processing state", "state", state.id)
+		retryAfter, err = f.handleFailure(err)
+	} else if context.CIResource.Status.Failures > 0 && context.CIResource.Status.State == state.id && state.id != ofcirv1.StateError {
+		// The state was successfully processed, so the failures are not consecutive anymore
+		context.CIResource.Status.Failures = 0
+		context.CIResource.Status.LastError = ""
+		f.statusDirty = true
 	}
 	f.debuglogger.Info("state <--", "state", state.id)
 
 	return f.resourceDirty, f.statusDirty, retryAfter, err
 }
 
+// handleFailure keeps track of the consecutive failures of the current state, and
+// moves the resource to the error state once the pool threshold is reached (if
+// allowed by the state). Until then, the state is retried with an exponential backoff
+func (f *CIResourceFSM) handleFailure(err error) (time.Duration, error) {
+	cir := f.currentContext.CIResource
+
+	if f.span != nil {
+		f.span.RecordError(err)
+	}
+
+	cir.Status.Failures++
+	cir.Status.LastError = err.Error()
+	f.statusDirty = true
+
+	if _, ok := f.currentState.transitions["on-error"]; ok && cir.Status.Failures >= f.currentContext.CIPool.GetMaxFailures() {
+		f.logger.Info("too many failures, moving resource to error", "Id", cir.Status.ResourceId, "Failures", cir.Status.Failures)
+		return f.moveToError(err.Error())
+	}
+
+	return failureBackoff(cir.Status.Failures), nil
+}
+
+// moveToError moves the resource to the error state for the given reason
+func (f *CIResourceFSM) moveToError(reason string) (time.Duration, error) {
+	cir := f.currentContext.CIResource
+
+	cir.Status.LastError = reason
+	cir.Status.FailedState = cir.Status.State
+
+	// Wait for an explicit request before leaving the error state
+	cir.Spec.State = ofcirv1.StateError
+	f.UpdateResourceOnly()
+	return f.TriggerEvent("on-error")
+}
+
+// failureBackoff returns the delay before retrying after the given number of failures
+func failureBackoff(failures int) time.Duration {
+	delay := defaultCirRetryDelay
+	for i := 1; i < failures && delay < maxCirFailureDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxCirFailureDelay)
+}
+
 func (f *CIResourceFSM) TriggerEvent(name string) (time.Duration, error) {
 
 	if f.currentState == nil {
@@ -376,13 +972,35 @@ func (f *CIResourceFSM) TriggerEvent(name string) (time.Duration, error) {
 		return time.Duration(0), fmt.Errorf("event not found: %s", name)
 	}
 
-	if t.guard != nil && !t.guard(f.currentContext) {
-		return defaultCirRetryDelay, nil
+	if t.hook != "" {
+		if err := f.runHooks(t.hook); err != nil {
+			return defaultCirRetryDelay, hookError{err}
+		}
 	}
 
 	f.logger.Info("triggering state change", "id", f.currentContext.CIResource.Status.ResourceId, "current", f.currentContext.CIResource.Status.State, "new", t.dst)
+	if f.span != nil {
+		f.span.AddEvent(name, trace.WithAttributes(attribute.String("ofcir.new_state", string(t.dst))))
+	}
+
+	cir := f.currentContext.CIResource
+
+	// The failures are tracked per state
+	if t.dst != ofcirv1.StateError {
+		cir.Status.Failures = 0
+		cir.Status.LastError = ""
+		cir.Status.FailedState = ofcirv1.StateNone
+	}
+	cir.Status.AcquireFailed = false
+	// Reprovisions are consecutive until the resource becomes available, or
+	// an explicit action is requested for a resource in error
+	if t.dst == ofcirv1.StateAvailable || cir.Status.State == ofcirv1.StateError {
+		cir.Status.Reprovisions = 0
+	}
 
-	f.currentContext.CIResource.Status.State = t.dst
+	now := metav1.Now()
+	cir.Status.StateChanged = &now
+	cir.Status.State = t.dst
 	f.statusDirty = true
 
 	return defaultCirRetryDelay, nil
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+const diagramHeader = "Generated from NewCIResourceFSM by cmd/ofcir-fsm, do not edit"
+
+// stateName returns the name used in the diagrams for the given state
+func stateName(id ofcirv1.CIResourceState) string {
+	if id == ofcirv1.StateNone {
+		return "none"
+	}
+	return string(id)
+}
+
+// transitionLabel returns the label of the edge for the given transition
+func transitionLabel(t FSMTransition) string {
+	label := t.Event
+	if t.Hook != "" {
+		label += fmt.Sprintf(" (%s hooks)", t.Hook)
+	}
+	return label
+}
+
+// DOT renders the fsm as a Graphviz digraph
+func (f *CIResourceFSM) DOT() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "// %s\n", diagramHeader)
+	b.WriteString("digraph CirStates {\n")
+	b.WriteString("    node [fontname=\"Droid Sans Mono\" style=\"filled\" color=black fillcolor=lightyellow]\n")
+	b.WriteString("    edge [fontsize=\"8p\"]\n\n")
+
+	for _, id := range f.States() {
+		switch id {
+		case ofcirv1.StateNone:
+			fmt.Fprintf(&b, "    %q [shape=\"point\" width=0.2 fillcolor=black]\n", stateName(id))
+		case ofcirv1.StateAvailable:
+			fmt.Fprintf(&b, "    %q [fontsize=\"20p\"]\n", stateName(id))
+		case ofcirv1.StateError:
+			fmt.Fprintf(&b, "    %q [fillcolor=lightpink]\n", stateName(id))
+		default:
+			fmt.Fprintf(&b, "    %q\n", stateName(id))
+		}
+	}
+	b.WriteString("\n")
+
+	for _, t := range f.Transitions() {
+		attrs := fmt.Sprintf("label=%q", transitionLabel(t))
+		if t.Dst == ofcirv1.StateError {
+			attrs += " color=red"
+		}
+		// The hooks can block the transition
+		if t.Hook != "" {
+			attrs += " style=dashed"
+		}
+		fmt.Fprintf(&b, "    %q -> %q [%s]\n", stateName(t.Src), stateName(t.Dst), attrs)
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// Mermaid renders the fsm as a Mermaid state diagram
+func (f *CIResourceFSM) Mermaid() string {
+	// Mermaid ids cannot contain spaces
+	mermaidID := func(id ofcirv1.CIResourceState) string {
+		if id == ofcirv1.StateNone {
+			return "[*]"
+		}
+		return strings.ReplaceAll(string(id), " ", "_")
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%%%% %s\n", diagramHeader)
+	b.WriteString("stateDiagram-v2\n")
+	for _, id := range f.States() {
+		if id != ofcirv1.StateNone && mermaidID(id) != string(id) {
+			fmt.Fprintf(&b, "    state %q as %s\n", string(id), mermaidID(id))
+		}
+	}
+	for _, t := range f.Transitions() {
+		fmt.Fprintf(&b, "    %s --> %s: %s\n", mermaidID(t.Src), mermaidID(t.Dst), transitionLabel(t))
+	}
+
+	return b.String()
+}
+
+// UpdateMermaidBlock replaces the generated Mermaid diagram embedded in a markdown
+// document with the given one. Only the mermaid code block starting with the
+// generated header is replaced
+func UpdateMermaidBlock(doc string, diagram string) (string, error) {
+	const fence = "```mermaid\n"
+
+	start := strings.Index(doc, fence+"%% "+diagramHeader+"\n")
+	if start < 0 {
+		return "", fmt.Errorf("no generated mermaid block found")
+	}
+	start += len(fence)
+	end := strings.Index(doc[start:], "```")
+	if end < 0 {
+		return "", fmt.Errorf("unterminated mermaid block")
+	}
+
+	return doc[:start] + diagram + doc[start+end:], nil
+}
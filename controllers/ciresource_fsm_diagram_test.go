@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/go-logr/logr"
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCIResourceFSMIntrospection(t *testing.T) {
+	fsm := NewCIResourceFSM(logr.Discard())
+
+	states := fsm.States()
+	assert.Equal(t, ofcirv1.StateNone, states[0])
+	assert.Contains(t, states, ofcirv1.StateError)
+
+	for _, tr := range fsm.Transitions() {
+		assert.Contains(t, states, tr.Src, "transition %s", tr.Event)
+		assert.Contains(t, states, tr.Dst, "transition %s", tr.Event)
+	}
+	assert.Contains(t, fsm.Transitions(), FSMTransition{Event: "released", Src: ofcirv1.StateInUse, Dst: ofcirv1.StateCleaning, Hook: ofcirv1.HookPostRelease})
+}
+
+func TestCIResourceFSMDiagramsUpToDate(t *testing.T) {
+	fsm := NewCIResourceFSM(logr.Discard())
+
+	dot, err := os.ReadFile("../docs/cir-states.dot")
+	assert.NoError(t, err)
+	assert.Equal(t, fsm.DOT(), string(dot), "docs/cir-states.dot is out of date, run `make docs`")
+
+	md, err := os.ReadFile("../docs/cir-states.md")
+	assert.NoError(t, err)
+	updated, err := UpdateMermaidBlock(string(md), fsm.Mermaid())
+	assert.NoError(t, err)
+	assert.Equal(t, updated, string(md), "docs/cir-states.md is out of date, run `make docs`")
+}
+
+func TestUpdateMermaidBlock(t *testing.T) {
+	doc := "# Title\n\n```mermaid\nflowchart LR\n```\n\n```mermaid\n%% " + diagramHeader + "\nstateDiagram-v2\n    a --> b: old\n```\nfooter\n"
+
+	updated, err := UpdateMermaidBlock(doc, "%% "+diagramHeader+"\nstateDiagram-v2\n    a --> c: new\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "# Title\n\n```mermaid\nflowchart LR\n```\n\n```mermaid\n%% "+diagramHeader+"\nstateDiagram-v2\n    a --> c: new\n```\nfooter\n", updated)
+
+	_, err = UpdateMermaidBlock("# Title\n", "")
+	assert.Error(t, err)
+}
+
+func TestHookTransitionRendering(t *testing.T) {
+	fsm := NewCIResourceFSM(logr.Discard())
+	fsm.State(ofcirv1.StateDelete, fsm.handleStateDelete,
+		Transition("on-undelete", ofcirv1.StateAvailable).WithHook(ofcirv1.HookPreAcquire))
+
+	assert.Contains(t, fsm.Transitions(), FSMTransition{Event: "on-undelete", Src: ofcirv1.StateDelete, Dst: ofcirv1.StateAvailable, Hook: ofcirv1.HookPreAcquire})
+	assert.Contains(t, fsm.DOT(), `"delete" -> "available" [label="on-undelete (pre-acquire hooks)" style=dashed]`)
+	assert.Contains(t, fsm.Mermaid(), "delete --> available: on-undelete (pre-acquire hooks)")
+}
@@ -1,12 +1,17 @@
 package controllers
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-logr/logr"
 	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/openshift/ofcir/pkg/hooks"
 	"github.com/openshift/ofcir/pkg/providers"
+	"github.com/openshift/ofcir/pkg/utils/sshtest"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,6 +31,11 @@ func TestCIResourceFSMProcess(t *testing.T) {
 		},
 	}
 
+	fakePoolWithDeadlines := fakePool.DeepCopy()
+	fakePoolWithDeadlines.Spec.ProvisioningTimeout = &v1.Duration{Duration: time.Hour}
+	fakePoolWithDeadlines.Spec.CleaningTimeout = &v1.Duration{Duration: time.Hour}
+	fakePoolWithDeadlines.Spec.Timeout = v1.Duration{Duration: time.Hour}
+
 	tests := []struct {
 		name                    string
 		cir                     *ofcirv1.CIResource
@@ -35,6 +45,8 @@ func TestCIResourceFSMProcess(t *testing.T) {
 		expectedState           ofcirv1.CIResourceState
 		expectedRetryAfter      time.Duration
 		expectedError           bool
+		expectedFailures        int
+		provider                *fakeProvider
 	}{
 		{
 			name: "init->init (no finalizer)",
@@ -139,6 +151,42 @@ func TestCIResourceFSMProcess(t *testing.T) {
 			expectedState:         ofcirv1.StateDelete,
 			expectedRetryAfter:    defaultCirRetryDelay,
 		},
+		{
+			name: "inuse->inuse (evicted, not released)",
+			cir: &ofcirv1.CIResource{
+				ObjectMeta: v1.ObjectMeta{
+					DeletionTimestamp: &now,
+				},
+				Spec: ofcirv1.CIResourceSpec{
+					State: ofcirv1.StateInUse,
+				},
+				Status: ofcirv1.CIResourceStatus{
+					State:       ofcirv1.StateInUse,
+					LastUpdated: &now,
+				},
+			},
+			cipool:             fakePoolWithDeadlines,
+			expectedState:      ofcirv1.StateInUse,
+			expectedRetryAfter: defaultCirRetryDelay,
+		},
+		{
+			name: "inuse->delete (evicted, released)",
+			cir: &ofcirv1.CIResource{
+				ObjectMeta: v1.ObjectMeta{
+					DeletionTimestamp: &now,
+				},
+				Spec: ofcirv1.CIResourceSpec{
+					State: ofcirv1.StateAvailable,
+				},
+				Status: ofcirv1.CIResourceStatus{
+					State: ofcirv1.StateInUse,
+				},
+			},
+			cipool:                fakePool,
+			expectedIsStatusDirty: true,
+			expectedState:         ofcirv1.StateDelete,
+			expectedRetryAfter:    defaultCirRetryDelay,
+		},
 		{
 			name: "maintenance->available",
 			cir: &ofcirv1.CIResource{
@@ -154,6 +202,182 @@ func TestCIResourceFSMProcess(t *testing.T) {
 			expectedState:         ofcirv1.StateAvailable,
 			expectedRetryAfter:    defaultCirRetryDelay,
 		},
+		{
+			name: "provisioning-wait failure (below threshold)",
+			cir: &ofcirv1.CIResource{
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId: "not-existing",
+					State:      ofcirv1.StateProvisioningWait,
+					Failures:   1,
+				},
+			},
+			cipool:                fakePool,
+			expectedIsStatusDirty: true,
+			expectedState:         ofcirv1.StateProvisioningWait,
+			expectedRetryAfter:    2 * defaultCirRetryDelay,
+			expectedFailures:      2,
+		},
+		{
+			name: "provisioning-wait->error (threshold reached)",
+			cir: &ofcirv1.CIResource{
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId: "not-existing",
+					State:      ofcirv1.StateProvisioningWait,
+					Failures:   ofcirv1.DefaultMaxFailures - 1,
+				},
+			},
+			cipool:                  fakePool,
+			expectedIsResourceDirty: true,
+			expectedIsStatusDirty:   true,
+			expectedState:           ofcirv1.StateError,
+			expectedRetryAfter:      defaultCirRetryDelay,
+			expectedFailures:        ofcirv1.DefaultMaxFailures,
+		},
+		{
+			name: "provisioning-wait failures reset (resource ready)",
+			cir: &ofcirv1.CIResource{
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId: "dummy-0",
+					State:      ofcirv1.StateProvisioningWait,
+					Failures:   2,
+					LastError:  "resource not found",
+				},
+			},
+			cipool:                fakePool,
+			expectedIsStatusDirty: true,
+			expectedState:         ofcirv1.StateAvailable,
+			expectedRetryAfter:    0,
+		},
+		{
+			name: "error->error (no request)",
+			cir: &ofcirv1.CIResource{
+				Spec: ofcirv1.CIResourceSpec{
+					State: ofcirv1.StateError,
+				},
+				Status: ofcirv1.CIResourceStatus{
+					State:       ofcirv1.StateError,
+					FailedState: ofcirv1.StateCleaning,
+					Failures:    5,
+				},
+			},
+			cipool:             fakePool,
+			expectedState:      ofcirv1.StateError,
+			expectedRetryAfter: defaultCirRetryDelay,
+			expectedFailures:   5,
+		},
+		{
+			name: "error->cleaning-wait (retry)",
+			cir: &ofcirv1.CIResource{
+				Spec: ofcirv1.CIResourceSpec{
+					State: ofcirv1.StateAvailable,
+				},
+				Status: ofcirv1.CIResourceStatus{
+					State:       ofcirv1.StateError,
+					FailedState: ofcirv1.StateCleaningWait,
+					Failures:    5,
+				},
+			},
+			cipool:                fakePool,
+			expectedIsStatusDirty: true,
+			expectedState:         ofcirv1.StateCleaningWait,
+			expectedRetryAfter:    defaultCirRetryDelay,
+		},
+		{
+			name: "error->provisioning (reprovision)",
+			cir: &ofcirv1.CIResource{
+				Spec: ofcirv1.CIResourceSpec{
+					State: ofcirv1.StateProvisioning,
+				},
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId:  "dummy-9",
+					State:       ofcirv1.StateError,
+					FailedState: ofcirv1.StateCleaning,
+					Failures:    5,
+				},
+			},
+			cipool:                  fakePool,
+			expectedIsResourceDirty: true,
+			expectedIsStatusDirty:   true,
+			expectedState:           ofcirv1.StateProvisioning,
+			expectedRetryAfter:      defaultCirRetryDelay,
+		},
+		{
+			name: "error->delete",
+			cir: &ofcirv1.CIResource{
+				ObjectMeta: v1.ObjectMeta{
+					DeletionTimestamp: &now,
+				},
+				Status: ofcirv1.CIResourceStatus{
+					State: ofcirv1.StateError,
+				},
+			},
+			cipool:                fakePool,
+			expectedIsStatusDirty: true,
+			expectedState:         ofcirv1.StateDelete,
+			expectedRetryAfter:    defaultCirRetryDelay,
+		},
+		{
+			name: "provisioning-wait->provisioning-wait (within deadline)",
+			cir: &ofcirv1.CIResource{
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId:   "slow-0",
+					State:        ofcirv1.StateProvisioningWait,
+					StateChanged: &v1.Time{Time: now.Add(-10 * time.Minute)},
+				},
+			},
+			cipool:             fakePoolWithDeadlines,
+			provider:           &fakeProvider{},
+			expectedState:      ofcirv1.StateProvisioningWait,
+			expectedRetryAfter: defaultCirProvisioningWaitDelay,
+		},
+		{
+			name: "provisioning-wait->provisioning (deadline expired)",
+			cir: &ofcirv1.CIResource{
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId:   "slow-0",
+					State:        ofcirv1.StateProvisioningWait,
+					StateChanged: &v1.Time{Time: now.Add(-2 * time.Hour)},
+				},
+			},
+			cipool:                fakePoolWithDeadlines,
+			provider:              &fakeProvider{},
+			expectedIsStatusDirty: true,
+			expectedState:         ofcirv1.StateProvisioning,
+			expectedRetryAfter:    defaultCirRetryDelay,
+		},
+		{
+			name: "cleaning-wait->provisioning (deadline expired)",
+			cir: &ofcirv1.CIResource{
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId:   "slow-0",
+					State:        ofcirv1.StateCleaningWait,
+					StateChanged: &v1.Time{Time: now.Add(-2 * time.Hour)},
+					Reprovisions: 1,
+				},
+			},
+			cipool:                fakePoolWithDeadlines,
+			provider:              &fakeProvider{},
+			expectedIsStatusDirty: true,
+			expectedState:         ofcirv1.StateProvisioning,
+			expectedRetryAfter:    defaultCirRetryDelay,
+		},
+		{
+			name: "cleaning-wait->error (too many reprovisions)",
+			cir: &ofcirv1.CIResource{
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId:   "slow-0",
+					State:        ofcirv1.StateCleaningWait,
+					StateChanged: &v1.Time{Time: now.Add(-2 * time.Hour)},
+					Reprovisions: ofcirv1.DefaultMaxReprovisions,
+				},
+			},
+			cipool:                  fakePoolWithDeadlines,
+			provider:                &fakeProvider{},
+			expectedIsResourceDirty: true,
+			expectedIsStatusDirty:   true,
+			expectedState:           ofcirv1.StateError,
+			expectedRetryAfter:      defaultCirRetryDelay,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -161,7 +385,12 @@ func TestCIResourceFSMProcess(t *testing.T) {
 			fakeLogger := logr.New(log.NullLogSink{})
 
 			fsm := NewCIResourceFSM(fakeLogger)
-			resDirty, statusDirty, retryAfter, err := fsm.Process(tt.cir, tt.cipool, &corev1.Secret{})
+			if tt.provider != nil {
+				fsm.newProvider = func(*ofcirv1.CIPool, *corev1.Secret, logr.Logger) (providers.Provider, error) {
+					return tt.provider, nil
+				}
+			}
+			resDirty, statusDirty, retryAfter, err := fsm.Process(context.TODO(), tt.cir, tt.cipool, &corev1.Secret{})
 			if !tt.expectedError {
 				assert.NoError(t, err)
 			} else {
@@ -171,6 +400,1210 @@ func TestCIResourceFSMProcess(t *testing.T) {
 			assert.Equal(t, tt.expectedRetryAfter, retryAfter)
 			assert.Equal(t, tt.expectedIsResourceDirty, resDirty, "Unexpected resource update")
 			assert.Equal(t, tt.expectedIsStatusDirty, statusDirty, "Unexpected status update")
+			assert.Equal(t, tt.expectedFailures, tt.cir.Status.Failures)
+		})
+	}
+}
+
+func TestFailureBackoff(t *testing.T) {
+	assert.Equal(t, defaultCirRetryDelay, failureBackoff(1))
+	assert.Equal(t, 2*defaultCirRetryDelay, failureBackoff(2))
+	assert.Equal(t, 8*defaultCirRetryDelay, failureBackoff(4))
+	assert.Equal(t, maxCirFailureDelay, failureBackoff(100))
+}
+
+func TestCIResourceFSMReprovision(t *testing.T) {
+	now := v1.Now()
+	pool := &ofcirv1.CIPool{
+		Spec: ofcirv1.CIPoolSpec{
+			Provider:            string(providers.ProviderDummy),
+			ProvisioningTimeout: &v1.Duration{Duration: time.Hour},
+		},
+	}
+	cir := &ofcirv1.CIResource{
+		Status: ofcirv1.CIResourceStatus{
+			ResourceId:   "slow-0",
+			Address:      "1.1.1.1",
+			State:        ofcirv1.StateProvisioningWait,
+			StateChanged: &v1.Time{Time: now.Add(-2 * time.Hour)},
+		},
+	}
+	provider := &fakeProvider{}
+
+	fsm := NewCIResourceFSM(logr.Discard())
+	fsm.newProvider = func(*ofcirv1.CIPool, *corev1.Secret, logr.Logger) (providers.Provider, error) {
+		return provider, nil
+	}
+	_, _, _, err := fsm.Process(context.TODO(), cir, pool, &corev1.Secret{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"slow-0"}, provider.released)
+	assert.Equal(t, ofcirv1.StateProvisioning, cir.Status.State)
+	assert.Empty(t, cir.Status.ResourceId)
+	assert.Empty(t, cir.Status.Address)
+	assert.Equal(t, 1, cir.Status.Reprovisions)
+	assert.Equal(t, "provisioning not completed within 1h0m0s", cir.Status.ReprovisionReason)
+	assert.True(t, cir.Status.StateChanged.After(now.Time))
+}
+
+func TestCIResourceFSMHealthCheck(t *testing.T) {
+	now := v1.Now()
+	unhealthy := errors.New("connection refused")
+
+	tests := []struct {
+		name             string
+		check            ofcirv1.HealthCheck
+		last             *ofcirv1.ProbeStatus
+		probeErr         error
+		expectedProbe    bool
+		expectedState    ofcirv1.CIResourceState
+		expectedFailures int
+		expectedReleased []string
+	}{
+		{
+			name:          "healthy",
+			check:         ofcirv1.HealthCheck{Probe: ofcirv1.Probe{Type: ofcirv1.ProbeTCP}},
+			expectedProbe: true,
+			expectedState: ofcirv1.StateAvailable,
+		},
+		{
+			name:          "not yet due",
+			check:         ofcirv1.HealthCheck{Probe: ofcirv1.Probe{Type: ofcirv1.ProbeTCP}},
+			last:          &ofcirv1.ProbeStatus{Time: v1.NewTime(now.Add(-time.Minute)), Healthy: true},
+			probeErr:      unhealthy,
+			expectedState: ofcirv1.StateAvailable,
+		},
+		{
+			name:             "unhealthy below threshold",
+			check:            ofcirv1.HealthCheck{Probe: ofcirv1.Probe{Type: ofcirv1.ProbeTCP}, FailureThreshold: 3},
+			last:             &ofcirv1.ProbeStatus{Time: v1.NewTime(now.Add(-time.Hour)), Failures: 1},
+			probeErr:         unhealthy,
+			expectedProbe:    true,
+			expectedState:    ofcirv1.StateAvailable,
+			expectedFailures: 2,
+		},
+		{
+			name:             "unhealthy reprovisioned",
+			check:            ofcirv1.HealthCheck{Probe: ofcirv1.Probe{Type: ofcirv1.ProbeTCP}},
+			probeErr:         unhealthy,
+			expectedProbe:    true,
+			expectedState:    ofcirv1.StateProvisioning,
+			expectedFailures: 1,
+			expectedReleased: []string{"slow-0"},
+		},
+		{
+			name:             "unhealthy cleaned",
+			check:            ofcirv1.HealthCheck{Probe: ofcirv1.Probe{Type: ofcirv1.ProbeHTTP}, Action: ofcirv1.HealthCheckActionClean},
+			probeErr:         unhealthy,
+			expectedProbe:    true,
+			expectedState:    ofcirv1.StateCleaning,
+			expectedFailures: 1,
+		},
+		{
+			name:             "failures before becoming available are ignored",
+			check:            ofcirv1.HealthCheck{Probe: ofcirv1.Probe{Type: ofcirv1.ProbeTCP}, FailureThreshold: 2},
+			last:             &ofcirv1.ProbeStatus{Time: v1.NewTime(now.Add(-3 * time.Hour)), Failures: 2},
+			probeErr:         unhealthy,
+			expectedProbe:    true,
+			expectedState:    ofcirv1.StateAvailable,
+			expectedFailures: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &ofcirv1.CIPool{
+				Spec: ofcirv1.CIPoolSpec{
+					Provider:    string(providers.ProviderDummy),
+					HealthCheck: &tt.check,
+				},
+			}
+			cir := &ofcirv1.CIResource{
+				Spec: ofcirv1.CIResourceSpec{
+					State: ofcirv1.StateAvailable,
+				},
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId:   "slow-0",
+					Address:      "1.1.1.1",
+					State:        ofcirv1.StateAvailable,
+					StateChanged: &v1.Time{Time: now.Add(-2 * time.Hour)},
+					HealthCheck:  tt.last,
+				},
+			}
+			provider := &fakeProvider{}
+			secret := &corev1.Secret{Data: map[string][]byte{ofcirv1.SSHPrivateKeySecretKey: []byte("key")}}
+
+			probed := false
+			fsm := NewCIResourceFSM(logr.Discard())
+			fsm.newProvider = func(*ofcirv1.CIPool, *corev1.Secret, logr.Logger) (providers.Provider, error) {
+				return provider, nil
+			}
+			fsm.probe = func(check ofcirv1.Probe, address string, privateKey []byte) error {
+				probed = true
+				assert.Equal(t, "1.1.1.1", address)
+				assert.Equal(t, []byte("key"), privateKey)
+				return tt.probeErr
+			}
+
+			_, statusDirty, _, err := fsm.Process(context.TODO(), cir, pool, secret)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedProbe, probed)
+			assert.Equal(t, tt.expectedProbe, statusDirty)
+			assert.Equal(t, tt.expectedState, cir.Status.State)
+			assert.Equal(t, tt.expectedReleased, provider.released)
+			if tt.expectedProbe {
+				assert.Equal(t, tt.probeErr == nil, cir.Status.HealthCheck.Healthy)
+				assert.Equal(t, tt.expectedFailures, cir.Status.HealthCheck.Failures)
+			}
+		})
+	}
+}
+
+func TestCIResourceFSMDrift(t *testing.T) {
+	now := v1.Now()
+
+	tests := []struct {
+		name             string
+		state            ofcirv1.CIResourceState
+		synced           *v1.Time
+		describer        *fakeDescriber
+		expectedDescribe bool
+		expectedState    ofcirv1.CIResourceState
+		expectedAddress  string
+		expectedReleased []string
+	}{
+		{
+			name:             "unchanged",
+			state:            ofcirv1.StateAvailable,
+			describer:        &fakeDescriber{address: "1.1.1.1"},
+			expectedDescribe: true,
+			expectedState:    ofcirv1.StateAvailable,
+			expectedAddress:  "1.1.1.1",
+		},
+		{
+			name:             "address changed",
+			state:            ofcirv1.StateAvailable,
+			describer:        &fakeDescriber{address: "2.2.2.2"},
+			expectedDescribe: true,
+			expectedState:    ofcirv1.StateAvailable,
+			expectedAddress:  "2.2.2.2",
+		},
+		{
+			name:             "recently synced",
+			state:            ofcirv1.StateAvailable,
+			synced:           &v1.Time{Time: now.Add(-time.Minute)},
+			describer:        &fakeDescriber{missing: true},
+			expectedDescribe: false,
+			expectedState:    ofcirv1.StateAvailable,
+			expectedAddress:  "1.1.1.1",
+		},
+		{
+			name:             "missing while available",
+			state:            ofcirv1.StateAvailable,
+			synced:           &v1.Time{Time: now.Add(-time.Hour)},
+			describer:        &fakeDescriber{missing: true},
+			expectedDescribe: true,
+			expectedState:    ofcirv1.StateProvisioning,
+			expectedReleased: []string{"slow-0"},
+		},
+		{
+			name:             "missing while in maintenance",
+			state:            ofcirv1.StateMaintenance,
+			describer:        &fakeDescriber{missing: true},
+			expectedDescribe: true,
+			expectedState:    ofcirv1.StateProvisioning,
+			expectedReleased: []string{"slow-0"},
+		},
+		{
+			name:             "describe failure ignored",
+			state:            ofcirv1.StateAvailable,
+			describer:        &fakeDescriber{err: errors.New("api unavailable")},
+			expectedDescribe: true,
+			expectedState:    ofcirv1.StateAvailable,
+			expectedAddress:  "1.1.1.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &ofcirv1.CIPool{
+				Spec: ofcirv1.CIPoolSpec{
+					Provider: string(providers.ProviderDummy),
+				},
+			}
+			cir := &ofcirv1.CIResource{
+				Spec: ofcirv1.CIResourceSpec{
+					State: tt.state,
+				},
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId:     "slow-0",
+					Address:        "1.1.1.1",
+					State:          tt.state,
+					ProviderSynced: tt.synced,
+				},
+			}
+
+			fsm := NewCIResourceFSM(logr.Discard())
+			fsm.newProvider = func(*ofcirv1.CIPool, *corev1.Secret, logr.Logger) (providers.Provider, error) {
+				return tt.describer, nil
+			}
+			_, statusDirty, _, err := fsm.Process(context.TODO(), cir, pool, &corev1.Secret{})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedDescribe, tt.describer.described)
+			assert.Equal(t, tt.expectedDescribe, statusDirty)
+			assert.Equal(t, tt.expectedState, cir.Status.State)
+			assert.Equal(t, tt.expectedAddress, cir.Status.Address)
+			assert.Equal(t, tt.expectedReleased, tt.describer.released)
+		})
+	}
+}
+
+func TestCIResourceFSMHooks(t *testing.T) {
+	tests := []struct {
+		name              string
+		hook              string
+		point             ofcirv1.HookPoint
+		specState         ofcirv1.CIResourceState
+		state             ofcirv1.CIResourceState
+		expectedSpecState ofcirv1.CIResourceState
+		expectedState     ofcirv1.CIResourceState
+		expectedFailures  int
+		expectedLastError string
+	}{
+		{
+			name:              "post-provision succeeded",
+			hook:              "ok",
+			point:             ofcirv1.HookPostProvision,
+			specState:         ofcirv1.StateAvailable,
+			state:             ofcirv1.StateProvisioningWait,
+			expectedSpecState: ofcirv1.StateAvailable,
+			expectedState:     ofcirv1.StateAvailable,
+		},
+		{
+			name:              "post-provision failed",
+			hook:              "fail",
+			point:             ofcirv1.HookPostProvision,
+			specState:         ofcirv1.StateAvailable,
+			state:             ofcirv1.StateProvisioningWait,
+			expectedSpecState: ofcirv1.StateAvailable,
+			expectedState:     ofcirv1.StateProvisioningWait,
+			expectedFailures:  1,
+			expectedLastError: "post-provision hook test failed: boom",
+		},
+		{
+			name:              "pre-acquire succeeded",
+			hook:              "ok",
+			point:             ofcirv1.HookPreAcquire,
+			specState:         ofcirv1.StateInUse,
+			state:             ofcirv1.StateAvailable,
+			expectedSpecState: ofcirv1.StateInUse,
+			expectedState:     ofcirv1.StateInUse,
+		},
+		{
+			name:              "pre-acquire failed",
+			hook:              "fail",
+			point:             ofcirv1.HookPreAcquire,
+			specState:         ofcirv1.StateInUse,
+			state:             ofcirv1.StateAvailable,
+			expectedSpecState: ofcirv1.StateMaintenance,
+			expectedState:     ofcirv1.StateMaintenance,
+			expectedLastError: "pre-acquire hook test failed: boom",
+		},
+		{
+			name:              "post-release failed",
+			hook:              "fail",
+			point:             ofcirv1.HookPostRelease,
+			specState:         ofcirv1.StateAvailable,
+			state:             ofcirv1.StateInUse,
+			expectedSpecState: ofcirv1.StateAvailable,
+			expectedState:     ofcirv1.StateInUse,
+			expectedFailures:  1,
+			expectedLastError: "post-release hook test failed: boom",
+		},
+		{
+			name:              "hook at another point",
+			hook:              "fail",
+			point:             ofcirv1.HookPostProvision,
+			specState:         ofcirv1.StateAvailable,
+			state:             ofcirv1.StateInUse,
+			expectedSpecState: ofcirv1.StateAvailable,
+			expectedState:     ofcirv1.StateCleaning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &ofcirv1.CIPool{
+				ObjectMeta: v1.ObjectMeta{Name: "fake-pool"},
+				Spec: ofcirv1.CIPoolSpec{
+					Provider: string(providers.ProviderDummy),
+					Hooks: []ofcirv1.Hook{
+						{Name: "test", Points: []ofcirv1.HookPoint{tt.point}, Builtin: tt.hook},
+					},
+				},
+			}
+			cir := &ofcirv1.CIResource{
+				ObjectMeta: v1.ObjectMeta{Name: "cir-0001", Namespace: "ofcir-system"},
+				Spec: ofcirv1.CIResourceSpec{
+					State: tt.specState,
+				},
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId:     "dummy-0",
+					State:          tt.state,
+					ProviderSynced: &v1.Time{Time: time.Now()},
+					LastUpdated:    &v1.Time{Time: time.Now()},
+				},
+			}
+
+			var events []hooks.Event
+			registry := hooks.NewRegistry()
+			registry.Register("ok", func(ctx context.Context, e hooks.Event) error {
+				events = append(events, e)
+				return nil
+			})
+			registry.Register("fail", func(ctx context.Context, e hooks.Event) error {
+				events = append(events, e)
+				return errors.New("boom")
+			})
+
+			fsm := NewCIResourceFSM(logr.Discard())
+			fsm.hooks = registry
+			_, _, _, err := fsm.Process(context.TODO(), cir, pool, &corev1.Secret{})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedFailures, cir.Status.Failures)
+			assert.Equal(t, tt.expectedSpecState, cir.Spec.State)
+			assert.Equal(t, tt.expectedState, cir.Status.State)
+			assert.Equal(t, tt.expectedLastError, cir.Status.LastError)
+			assert.Equal(t, tt.point == ofcirv1.HookPreAcquire && tt.hook == "fail", cir.Status.AcquireFailed)
+			for _, e := range events {
+				assert.Equal(t, tt.point, e.Point)
+				assert.Equal(t, "fake-pool", e.Pool)
+				assert.Equal(t, "cir-0001", e.CIResource)
+				assert.Equal(t, "dummy-0", e.ResourceId)
+			}
 		})
 	}
 }
+
+func TestCIResourceFSMOperatorHooks(t *testing.T) {
+	tests := []struct {
+		name              string
+		point             ofcirv1.HookPoint
+		expectedErr       string
+		expectedSpecState ofcirv1.CIResourceState
+		expectedState     ofcirv1.CIResourceState
+		expectedLastError string
+	}{
+		{
+			name:              "before any state",
+			point:             hooks.PointBeforeAnyState,
+			expectedErr:       "before-any-state hook guard failed: paused",
+			expectedSpecState: ofcirv1.StateInUse,
+			expectedState:     ofcirv1.StateAvailable,
+		},
+		{
+			name:              "guarding the acquisition",
+			point:             ofcirv1.HookPreAcquire,
+			expectedSpecState: ofcirv1.StateMaintenance,
+			expectedState:     ofcirv1.StateMaintenance,
+			expectedLastError: "pre-acquire hook guard failed: paused",
+		},
+		{
+			name:              "other point",
+			point:             ofcirv1.HookPostRelease,
+			expectedSpecState: ofcirv1.StateInUse,
+			expectedState:     ofcirv1.StateInUse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The pool does not configure any hook
+			pool := &ofcirv1.CIPool{
+				ObjectMeta: v1.ObjectMeta{Name: "fake-pool"},
+				Spec: ofcirv1.CIPoolSpec{
+					Provider: string(providers.ProviderDummy),
+				},
+			}
+			cir := &ofcirv1.CIResource{
+				ObjectMeta: v1.ObjectMeta{Name: "cir-0001", Namespace: "ofcir-system"},
+				Spec: ofcirv1.CIResourceSpec{
+					State: ofcirv1.StateInUse,
+				},
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId: "dummy-0",
+					State:      ofcirv1.StateAvailable,
+				},
+			}
+
+			var events []hooks.Event
+			registry := hooks.NewRegistry()
+			registry.Use(tt.point, "guard", func(ctx context.Context, e hooks.Event) error {
+				events = append(events, e)
+				return errors.New("paused")
+			})
+
+			fsm := NewCIResourceFSM(logr.Discard())
+			fsm.hooks = registry
+			_, _, _, err := fsm.Process(context.TODO(), cir, pool, &corev1.Secret{})
+
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedSpecState, cir.Spec.State)
+			assert.Equal(t, tt.expectedState, cir.Status.State)
+			assert.Equal(t, tt.expectedLastError, cir.Status.LastError)
+			if tt.point == ofcirv1.HookPostRelease {
+				assert.Empty(t, events)
+				return
+			}
+			assert.Len(t, events, 1)
+			for _, e := range events {
+				assert.Equal(t, tt.point, e.Point)
+				assert.Equal(t, "cir-0001", e.CIResource)
+			}
+		})
+	}
+}
+
+func TestCIResourceFSMReadiness(t *testing.T) {
+	notReady := errors.New("connection refused")
+
+	tests := []struct {
+		name             string
+		state            ofcirv1.CIResourceState
+		probeErrs        []error
+		expectedProbes   int
+		expectedState    ofcirv1.CIResourceState
+		expectedMessage  string
+		expectedFailures int
+	}{
+		{
+			name:           "provisioned and ready",
+			state:          ofcirv1.StateProvisioningWait,
+			probeErrs:      []error{nil, nil},
+			expectedProbes: 2,
+			expectedState:  ofcirv1.StateAvailable,
+		},
+		{
+			name:             "provisioned but not ready",
+			state:            ofcirv1.StateProvisioningWait,
+			probeErrs:        []error{nil, notReady},
+			expectedProbes:   2,
+			expectedState:    ofcirv1.StateProvisioningWait,
+			expectedMessage:  "cloud-init check failed: connection refused",
+			expectedFailures: 1,
+		},
+		{
+			name:           "cleaned and ready",
+			state:          ofcirv1.StateCleaningWait,
+			probeErrs:      []error{nil, nil},
+			expectedProbes: 2,
+			expectedState:  ofcirv1.StateAvailable,
+		},
+		{
+			name:             "cleaned but not ready",
+			state:            ofcirv1.StateCleaningWait,
+			probeErrs:        []error{notReady, nil},
+			expectedProbes:   1,
+			expectedState:    ofcirv1.StateCleaningWait,
+			expectedMessage:  "tcp check failed: connection refused",
+			expectedFailures: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &ofcirv1.CIPool{
+				Spec: ofcirv1.CIPoolSpec{
+					Provider: string(providers.ProviderDummy),
+					ReadinessChecks: []ofcirv1.Probe{
+						{Type: ofcirv1.ProbeTCP, Port: 6443},
+						{Type: ofcirv1.ProbeCloudInit},
+					},
+				},
+			}
+			cir := &ofcirv1.CIResource{
+				Spec: ofcirv1.CIResourceSpec{
+					State: ofcirv1.StateAvailable,
+				},
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId: "dummy-2",
+					Address:    "1.1.1.2",
+					State:      tt.state,
+				},
+			}
+
+			probes := 0
+			fsm := NewCIResourceFSM(logr.Discard())
+			fsm.probe = func(check ofcirv1.Probe, address string, privateKey []byte) error {
+				assert.Equal(t, pool.Spec.ReadinessChecks[probes], check)
+				assert.Equal(t, "1.1.1.2", address)
+				probes++
+				return tt.probeErrs[probes-1]
+			}
+			_, statusDirty, _, err := fsm.Process(context.TODO(), cir, pool, &corev1.Secret{})
+
+			assert.NoError(t, err)
+			assert.True(t, statusDirty)
+			assert.Equal(t, tt.expectedProbes, probes)
+			assert.Equal(t, tt.expectedState, cir.Status.State)
+			assert.Equal(t, tt.expectedMessage == "", cir.Status.Readiness.Healthy)
+			assert.Equal(t, tt.expectedMessage, cir.Status.Readiness.Message)
+			assert.Equal(t, tt.expectedFailures, cir.Status.Readiness.Failures)
+		})
+	}
+}
+
+func TestCIResourceFSMReadinessTimeout(t *testing.T) {
+	pool := &ofcirv1.CIPool{
+		Spec: ofcirv1.CIPoolSpec{
+			Provider: string(providers.ProviderDummy),
+			ReadinessChecks: []ofcirv1.Probe{
+				{Type: ofcirv1.ProbeTCP, Port: 6443},
+				{Type: ofcirv1.ProbeCloudInit},
+			},
+		},
+	}
+	cir := &ofcirv1.CIResource{
+		Spec: ofcirv1.CIResourceSpec{
+			State: ofcirv1.StateAvailable,
+		},
+		Status: ofcirv1.CIResourceStatus{
+			ResourceId: "dummy-2",
+			Address:    "1.1.1.2",
+			State:      ofcirv1.StateProvisioningWait,
+		},
+	}
+
+	var probed []ofcirv1.ProbeType
+	fsm := NewCIResourceFSM(logr.Discard())
+	fsm.readinessTimeout = 100 * time.Millisecond
+	fsm.probe = func(check ofcirv1.Probe, address string, privateKey []byte) error {
+		// The probe is given only the time left
+		assert.LessOrEqual(t, check.GetTimeout(), fsm.readinessTimeout)
+		probed = append(probed, check.Type)
+		time.Sleep(fsm.readinessTimeout)
+		return nil
+	}
+	_, _, _, err := fsm.Process(context.TODO(), cir, pool, &corev1.Secret{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []ofcirv1.ProbeType{ofcirv1.ProbeTCP}, probed)
+	assert.Equal(t, ofcirv1.StateProvisioningWait, cir.Status.State)
+	assert.False(t, cir.Status.Readiness.Healthy)
+	assert.Equal(t, "cloud-init check failed: not run, the readiness checks did not complete within 100ms", cir.Status.Readiness.Message)
+}
+
+func TestCIResourceFSMCleaningScript(t *testing.T) {
+	stateChanged := v1.NewTime(time.Now().Add(-time.Minute))
+
+	tests := []struct {
+		name             string
+		mode             ofcirv1.CleaningScriptMode
+		state            ofcirv1.CIResourceState
+		lastRun          *ofcirv1.CleaningScriptStatus
+		output           string
+		exitStatus       int
+		expectedCleaned  int
+		expectedCommands int
+		expectedState    ofcirv1.CIResourceState
+		expectedStatus   *ofcirv1.CleaningScriptStatus
+		expectedFailures int
+	}{
+		{
+			name:            "provider cleaning requested",
+			mode:            ofcirv1.CleaningScriptAfter,
+			state:           ofcirv1.StateCleaning,
+			expectedCleaned: 1,
+			expectedState:   ofcirv1.StateCleaningWait,
+		},
+		{
+			name:            "provider cleaning replaced",
+			mode:            ofcirv1.CleaningScriptReplace,
+			state:           ofcirv1.StateCleaning,
+			expectedCleaned: 0,
+			expectedState:   ofcirv1.StateCleaningWait,
+		},
+		{
+			name:          "waiting for provider cleaning",
+			mode:          ofcirv1.CleaningScriptAfter,
+			state:         ofcirv1.StateCleaningWait,
+			expectedState: ofcirv1.StateCleaningWait,
+		},
+		{
+			name:             "script completed",
+			mode:             ofcirv1.CleaningScriptReplace,
+			state:            ofcirv1.StateCleaningWait,
+			output:           "removed /tmp/job",
+			expectedCommands: 1,
+			expectedState:    ofcirv1.StateAvailable,
+			expectedStatus:   &ofcirv1.CleaningScriptStatus{Succeeded: true, Output: "removed /tmp/job"},
+		},
+		{
+			name:             "script failed",
+			mode:             ofcirv1.CleaningScriptReplace,
+			state:            ofcirv1.StateCleaningWait,
+			output:           "device busy",
+			exitStatus:       3,
+			expectedCommands: 1,
+			expectedState:    ofcirv1.StateCleaningWait,
+			expectedStatus: &ofcirv1.CleaningScriptStatus{Output: "device busy",
+				Message: "cleaning script failed: Process exited with status 3"},
+			expectedFailures: 1,
+		},
+		{
+			name:             "script output truncated",
+			mode:             ofcirv1.CleaningScriptReplace,
+			state:            ofcirv1.StateCleaningWait,
+			output:           strings.Repeat("x", ofcirv1.MaxCleaningScriptOutput) + "done",
+			expectedCommands: 1,
+			expectedState:    ofcirv1.StateAvailable,
+			expectedStatus:   &ofcirv1.CleaningScriptStatus{Succeeded: true, Output: strings.Repeat("x", ofcirv1.MaxCleaningScriptOutput-4) + "done"},
+		},
+		{
+			name:             "script already run in the current cleaning",
+			mode:             ofcirv1.CleaningScriptReplace,
+			state:            ofcirv1.StateCleaningWait,
+			lastRun:          &ofcirv1.CleaningScriptStatus{Time: v1.Now(), Succeeded: true, Output: "previous"},
+			expectedCommands: 0,
+			expectedState:    ofcirv1.StateAvailable,
+			expectedStatus:   &ofcirv1.CleaningScriptStatus{Succeeded: true, Output: "previous"},
+		},
+		{
+			name:             "script run in a previous cleaning",
+			mode:             ofcirv1.CleaningScriptReplace,
+			state:            ofcirv1.StateCleaningWait,
+			lastRun:          &ofcirv1.CleaningScriptStatus{Time: v1.NewTime(stateChanged.Add(-time.Hour)), Succeeded: true, Output: "previous"},
+			output:           "current",
+			expectedCommands: 1,
+			expectedState:    ofcirv1.StateAvailable,
+			expectedStatus:   &ofcirv1.CleaningScriptStatus{Succeeded: true, Output: "current"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, err := sshtest.NewServer(func(command string) (string, int) {
+				return tt.output, tt.exitStatus
+			})
+			assert.NoError(t, err)
+			defer srv.Close()
+
+			pool := &ofcirv1.CIPool{
+				Spec: ofcirv1.CIPoolSpec{
+					CleaningScript: &ofcirv1.CleaningScript{
+						Script: "rm -rf /tmp/job",
+						Mode:   tt.mode,
+						Port:   srv.Port,
+					},
+				},
+			}
+			secret := &corev1.Secret{
+				Data: map[string][]byte{ofcirv1.SSHPrivateKeySecretKey: srv.PrivateKey},
+			}
+			cir := &ofcirv1.CIResource{
+				Spec: ofcirv1.CIResourceSpec{
+					State: ofcirv1.StateAvailable,
+				},
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId:     "slow-0",
+					Address:        srv.Host,
+					State:          tt.state,
+					StateChanged:   &stateChanged,
+					CleaningScript: tt.lastRun,
+				},
+			}
+
+			provider := &fakeProvider{}
+			scripts := newScriptRunner()
+			fsm := NewCIResourceFSM(logr.Discard())
+			fsm.newProvider = func(*ofcirv1.CIPool, *corev1.Secret, logr.Logger) (providers.Provider, error) {
+				return provider, nil
+			}
+			fsm.scripts = scripts
+			_, _, retryAfter, err := fsm.Process(context.TODO(), cir, pool, secret)
+			assert.NoError(t, err)
+
+			// The script runs in the background, and its result is collected
+			// by the next reconcile
+			if waitScripts(scripts) {
+				assert.Equal(t, defaultCirScriptPollDelay, retryAfter)
+				assert.Equal(t, ofcirv1.StateCleaningWait, cir.Status.State)
+				assert.Equal(t, tt.lastRun, cir.Status.CleaningScript)
+				_, _, _, err = fsm.Process(context.TODO(), cir, pool, secret)
+				assert.NoError(t, err)
+			}
+
+			assert.Len(t, provider.cleaned, tt.expectedCleaned)
+			assert.Len(t, srv.Commands(), tt.expectedCommands)
+			for _, c := range srv.Commands() {
+				assert.Equal(t, "rm -rf /tmp/job", c)
+			}
+			assert.Equal(t, tt.expectedState, cir.Status.State)
+			assert.Equal(t, tt.expectedFailures, cir.Status.Failures)
+			if tt.expectedStatus == nil {
+				assert.Nil(t, cir.Status.CleaningScript)
+			} else {
+				assert.Equal(t, tt.expectedStatus.Succeeded, cir.Status.CleaningScript.Succeeded)
+				assert.Equal(t, tt.expectedStatus.Output, cir.Status.CleaningScript.Output)
+				assert.Equal(t, tt.expectedStatus.Message, cir.Status.CleaningScript.Message)
+			}
+		})
+	}
+}
+
+// waitScripts waits for the scripts started by the runner to complete. Returns
+// true if any was started
+func waitScripts(r *scriptRunner) bool {
+	r.mu.Lock()
+	runs := make([]*scriptRun, 0, len(r.runs))
+	for _, run := range r.runs {
+		runs = append(runs, run)
+	}
+	r.mu.Unlock()
+
+	for _, run := range runs {
+		<-run.done
+	}
+	return len(runs) > 0
+}
+
+func TestCIResourceFSMCleaningScriptInProgress(t *testing.T) {
+	release := make(chan struct{})
+	srv, err := sshtest.NewServer(func(command string) (string, int) {
+		<-release
+		return "done", 0
+	})
+	assert.NoError(t, err)
+	defer srv.Close()
+
+	stateChanged := v1.NewTime(time.Now().Add(-time.Minute))
+	pool := &ofcirv1.CIPool{
+		Spec: ofcirv1.CIPoolSpec{
+			CleaningScript: &ofcirv1.CleaningScript{Script: "rm -rf /tmp/job", Mode: ofcirv1.CleaningScriptReplace, Port: srv.Port},
+		},
+	}
+	secret := &corev1.Secret{
+		Data: map[string][]byte{ofcirv1.SSHPrivateKeySecretKey: srv.PrivateKey},
+	}
+	cir := &ofcirv1.CIResource{
+		ObjectMeta: v1.ObjectMeta{Name: "cir-0", Namespace: "ofcir-system"},
+		Spec:       ofcirv1.CIResourceSpec{State: ofcirv1.StateAvailable},
+		Status: ofcirv1.CIResourceStatus{
+			ResourceId:   "slow-0",
+			Address:      srv.Host,
+			State:        ofcirv1.StateCleaningWait,
+			StateChanged: &stateChanged,
+		},
+	}
+
+	scripts := newScriptRunner()
+	process := func() time.Duration {
+		fsm := NewCIResourceFSM(logr.Discard())
+		fsm.newProvider = func(*ofcirv1.CIPool, *corev1.Secret, logr.Logger) (providers.Provider, error) {
+			return &fakeProvider{}, nil
+		}
+		fsm.scripts = scripts
+		_, _, retryAfter, err := fsm.Process(context.TODO(), cir, pool, secret)
+		assert.NoError(t, err)
+		return retryAfter
+	}
+
+	// The reconciles return while the script is running, without starting it again
+	for range 3 {
+		assert.Equal(t, defaultCirScriptPollDelay, process())
+		assert.Equal(t, ofcirv1.StateCleaningWait, cir.Status.State)
+		assert.Nil(t, cir.Status.CleaningScript)
+	}
+
+	close(release)
+	waitScripts(scripts)
+	process()
+	assert.Len(t, srv.Commands(), 1)
+	assert.Equal(t, ofcirv1.StateAvailable, cir.Status.State)
+	assert.True(t, cir.Status.CleaningScript.Succeeded)
+	assert.Empty(t, scripts.runs)
+}
+
+func TestCIResourceFSMConcurrencyLimits(t *testing.T) {
+	now := v1.Now()
+	earlier := &v1.Time{Time: now.Add(-time.Minute)}
+	later := &v1.Time{Time: now.Add(time.Minute)}
+
+	poolCir := func(name string, state ofcirv1.CIResourceState, stateChanged *v1.Time) ofcirv1.CIResource {
+		return ofcirv1.CIResource{
+			ObjectMeta: v1.ObjectMeta{Name: name},
+			Status:     ofcirv1.CIResourceStatus{State: state, StateChanged: stateChanged},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		state         ofcirv1.CIResourceState
+		requiredState ofcirv1.CIResourceState
+		priority      int
+		provisioning  int
+		cleaning      int
+		poolCirs      []ofcirv1.CIResource
+		listErr       error
+
+		expectedState      ofcirv1.CIResourceState
+		expectedRetryAfter time.Duration
+		expectedFailures   int
+		expectedCleaned    []string
+	}{
+		{
+			name:         "provisioning slot available",
+			state:        ofcirv1.StateProvisioning,
+			provisioning: 2,
+			poolCirs:     []ofcirv1.CIResource{poolCir("cir-1", ofcirv1.StateProvisioningWait, earlier)},
+
+			expectedState:      ofcirv1.StateProvisioningWait,
+			expectedRetryAfter: defaultCirRetryDelay,
+		},
+		{
+			name:         "provisioning slots busy",
+			state:        ofcirv1.StateProvisioning,
+			provisioning: 1,
+			poolCirs:     []ofcirv1.CIResource{poolCir("cir-1", ofcirv1.StateProvisioningWait, earlier)},
+
+			expectedState:      ofcirv1.StateProvisioning,
+			expectedRetryAfter: defaultCirSlotWaitDelay,
+		},
+		{
+			name:         "free provisioning slot taken by an earlier resource",
+			state:        ofcirv1.StateProvisioning,
+			provisioning: 2,
+			poolCirs: []ofcirv1.CIResource{
+				poolCir("cir-1", ofcirv1.StateProvisioningWait, earlier),
+				poolCir("cir-2", ofcirv1.StateProvisioning, earlier),
+			},
+
+			expectedState:      ofcirv1.StateProvisioning,
+			expectedRetryAfter: defaultCirSlotWaitDelay,
+		},
+		{
+			name:         "free provisioning slot taken before a later resource",
+			state:        ofcirv1.StateProvisioning,
+			provisioning: 2,
+			poolCirs: []ofcirv1.CIResource{
+				poolCir("cir-1", ofcirv1.StateProvisioningWait, earlier),
+				poolCir("cir-2", ofcirv1.StateProvisioning, later),
+			},
+
+			expectedState:      ofcirv1.StateProvisioningWait,
+			expectedRetryAfter: defaultCirRetryDelay,
+		},
+		{
+			name:         "fallback resource not requested does not need a slot",
+			state:        ofcirv1.StateProvisioning,
+			priority:     -1,
+			provisioning: 1,
+			poolCirs:     []ofcirv1.CIResource{poolCir("cir-1", ofcirv1.StateProvisioningWait, earlier)},
+
+			expectedState:      ofcirv1.StateAvailable,
+			expectedRetryAfter: defaultCirRetryDelay,
+		},
+		{
+			name:          "requested fallback resource waits for a slot",
+			state:         ofcirv1.StateProvisioning,
+			requiredState: ofcirv1.StateInUse,
+			priority:      -1,
+			provisioning:  1,
+			poolCirs:      []ofcirv1.CIResource{poolCir("cir-1", ofcirv1.StateProvisioningWait, earlier)},
+
+			expectedState:      ofcirv1.StateProvisioning,
+			expectedRetryAfter: defaultCirSlotWaitDelay,
+		},
+		{
+			name:     "cleaning slots busy",
+			state:    ofcirv1.StateCleaning,
+			cleaning: 1,
+			poolCirs: []ofcirv1.CIResource{poolCir("cir-1", ofcirv1.StateCleaningWait, earlier)},
+
+			expectedState:      ofcirv1.StateCleaning,
+			expectedRetryAfter: defaultCirSlotWaitDelay,
+		},
+		{
+			name:     "cleaning slot available",
+			state:    ofcirv1.StateCleaning,
+			cleaning: 1,
+			poolCirs: []ofcirv1.CIResource{poolCir("cir-1", ofcirv1.StateProvisioningWait, earlier)},
+
+			expectedState:      ofcirv1.StateCleaningWait,
+			expectedRetryAfter: defaultCirRetryDelay,
+			expectedCleaned:    []string{"slow-0"},
+		},
+		{
+			name:         "pool resources cannot be listed",
+			state:        ofcirv1.StateProvisioning,
+			provisioning: 1,
+			listErr:      errors.New("cache not synced"),
+
+			expectedState:      ofcirv1.StateProvisioning,
+			expectedRetryAfter: defaultCirRetryDelay,
+			expectedFailures:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &ofcirv1.CIPool{
+				Spec: ofcirv1.CIPoolSpec{
+					Provider:                  string(providers.ProviderDummy),
+					Priority:                  tt.priority,
+					MaxConcurrentProvisioning: tt.provisioning,
+					MaxConcurrentCleaning:     tt.cleaning,
+				},
+			}
+			cir := &ofcirv1.CIResource{
+				ObjectMeta: v1.ObjectMeta{Name: "cir-0"},
+				Spec:       ofcirv1.CIResourceSpec{State: tt.requiredState},
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId:   "slow-0",
+					State:        tt.state,
+					StateChanged: &now,
+				},
+			}
+			provider := &fakeProvider{}
+
+			fsm := NewCIResourceFSM(logr.Discard())
+			fsm.newProvider = func(*ofcirv1.CIPool, *corev1.Secret, logr.Logger) (providers.Provider, error) {
+				return provider, nil
+			}
+			fsm.poolResources = func(*ofcirv1.CIPool) ([]ofcirv1.CIResource, error) {
+				return append(tt.poolCirs, *cir.DeepCopy()), tt.listErr
+			}
+			_, _, retryAfter, err := fsm.Process(context.TODO(), cir, pool, &corev1.Secret{})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedState, cir.Status.State)
+			assert.Equal(t, tt.expectedRetryAfter, retryAfter)
+			assert.Equal(t, tt.expectedFailures, cir.Status.Failures)
+			assert.Equal(t, tt.expectedCleaned, provider.cleaned)
+		})
+	}
+}
+
+func TestSlotQueueQueued(t *testing.T) {
+	pool := &ofcirv1.CIPool{Spec: ofcirv1.CIPoolSpec{MaxConcurrentProvisioning: 2}}
+	poolCirs := []ofcirv1.CIResource{
+		{Status: ofcirv1.CIResourceStatus{State: ofcirv1.StateProvisioningWait}},
+		{Status: ofcirv1.CIResourceStatus{State: ofcirv1.StateProvisioning}},
+		{Status: ofcirv1.CIResourceStatus{State: ofcirv1.StateProvisioning}},
+		{Status: ofcirv1.CIResourceStatus{State: ofcirv1.StateProvisioning}},
+		{Status: ofcirv1.CIResourceStatus{State: ofcirv1.StateCleaning}},
+	}
+
+	assert.Equal(t, 2, provisioningSlots.queued(pool, poolCirs))
+	assert.Equal(t, 0, cleaningSlots.queued(pool, poolCirs), "no cleaning limit")
+
+	pool.Spec.MaxConcurrentProvisioning = 5
+	assert.Equal(t, 0, provisioningSlots.queued(pool, poolCirs))
+}
+
+func TestCIResourceFSMBudget(t *testing.T) {
+	overBudget := v1.Condition{Type: ofcirv1.PoolConditionBudgetExceeded, Status: v1.ConditionTrue, Reason: ofcirv1.ReasonBudgetExceeded}
+
+	tests := []struct {
+		name          string
+		priority      int
+		state         ofcirv1.CIResourceState
+		requiredState ofcirv1.CIResourceState
+		resourceId    string
+
+		expectedState         ofcirv1.CIResourceState
+		expectedRequiredState ofcirv1.CIResourceState
+		expectedAcquireFailed bool
+	}{
+		{
+			name:          "provisioning refused",
+			state:         ofcirv1.StateProvisioning,
+			expectedState: ofcirv1.StateProvisioning,
+		},
+		{
+			name:                  "fallback provisioning refused",
+			priority:              -1,
+			state:                 ofcirv1.StateInUse,
+			requiredState:         ofcirv1.StateInUse,
+			resourceId:            ofcirv1.FallbackResourceID,
+			expectedState:         ofcirv1.StateCleaning,
+			expectedRequiredState: ofcirv1.StateAvailable,
+			expectedAcquireFailed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &ofcirv1.CIPool{
+				ObjectMeta: v1.ObjectMeta{Name: "cipool-aws"},
+				Spec: ofcirv1.CIPoolSpec{
+					Provider: string(providers.ProviderDummy),
+					Priority: tt.priority,
+					Timeout:  v1.Duration{Duration: time.Hour},
+					Hooks: []ofcirv1.Hook{
+						{Name: "test", Points: []ofcirv1.HookPoint{ofcirv1.HookPostRelease}, Builtin: "fail"},
+					},
+				},
+				Status: ofcirv1.CIPoolStatus{Conditions: []v1.Condition{overBudget}},
+			}
+			now := v1.Now()
+			cir := &ofcirv1.CIResource{
+				Spec: ofcirv1.CIResourceSpec{State: tt.requiredState},
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId:  tt.resourceId,
+					State:       tt.state,
+					LastUpdated: &now,
+				},
+			}
+
+			// A refused resource was never handed to the job, so it's not released
+			hookCalls := 0
+			registry := hooks.NewRegistry()
+			registry.Register("fail", func(ctx context.Context, e hooks.Event) error {
+				hookCalls++
+				return errors.New("boom")
+			})
+
+			fsm := NewCIResourceFSM(logr.Discard())
+			fsm.hooks = registry
+			fsm.newProvider = func(*ofcirv1.CIPool, *corev1.Secret, logr.Logger) (providers.Provider, error) {
+				return &fakeProvider{}, nil
+			}
+			_, statusDirty, _, err := fsm.Process(context.TODO(), cir, pool, &corev1.Secret{})
+
+			assert.NoError(t, err)
+			assert.True(t, statusDirty)
+			assert.Zero(t, hookCalls)
+			assert.Equal(t, tt.expectedState, cir.Status.State)
+			assert.Equal(t, tt.expectedRequiredState, cir.Spec.State)
+			assert.Equal(t, tt.resourceId, cir.Status.ResourceId)
+			assert.Equal(t, "provisioning refused, pool cipool-aws exceeded its monthly budget", cir.Status.LastError)
+			assert.Equal(t, tt.expectedAcquireFailed, cir.Status.AcquireFailed)
+		})
+	}
+}
+
+func TestCIResourceFSMInUseTimeout(t *testing.T) {
+	pool := &ofcirv1.CIPool{
+		Spec: ofcirv1.CIPoolSpec{
+			Provider: string(providers.ProviderDummy),
+			Timeout:  v1.Duration{Duration: time.Hour},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		acquired      time.Duration
+		expectedState ofcirv1.CIResourceState
+	}{
+		{
+			name:          "within the timeout",
+			acquired:      30 * time.Minute,
+			expectedState: ofcirv1.StateInUse,
+		},
+		{
+			name:          "timeout expired",
+			acquired:      2 * time.Hour,
+			expectedState: ofcirv1.StateAvailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The status refreshes do not extend the time the resource is held
+			now := v1.Now()
+			acquired := v1.NewTime(now.Add(-tt.acquired))
+			cir := &ofcirv1.CIResource{
+				Spec: ofcirv1.CIResourceSpec{State: ofcirv1.StateInUse},
+				Status: ofcirv1.CIResourceStatus{
+					ResourceId:   "cir-0",
+					State:        ofcirv1.StateInUse,
+					StateChanged: &acquired,
+					LastUpdated:  &now,
+				},
+			}
+
+			fsm := NewCIResourceFSM(logr.Discard())
+			fsm.newProvider = func(*ofcirv1.CIPool, *corev1.Secret, logr.Logger) (providers.Provider, error) {
+				return &fakeProvider{}, nil
+			}
+			_, _, _, err := fsm.Process(context.TODO(), cir, pool, &corev1.Secret{})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedState, cir.Spec.State)
+		})
+	}
+}
+
+func TestCIResourceFSMHourlyPrice(t *testing.T) {
+	pool := &ofcirv1.CIPool{Spec: ofcirv1.CIPoolSpec{Provider: string(providers.ProviderDummy)}}
+	cir := &ofcirv1.CIResource{
+		Status: ofcirv1.CIResourceStatus{ResourceId: "priced-0", State: ofcirv1.StateProvisioningWait},
+	}
+
+	fsm := NewCIResourceFSM(logr.Discard())
+	fsm.newProvider = func(*ofcirv1.CIPool, *corev1.Secret, logr.Logger) (providers.Provider, error) {
+		return &pricedProvider{price: 0.85}, nil
+	}
+	_, _, _, err := fsm.Process(context.TODO(), cir, pool, &corev1.Secret{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ofcirv1.StateAvailable, cir.Status.State)
+	assert.Equal(t, "0.85", cir.Status.HourlyPrice)
+}
+
+// fakeProvider never completes the provisioning and the cleaning
+type fakeProvider struct {
+	released []string
+	cleaned  []string
+}
+
+func (p *fakeProvider) Acquire(poolSize int, poolName string, poolType string) (providers.Resource, error) {
+	return providers.Resource{Id: "slow-0"}, nil
+}
+
+func (p *fakeProvider) AcquireCompleted(id string) (bool, providers.Resource, error) {
+	return false, providers.Resource{}, nil
+}
+
+func (p *fakeProvider) Clean(id string) error {
+	p.cleaned = append(p.cleaned, id)
+	return nil
+}
+
+func (p *fakeProvider) CleanCompleted(id string) (bool, error) {
+	return false, nil
+}
+
+func (p *fakeProvider) Release(id string) error {
+	p.released = append(p.released, id)
+	return nil
+}
+
+// fakeDescriber is a fakeProvider able to report the resource details
+type fakeDescriber struct {
+	fakeProvider
+	address   string
+	missing   bool
+	err       error
+	described bool
+}
+
+func (p *fakeDescriber) Describe(id string) (providers.Resource, error) {
+	p.described = true
+	if p.missing {
+		return providers.Resource{}, providers.NewResourceNotFoundError(id)
+	}
+	return providers.Resource{Id: id, Address: p.address}, p.err
+}
+
+// pricedProvider is a fakeProvider completing the provisioning immediately, and
+// reporting the price of the resource
+type pricedProvider struct {
+	fakeProvider
+	price float64
+}
+
+func (p *pricedProvider) AcquireCompleted(id string) (bool, providers.Resource, error) {
+	return true, providers.Resource{Id: id, Address: "1.1.1.1", HourlyPrice: p.price}, nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+// poolRefField indexes the CIResources by the name of their pool
+const poolRefField = "spec.poolRef.name"
+
+// poolRefIndexer extracts the pool name of a CIResource for the field index
+func poolRefIndexer(obj client.Object) []string {
+	cir, ok := obj.(*ofcirv1.CIResource)
+	if !ok || cir.Spec.PoolRef.Name == "" {
+		return nil
+	}
+	return []string{cir.Spec.PoolRef.Name}
+}
+
+// listPoolResources returns the resources belonging to the given pool, sorted by name
+func listPoolResources(ctx context.Context, c client.Reader, pool *ofcirv1.CIPool) ([]ofcirv1.CIResource, error) {
+	cirs := &ofcirv1.CIResourceList{}
+	if err := c.List(ctx, cirs, client.InNamespace(pool.Namespace), client.MatchingFields{poolRefField: pool.Name}); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(cirs.Items, func(i, j int) bool {
+		return cirs.Items[i].Name < cirs.Items[j].Name
+	})
+	return cirs.Items, nil
+}
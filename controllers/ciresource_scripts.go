@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// scriptRunner runs the cleaning scripts in the background, so that a long
+// script does not hold the reconcile worker. The result of a run is kept until
+// collected by a later reconcile of the resource
+type scriptRunner struct {
+	mu   sync.Mutex
+	runs map[types.NamespacedName]*scriptRun
+}
+
+type scriptRun struct {
+	// The cleaning the script was started for
+	since time.Time
+	done  chan struct{}
+
+	output string
+	err    error
+}
+
+// scriptFunc runs a script, returning its combined output
+type scriptFunc func(ctx context.Context) (string, error)
+
+// Shared by the reconciles of all the resources
+var defaultScriptRunner = newScriptRunner()
+
+func newScriptRunner() *scriptRunner {
+	return &scriptRunner{
+		runs: make(map[types.NamespacedName]*scriptRun),
+	}
+}
+
+// poll returns the result of the script run for the resource cleaning started
+// at the given time, starting the run if required. Returns false while the run
+// is in progress. A run left by a previous cleaning is waited for and discarded,
+// so that two scripts never run on the same resource at the same time
+func (r *scriptRunner) poll(ctx context.Context, key types.NamespacedName, since time.Time, script scriptFunc) (bool, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if run, ok := r.runs[key]; ok {
+		select {
+		case <-run.done:
+		default:
+			return false, "", nil
+		}
+		delete(r.runs, key)
+		if run.since.Equal(since) {
+			return true, run.output, run.err
+		}
+	}
+
+	run := &scriptRun{since: since, done: make(chan struct{})}
+	r.runs[key] = run
+	// The run outlives the reconcile, but not the script timeout
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer close(run.done)
+		run.output, run.err = script(ctx)
+	}()
+	return false, "", nil
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"time"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+// slotQueue describes a provider operation the resources of a pool can run
+// only up to a given number at the same time. The resources wait for a free
+// slot in the state preceding the operation, and hold it while the operation
+// is in progress
+type slotQueue struct {
+	// The state where the resources wait for a slot
+	waiting ofcirv1.CIResourceState
+	// The state where the resources hold a slot
+	running ofcirv1.CIResourceState
+	// The number of slots of the pool, no limit if zero
+	limit func(*ofcirv1.CIPool) int
+	// Returns true if the resource in the waiting state needs a slot
+	needsSlot func(*ofcirv1.CIPool, *ofcirv1.CIResource) bool
+}
+
+var provisioningSlots = slotQueue{
+	waiting: ofcirv1.StateProvisioning,
+	running: ofcirv1.StateProvisioningWait,
+	limit:   func(pool *ofcirv1.CIPool) int { return pool.Spec.MaxConcurrentProvisioning },
+	needsSlot: func(pool *ofcirv1.CIPool, cir *ofcirv1.CIResource) bool {
+		// A fallback resource is provisioned only once requested
+		return !pool.IsFallbackPool() || cir.Spec.State == ofcirv1.StateInUse
+	},
+}
+
+var cleaningSlots = slotQueue{
+	waiting: ofcirv1.StateCleaning,
+	running: ofcirv1.StateCleaningWait,
+	limit:   func(pool *ofcirv1.CIPool) int { return pool.Spec.MaxConcurrentCleaning },
+	needsSlot: func(pool *ofcirv1.CIPool, cir *ofcirv1.CIResource) bool {
+		// A fallback resource is released instead of being cleaned
+		return !pool.IsFallbackPool()
+	},
+}
+
+func (q slotQueue) isWaiting(pool *ofcirv1.CIPool, cir *ofcirv1.CIResource) bool {
+	return cir.Status.State == q.waiting && q.needsSlot(pool, cir)
+}
+
+// hasSlot returns true if the given resource can start the operation. The slots
+// are assigned to the waiting resources in arrival order
+func (q slotQueue) hasSlot(pool *ofcirv1.CIPool, cir *ofcirv1.CIResource, poolCirs []ofcirv1.CIResource) bool {
+	limit := q.limit(pool)
+	if limit <= 0 || !q.isWaiting(pool, cir) {
+		return true
+	}
+
+	busy := 0
+	for i := range poolCirs {
+		c := &poolCirs[i]
+		if c.Name == cir.Name {
+			continue
+		}
+		if c.Status.State == q.running || (q.isWaiting(pool, c) && waitingBefore(c, cir)) {
+			busy++
+		}
+	}
+	return busy < limit
+}
+
+// queued returns how many resources of the pool are waiting for a slot
+func (q slotQueue) queued(pool *ofcirv1.CIPool, poolCirs []ofcirv1.CIResource) int {
+	limit := q.limit(pool)
+	if limit <= 0 {
+		return 0
+	}
+
+	waiting, running := 0, 0
+	for i := range poolCirs {
+		c := &poolCirs[i]
+		switch {
+		case c.Status.State == q.running:
+			running++
+		case q.isWaiting(pool, c):
+			waiting++
+		}
+	}
+	return max(0, waiting-max(0, limit-running))
+}
+
+// waitingBefore returns true if the resource a entered its current state before b
+func waitingBefore(a, b *ofcirv1.CIResource) bool {
+	var ta, tb time.Time
+	if a.Status.StateChanged != nil {
+		ta = a.Status.StateChanged.Time
+	}
+	if b.Status.StateChanged != nil {
+		tb = b.Status.StateChanged.Time
+	}
+	if !ta.Equal(tb) {
+		return ta.Before(tb)
+	}
+	return a.Name < b.Name
+}
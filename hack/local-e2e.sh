@@ -267,7 +267,7 @@ setup_cluster() {
 	restore_manager_kustomization
 
 	log "Waiting for deployment to become ready..."
-	kubectl -n ofcir-system wait --for=condition=available deployment/ofcir-controller-manager --timeout=180s
+	kubectl -n ofcir-system wait --for=condition=available deployment/ofcir-controller-manager deployment/ofcir-api --timeout=180s
 
 	log "Setup complete."
 	log "  KUBECONFIG=${kubeconfig}"
@@ -17,9 +17,11 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -35,9 +37,15 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	ofcirv1beta2 "github.com/openshift/ofcir/api/v1beta2"
 	"github.com/openshift/ofcir/controllers"
+	"github.com/openshift/ofcir/pkg/admission"
+	"github.com/openshift/ofcir/pkg/notifier"
+	"github.com/openshift/ofcir/pkg/tracing"
+	"github.com/openshift/ofcir/pkg/utils"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -50,6 +58,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(ofcirv1.AddToScheme(scheme))
+	utilruntime.Must(ofcirv1beta2.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -58,24 +67,41 @@ func main() {
 	var enableLeaderElection bool
 	var probeAddr string
 	var webhookPort int
+	var enableWebhooks bool
+	var namespaces string
+	var leaseRetention time.Duration
+	var tracingOpts tracing.Options
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8443", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
-	flag.IntVar(&webhookPort, "webhook-port", 9443,
-		"Webhook Server port (set to 0 to disable)")
+	flag.IntVar(&webhookPort, "webhook-port", 9443, "The port the webhook server binds to.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Serve the admission and conversion webhooks. The server requires a serving certificate")
+	flag.StringVar(&namespaces, "namespaces", "ofcir-system",
+		"Comma separated namespaces watched for pools and resources, each one with its own inventory (empty for all namespaces)")
+	flag.DurationVar(&leaseRetention, "lease-retention", 90*24*time.Hour,
+		"How long the usage lease records are kept (set to 0 to keep them forever)")
 	opts := zap.Options{
 		Development: true,
 		Level:       zapcore.InfoLevel,
 		TimeEncoder: zapcore.ISO8601TimeEncoder,
 	}
 	opts.BindFlags(flag.CommandLine)
+	tracingOpts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	ctx := ctrl.SetupSignalHandler()
+	shutdownTracing, err := tracing.Setup(ctx, "ofcir-operator", tracingOpts)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	// CVE-2023-44487 - disable HTTP2 until fully fixed in k8s
 	disableHTTP2 := func(c *tls.Config) {
 		c.NextProtos = []string{"http/1.1"}
@@ -88,6 +114,16 @@ func main() {
 		FilterProvider: filters.WithAuthenticationAndAuthorization,
 	}
 
+	// The manager cache is cluster wide when no namespace is set
+	cacheOptions := cache.Options{}
+	if watched := utils.ParseNamespaces(namespaces); len(watched) > 0 {
+		cacheOptions.DefaultNamespaces = make(map[string]cache.Config)
+		for _, ns := range watched {
+			cacheOptions.DefaultNamespaces[ns] = cache.Config{}
+		}
+	}
+	setupLog.Info("watching namespaces", "namespaces", namespaces)
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                  scheme,
 		Metrics:                 metricsServerOptions,
@@ -95,29 +131,49 @@ func main() {
 		LeaderElection:          enableLeaderElection,
 		LeaderElectionID:        "7c25506c.openshift",
 		LeaderElectionNamespace: "ofcir-system",
-		Cache: cache.Options{
-			DefaultNamespaces: map[string]cache.Config{"ofcir-system": {}},
-		},
+		Cache:                   cacheOptions,
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Port:    webhookPort,
+			TLSOpts: []func(config *tls.Config){disableHTTP2},
+		}),
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	webhookNotifier := notifier.NewNotifier(mgr.GetClient(), ctrl.Log.WithName("notifier"))
+
 	if err = (&controllers.CIPoolReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Notifier: webhookNotifier,
+		Recorder: mgr.GetEventRecorder("cipool-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CIPool")
 		os.Exit(1)
 	}
+	if err = (&controllers.CILeasePruner{
+		Client:    mgr.GetClient(),
+		Retention: leaseRetention,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create lease pruner")
+		os.Exit(1)
+	}
 	if err = (&controllers.CIResourceReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Notifier: webhookNotifier,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CIResource")
 		os.Exit(1)
 	}
+	if enableWebhooks {
+		if err = admission.SetupWebhooksWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create admission webhooks")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -130,7 +186,12 @@ func main() {
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	err = mgr.Start(ctx)
+	webhookNotifier.Wait()
+	if shutdownErr := shutdownTracing(context.Background()); shutdownErr != nil {
+		setupLog.Error(shutdownErr, "problem flushing traces")
+	}
+	if err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
@@ -0,0 +1,186 @@
+package admission
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/openshift/ofcir/pkg/providers"
+	"github.com/openshift/ofcir/pkg/schedule"
+)
+
+//+kubebuilder:webhook:path=/mutate-ofcir-openshift-v1-cipool,mutating=true,failurePolicy=fail,sideEffects=None,groups=ofcir.openshift,resources=cipools,verbs=create;update,versions=v1,name=mcipool.ofcir.openshift,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-ofcir-openshift-v1-cipool,mutating=false,failurePolicy=fail,sideEffects=None,groups=ofcir.openshift,resources=cipools,verbs=create;update,versions=v1,name=vcipool.ofcir.openshift,admissionReviewVersions=v1
+
+// CIPoolWebhook sets the CIPool defaults, and rejects the invalid pools before
+// they reach the reconciler
+type CIPoolWebhook struct {
+	// Used for retrieving the pool secret
+	Client client.Reader
+}
+
+// Default sets the timeout and the type of the pool, when not specified
+func (w *CIPoolWebhook) Default(ctx context.Context, pool *ofcirv1.CIPool) error {
+	if pool.Spec.Timeout.Duration == 0 {
+		pool.Spec.Timeout = metav1.Duration{Duration: ofcirv1.DefaultPoolTimeout}
+	}
+	if pool.Spec.Type == "" {
+		pool.Spec.Type = ofcirv1.TypeCIHost
+	}
+	return nil
+}
+
+// ValidateCreate validates a new pool
+func (w *CIPoolWebhook) ValidateCreate(ctx context.Context, pool *ofcirv1.CIPool) (admission.Warnings, error) {
+	return w.validate(ctx, pool)
+}
+
+// ValidateUpdate validates a pool change. Metadata-only updates (for example the
+// finalizer removal) are always allowed, so that pools created before the webhook
+// was enabled can still be managed
+func (w *CIPoolWebhook) ValidateUpdate(ctx context.Context, oldPool, pool *ofcirv1.CIPool) (admission.Warnings, error) {
+	if !pool.DeletionTimestamp.IsZero() || equality.Semantic.DeepEqual(oldPool.Spec, pool.Spec) {
+		return nil, nil
+	}
+	return w.validate(ctx, pool)
+}
+
+// ValidateDelete always allows a pool removal
+func (w *CIPoolWebhook) ValidateDelete(ctx context.Context, pool *ofcirv1.CIPool) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (w *CIPoolWebhook) validate(ctx context.Context, pool *ofcirv1.CIPool) (admission.Warnings, error) {
+	errs := validateCIPoolSpec(&pool.Spec, field.NewPath("spec"))
+
+	warnings, err := w.validateProviderConfig(ctx, pool)
+	if err != nil {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "secretRef"), pool.GetSecretName(), err.Error()))
+	}
+
+	if len(errs) > 0 {
+		return warnings, apierrors.NewInvalid(ofcirv1.GroupVersion.WithKind("CIPool").GroupKind(), pool.Name, errs)
+	}
+	return warnings, nil
+}
+
+// validateProviderConfig runs the provider validation hook against the `config`
+// field of the pool secret, merged with the pool `spec.providerConfig` settings.
+// Since the secret may be created after the pool, a missing one is only reported
+// as a warning
+func (w *CIPoolWebhook) validateProviderConfig(ctx context.Context, pool *ofcirv1.CIPool) (admission.Warnings, error) {
+	if w.Client == nil || !providers.IsKnownProvider(pool.Spec.Provider) {
+		return nil, nil
+	}
+
+	secret := &v1.Secret{}
+	key := types.NamespacedName{Namespace: pool.Namespace, Name: pool.GetSecretName()}
+	if err := w.Client.Get(ctx, key, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			warnings := admission.Warnings{fmt.Sprintf("secret %s not found, the provider is not configured", key.Name)}
+			return warnings, providers.ValidatePoolConfig(pool, nil)
+		}
+		// The secret content cannot be checked, let the reconciler report it
+		log.FromContext(ctx).Error(err, "could not get CIPool secret", "Secret", key.Name)
+		return nil, nil
+	}
+
+	return nil, providers.ValidatePoolConfig(pool, secret.Data)
+}
+
+func validateCIPoolSpec(spec *ofcirv1.CIPoolSpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if !providers.IsKnownProvider(spec.Provider) {
+		var known []string
+		for _, p := range providers.KnownProviders() {
+			known = append(known, string(p))
+		}
+		errs = append(errs, field.NotSupported(path.Child("provider"), spec.Provider, known))
+	}
+	if spec.Priority < -1 {
+		errs = append(errs, field.Invalid(path.Child("priority"), spec.Priority, "must be greater than or equal to -1"))
+	}
+	if spec.Size < 0 {
+		errs = append(errs, field.Invalid(path.Child("size"), spec.Size, "must be greater than or equal to 0"))
+	}
+	if spec.Timeout.Duration <= 0 {
+		errs = append(errs, field.Invalid(path.Child("timeout"), spec.Timeout.Duration.String(), "must be greater than 0"))
+	}
+	switch spec.State {
+	case ofcirv1.StatePoolAvailable, ofcirv1.StatePoolOffline, ofcirv1.StatePoolDraining:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("state"), spec.State,
+			[]ofcirv1.CIPoolState{ofcirv1.StatePoolAvailable, ofcirv1.StatePoolOffline, ofcirv1.StatePoolDraining}))
+	}
+	switch spec.Type {
+	case ofcirv1.TypeCIHost, ofcirv1.TypeCICluster:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("type"), spec.Type,
+			[]ofcirv1.CIResourceType{ofcirv1.TypeCIHost, ofcirv1.TypeCICluster}))
+	}
+
+	errs = append(errs, validateNonNegativeDuration(spec.ProvisioningTimeout, path.Child("provisioningTimeout"))...)
+	errs = append(errs, validateNonNegativeDuration(spec.CleaningTimeout, path.Child("cleaningTimeout"))...)
+
+	for i, h := range spec.Hooks {
+		if (h.Builtin == "") == (h.HTTP == nil) {
+			errs = append(errs, field.Invalid(path.Child("hooks").Index(i), h.Name, "exactly one of builtin and http must be set"))
+		}
+	}
+
+	if a := spec.Autoscaling; a != nil && a.MinSize > a.MaxSize {
+		errs = append(errs, field.Invalid(path.Child("autoscaling", "minSize"), a.MinSize, "must be less than or equal to maxSize"))
+	}
+
+	if c := spec.Cost; c != nil {
+		costPath := path.Child("cost")
+		if _, err := ofcirv1.ParseAmount(c.HourlyPrice); err != nil {
+			errs = append(errs, field.Invalid(costPath.Child("hourlyPrice"), c.HourlyPrice, "must be a non-negative decimal number"))
+		}
+		if _, err := ofcirv1.ParseAmount(c.MonthlyBudget); err != nil {
+			errs = append(errs, field.Invalid(costPath.Child("monthlyBudget"), c.MonthlyBudget, "must be a non-negative decimal number"))
+		}
+	}
+
+	names := map[string]bool{}
+	for i, s := range spec.Schedules {
+		schedulePath := path.Child("schedules").Index(i)
+		if names[s.Name] {
+			errs = append(errs, field.Duplicate(schedulePath.Child("name"), s.Name))
+		}
+		names[s.Name] = true
+
+		if _, err := schedule.Parse(s.Start); err != nil {
+			errs = append(errs, field.Invalid(schedulePath.Child("start"), s.Start, err.Error()))
+		}
+		if s.Timezone != "" {
+			if _, err := time.LoadLocation(s.Timezone); err != nil {
+				errs = append(errs, field.Invalid(schedulePath.Child("timezone"), s.Timezone, err.Error()))
+			}
+		}
+		if s.Duration.Duration <= 0 {
+			errs = append(errs, field.Invalid(schedulePath.Child("duration"), s.Duration.Duration.String(), "must be greater than 0"))
+		}
+	}
+
+	return errs
+}
+
+func validateNonNegativeDuration(d *metav1.Duration, path *field.Path) field.ErrorList {
+	if d != nil && d.Duration < 0 {
+		return field.ErrorList{field.Invalid(path, d.Duration.String(), "must be greater than or equal to 0")}
+	}
+	return nil
+}
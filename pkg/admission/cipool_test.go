@@ -0,0 +1,179 @@
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+func validPool() *ofcirv1.CIPool {
+	return &ofcirv1.CIPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "cipool-ironic", Namespace: "ofcir-system"},
+		Spec: ofcirv1.CIPoolSpec{
+			Provider: "ironic",
+			Size:     2,
+			Timeout:  metav1.Duration{Duration: time.Hour},
+			State:    ofcirv1.StatePoolAvailable,
+			Type:     ofcirv1.TypeCIHost,
+		},
+	}
+}
+
+func poolSecret(config string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cipool-ironic-secret", Namespace: "ofcir-system"},
+		Data:       map[string][]byte{ofcirv1.ProviderConfigSecretKey: []byte(config)},
+	}
+}
+
+func TestCIPoolDefault(t *testing.T) {
+	pool := validPool()
+	pool.Spec.Timeout = metav1.Duration{}
+	pool.Spec.Type = ""
+
+	assert.NoError(t, (&CIPoolWebhook{}).Default(context.Background(), pool))
+	assert.Equal(t, 4*time.Hour, pool.Spec.Timeout.Duration)
+	assert.Equal(t, ofcirv1.TypeCIHost, pool.Spec.Type)
+
+	pool.Spec.Timeout = metav1.Duration{Duration: time.Hour}
+	pool.Spec.Type = ofcirv1.TypeCICluster
+	assert.NoError(t, (&CIPoolWebhook{}).Default(context.Background(), pool))
+	assert.Equal(t, time.Hour, pool.Spec.Timeout.Duration)
+	assert.Equal(t, ofcirv1.TypeCICluster, pool.Spec.Type)
+}
+
+func TestCIPoolValidate(t *testing.T) {
+	cases := []struct {
+		name             string
+		mutate           func(*ofcirv1.CIPool)
+		secret           *corev1.Secret
+		expectedErr      string
+		expectedWarnings []string
+	}{
+		{
+			name:   "valid pool",
+			secret: poolSecret(`{"endpoint": "https://172.22.0.3:6385"}`),
+		},
+		{
+			name:             "missing secret",
+			expectedWarnings: []string{"secret cipool-ironic-secret not found, the provider is not configured"},
+		},
+		{
+			name: "negative size and priority",
+			mutate: func(p *ofcirv1.CIPool) {
+				p.Spec.Size = -1
+				p.Spec.Priority = -2
+			},
+			secret:      poolSecret(`{}`),
+			expectedErr: `CIPool.ofcir.openshift "cipool-ironic" is invalid: [spec.priority: Invalid value: -2: must be greater than or equal to -1, spec.size: Invalid value: -1: must be greater than or equal to 0]`,
+		},
+		{
+			name: "unknown provider",
+			mutate: func(p *ofcirv1.CIPool) {
+				p.Spec.Provider = "openstack"
+			},
+			expectedErr: `CIPool.ofcir.openshift "cipool-ironic" is invalid: spec.provider: Unsupported value: "openstack": supported values: "fake-provider", "libvirt", "ironic", "equinix", "ibmcloud", "aws"`,
+		},
+		{
+			name:        "malformed provider config",
+			secret:      poolSecret(`{"endpoint": `),
+			expectedErr: `CIPool.ofcir.openshift "cipool-ironic" is invalid: spec.secretRef: Invalid value: "cipool-ironic-secret": error in provider config json: unexpected end of JSON input`,
+		},
+		{
+			name: "referenced secret",
+			mutate: func(p *ofcirv1.CIPool) {
+				p.Spec.SecretRef = &corev1.LocalObjectReference{Name: "ironic-credentials"}
+			},
+			secret:           poolSecret(`{"endpoint": `),
+			expectedWarnings: []string{"secret ironic-credentials not found, the provider is not configured"},
+		},
+		{
+			name: "invalid state and hooks",
+			mutate: func(p *ofcirv1.CIPool) {
+				p.Spec.State = "paused"
+				p.Spec.Hooks = []ofcirv1.Hook{{Name: "notify", Points: []ofcirv1.HookPoint{ofcirv1.HookPostProvision}}}
+			},
+			secret:      poolSecret(`{}`),
+			expectedErr: `CIPool.ofcir.openshift "cipool-ironic" is invalid: [spec.state: Unsupported value: "paused": supported values: "available", "offline", "draining", spec.hooks[0]: Invalid value: "notify": exactly one of builtin and http must be set]`,
+		},
+		{
+			name: "invalid autoscaling and schedules",
+			mutate: func(p *ofcirv1.CIPool) {
+				p.Spec.Autoscaling = &ofcirv1.Autoscaling{MinSize: 5, MaxSize: 2}
+				p.Spec.Schedules = []ofcirv1.SizeSchedule{
+					{Name: "weekdays", Start: "0 6 * * 1-5", Duration: metav1.Duration{Duration: time.Hour}, Size: 4},
+					{Name: "weekdays", Start: "0 25 * * *", Timezone: "Mars/Olympus", Size: 4},
+				}
+			},
+			secret: poolSecret(`{}`),
+			expectedErr: `CIPool.ofcir.openshift "cipool-ironic" is invalid: [spec.autoscaling.minSize: Invalid value: 5: must be less than or equal to maxSize, ` +
+				`spec.schedules[1].name: Duplicate value: "weekdays", ` +
+				`spec.schedules[1].start: Invalid value: "0 25 * * *": invalid cron expression "0 25 * * *": hour "25" out of the [0, 23] range, ` +
+				`spec.schedules[1].timezone: Invalid value: "Mars/Olympus": unknown time zone Mars/Olympus, ` +
+				`spec.schedules[1].duration: Invalid value: "0s": must be greater than 0]`,
+		},
+		{
+			name: "invalid cost",
+			mutate: func(p *ofcirv1.CIPool) {
+				p.Spec.Cost = &ofcirv1.Cost{HourlyPrice: "-0.5", MonthlyBudget: "NaN"}
+			},
+			secret: poolSecret(`{}`),
+			expectedErr: `CIPool.ofcir.openshift "cipool-ironic" is invalid: [spec.cost.hourlyPrice: Invalid value: "-0.5": must be a non-negative decimal number, ` +
+				`spec.cost.monthlyBudget: Invalid value: "NaN": must be a non-negative decimal number]`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pool := validPool()
+			if tc.mutate != nil {
+				tc.mutate(pool)
+			}
+			w := &CIPoolWebhook{Client: fakeClient(t, tc.secret)}
+
+			warnings, err := w.ValidateCreate(context.Background(), pool)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+			assert.Equal(t, tc.expectedWarnings, []string(warnings))
+		})
+	}
+}
+
+func TestCIPoolValidateUpdate(t *testing.T) {
+	w := &CIPoolWebhook{Client: fakeClient(t, poolSecret(`{}`))}
+
+	// A pool created before the webhook was enabled can still be managed
+	legacy := validPool()
+	legacy.Spec.Size = -1
+	updated := legacy.DeepCopy()
+	updated.Finalizers = []string{ofcirv1.OfcirFinalizer}
+	_, err := w.ValidateUpdate(context.Background(), legacy, updated)
+	assert.NoError(t, err)
+
+	updated.Spec.State = ofcirv1.StatePoolOffline
+	_, err = w.ValidateUpdate(context.Background(), legacy, updated)
+	assert.EqualError(t, err, `CIPool.ofcir.openshift "cipool-ironic" is invalid: spec.size: Invalid value: -1: must be greater than or equal to 0`)
+}
+
+func fakeClient(t *testing.T, secret *corev1.Secret) client.Client {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, corev1.AddToScheme(scheme))
+
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	if secret != nil {
+		builder = builder.WithObjects(secret)
+	}
+	return builder.Build()
+}
@@ -0,0 +1,88 @@
+package admission
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+//+kubebuilder:webhook:path=/validate-ofcir-openshift-v1-ciresource,mutating=false,failurePolicy=fail,sideEffects=None,groups=ofcir.openshift,resources=ciresources,verbs=create;update,versions=v1,name=vciresource.ofcir.openshift,admissionReviewVersions=v1
+
+// CIResourceWebhook rejects the CIResource changes the state machine cannot handle
+type CIResourceWebhook struct{}
+
+// requestableStates are the states a resource can be asked to move to. The
+// remaining ones are only reached through the state machine
+var requestableStates = []ofcirv1.CIResourceState{
+	ofcirv1.StateAvailable,
+	ofcirv1.StateInUse,
+	ofcirv1.StateMaintenance,
+	ofcirv1.StateDelete,
+	ofcirv1.StateError,
+}
+
+// ValidateCreate validates a new resource
+func (w *CIResourceWebhook) ValidateCreate(ctx context.Context, cir *ofcirv1.CIResource) (admission.Warnings, error) {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if cir.Spec.PoolRef.Name == "" {
+		errs = append(errs, field.Required(specPath.Child("poolRef", "name"), "the pool of the resource must be set"))
+	}
+	if cir.Spec.State != ofcirv1.StateNone && !isRequestable(cir.Spec.State) {
+		errs = append(errs, field.NotSupported(specPath.Child("state"), cir.Spec.State, requestableStates))
+	}
+
+	return nil, toInvalid(cir, errs)
+}
+
+// ValidateUpdate validates a resource change. The pool of a resource cannot be
+// changed, and only the requestable states can be set
+func (w *CIResourceWebhook) ValidateUpdate(ctx context.Context, oldCir, cir *ofcirv1.CIResource) (admission.Warnings, error) {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if cir.Spec.PoolRef.Name != oldCir.Spec.PoolRef.Name {
+		errs = append(errs, field.Forbidden(specPath.Child("poolRef", "name"), "the pool of a resource cannot be changed"))
+	}
+
+	if cir.Spec.State != oldCir.Spec.State {
+		statePath := specPath.Child("state")
+		switch {
+		case cir.Spec.State == ofcirv1.StateProvisioning:
+			// Provisioning can only be requested for throwing away a failed resource
+			if cir.Status.State != ofcirv1.StateError {
+				errs = append(errs, field.Forbidden(statePath, "provisioning can be requested only for a resource in the error state"))
+			}
+		case !isRequestable(cir.Spec.State):
+			errs = append(errs, field.NotSupported(statePath, cir.Spec.State, requestableStates))
+		}
+	}
+
+	return nil, toInvalid(cir, errs)
+}
+
+// ValidateDelete always allows a resource removal
+func (w *CIResourceWebhook) ValidateDelete(ctx context.Context, cir *ofcirv1.CIResource) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func isRequestable(state ofcirv1.CIResourceState) bool {
+	for _, s := range requestableStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+func toInvalid(cir *ofcirv1.CIResource, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(ofcirv1.GroupVersion.WithKind("CIResource").GroupKind(), cir.Name, errs)
+}
@@ -0,0 +1,112 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+func resource(requiredState, currentState ofcirv1.CIResourceState) *ofcirv1.CIResource {
+	return &ofcirv1.CIResource{
+		ObjectMeta: metav1.ObjectMeta{Name: "cir-0001", Namespace: "ofcir-system"},
+		Spec: ofcirv1.CIResourceSpec{
+			PoolRef: corev1.LocalObjectReference{Name: "cipool-ironic"},
+			State:   requiredState,
+		},
+		Status: ofcirv1.CIResourceStatus{
+			State: currentState,
+		},
+	}
+}
+
+func TestCIResourceValidateCreate(t *testing.T) {
+	w := &CIResourceWebhook{}
+
+	_, err := w.ValidateCreate(context.Background(), resource(ofcirv1.StateNone, ofcirv1.StateNone))
+	assert.NoError(t, err)
+
+	_, err = w.ValidateCreate(context.Background(), resource(ofcirv1.StateCleaning, ofcirv1.StateNone))
+	assert.EqualError(t, err, `CIResource.ofcir.openshift "cir-0001" is invalid: spec.state: Unsupported value: "cleaning": supported values: "available", "in use", "maintenance", "delete", "error"`)
+
+	noPool := resource(ofcirv1.StateNone, ofcirv1.StateNone)
+	noPool.Spec.PoolRef.Name = ""
+	_, err = w.ValidateCreate(context.Background(), noPool)
+	assert.EqualError(t, err, `CIResource.ofcir.openshift "cir-0001" is invalid: spec.poolRef.name: Required value: the pool of the resource must be set`)
+}
+
+func TestCIResourceValidateUpdate(t *testing.T) {
+	cases := []struct {
+		name          string
+		currentState  ofcirv1.CIResourceState
+		previousState ofcirv1.CIResourceState
+		requiredState ofcirv1.CIResourceState
+		expectedErr   string
+	}{
+		{
+			name:          "acquire",
+			currentState:  ofcirv1.StateAvailable,
+			previousState: ofcirv1.StateAvailable,
+			requiredState: ofcirv1.StateInUse,
+		},
+		{
+			name:          "maintenance",
+			currentState:  ofcirv1.StateAvailable,
+			previousState: ofcirv1.StateAvailable,
+			requiredState: ofcirv1.StateMaintenance,
+		},
+		{
+			name:          "reprovision a failed resource",
+			currentState:  ofcirv1.StateError,
+			previousState: ofcirv1.StateError,
+			requiredState: ofcirv1.StateProvisioning,
+		},
+		{
+			name:          "provisioning by hand",
+			currentState:  ofcirv1.StateAvailable,
+			previousState: ofcirv1.StateAvailable,
+			requiredState: ofcirv1.StateProvisioning,
+			expectedErr:   `CIResource.ofcir.openshift "cir-0001" is invalid: spec.state: Forbidden: provisioning can be requested only for a resource in the error state`,
+		},
+		{
+			name:          "internal state",
+			currentState:  ofcirv1.StateInUse,
+			previousState: ofcirv1.StateInUse,
+			requiredState: ofcirv1.StateCleaningWait,
+			expectedErr:   `CIResource.ofcir.openshift "cir-0001" is invalid: spec.state: Unsupported value: "cleaning wait": supported values: "available", "in use", "maintenance", "delete", "error"`,
+		},
+		{
+			name:          "unchanged state",
+			currentState:  ofcirv1.StateProvisioningWait,
+			previousState: ofcirv1.StateNone,
+			requiredState: ofcirv1.StateNone,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			oldCir := resource(tc.previousState, tc.currentState)
+			cir := resource(tc.requiredState, tc.currentState)
+
+			_, err := (&CIResourceWebhook{}).ValidateUpdate(context.Background(), oldCir, cir)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestCIResourceValidateUpdatePool(t *testing.T) {
+	oldCir := resource(ofcirv1.StateAvailable, ofcirv1.StateAvailable)
+	cir := oldCir.DeepCopy()
+	cir.Spec.PoolRef.Name = "cipool-aws"
+
+	_, err := (&CIResourceWebhook{}).ValidateUpdate(context.Background(), oldCir, cir)
+	assert.EqualError(t, err, `CIResource.ofcir.openshift "cir-0001" is invalid: spec.poolRef.name: Forbidden: the pool of a resource cannot be changed`)
+}
@@ -0,0 +1,25 @@
+// Package admission implements the admission webhooks of the operator, setting
+// the defaults of the custom resources and rejecting the invalid ones before
+// they reach the reconcilers
+package admission
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+// SetupWebhooksWithManager registers the admission webhooks with the manager
+func SetupWebhooksWithManager(mgr ctrl.Manager) error {
+	poolWebhook := &CIPoolWebhook{Client: mgr.GetClient()}
+	if err := ctrl.NewWebhookManagedBy(mgr, &ofcirv1.CIPool{}).
+		WithDefaulter(poolWebhook).
+		WithValidator(poolWebhook).
+		Complete(); err != nil {
+		return err
+	}
+
+	return ctrl.NewWebhookManagedBy(mgr, &ofcirv1.CIResource{}).
+		WithValidator(&CIResourceWebhook{}).
+		Complete()
+}
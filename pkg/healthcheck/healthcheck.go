@@ -0,0 +1,106 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/openshift/ofcir/pkg/utils"
+)
+
+const (
+	defaultPath    = "/"
+	defaultUser    = "root"
+	defaultCommand = "true"
+
+	// Written by cloud-init once the boot completed
+	cloudInitCommand = "test -f /var/lib/cloud/instance/boot-finished"
+)
+
+// Probe runs the check against the resource address, returning an error if
+// the resource is not healthy. The private key is used only by the ssh and
+// cloud-init probes
+func Probe(check ofcirv1.Probe, address string, privateKey []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), check.GetTimeout())
+	defer cancel()
+
+	switch check.Type {
+	case ofcirv1.ProbeTCP:
+		return probeTCP(ctx, address, check.GetPort())
+	case ofcirv1.ProbeHTTP:
+		return probeHTTP(ctx, address, check.GetPort(), check.Path)
+	case ofcirv1.ProbeSSH:
+		return probeSSH(ctx, address, check, privateKey)
+	case ofcirv1.ProbeCloudInit:
+		check.Command = cloudInitCommand
+		if err := probeSSH(ctx, address, check, privateKey); err != nil {
+			return fmt.Errorf("cloud-init not finished: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown probe type: %s", check.Type)
+}
+
+func probeTCP(ctx context.Context, address string, port int) error {
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func probeHTTP(ctx context.Context, address string, port int, path string) error {
+	if path == "" {
+		path = defaultPath
+	}
+	u := url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(address, strconv.Itoa(port)),
+		Path:   path,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return nil
+}
+
+func probeSSH(ctx context.Context, address string, check ofcirv1.Probe, privateKey []byte) error {
+	if len(privateKey) == 0 {
+		return fmt.Errorf("pool secret does not contain the `%s` field", ofcirv1.SSHPrivateKeySecretKey)
+	}
+
+	user := check.User
+	if user == "" {
+		user = defaultUser
+	}
+	command := check.Command
+	if command == "" {
+		command = defaultCommand
+	}
+
+	out, err := utils.RunSSHCommand(ctx, address, check.GetPort(), user, privateKey, command)
+	if err != nil {
+		if out = strings.TrimSpace(out); out != "" {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
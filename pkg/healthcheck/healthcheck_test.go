@@ -0,0 +1,103 @@
+package healthcheck
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/openshift/ofcir/pkg/utils/sshtest"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func hostPort(t *testing.T, addr string) (string, int) {
+	host, p, err := net.SplitHostPort(addr)
+	assert.NoError(t, err)
+	port, err := strconv.Atoi(p)
+	assert.NoError(t, err)
+	return host, port
+}
+
+func TestProbeTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	host, port := hostPort(t, l.Addr().String())
+
+	check := ofcirv1.Probe{Type: ofcirv1.ProbeTCP, Port: port}
+	assert.NoError(t, Probe(check, host, nil))
+
+	l.Close()
+	assert.Error(t, Probe(check, host, nil))
+}
+
+func TestProbeHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	host, port := hostPort(t, srv.Listener.Addr().String())
+
+	assert.NoError(t, Probe(ofcirv1.Probe{Type: ofcirv1.ProbeHTTP, Port: port, Path: "/healthz"}, host, nil))
+	assert.ErrorContains(t, Probe(ofcirv1.Probe{Type: ofcirv1.ProbeHTTP, Port: port}, host, nil), "503")
+}
+
+func TestProbeSSH(t *testing.T) {
+	srv, err := sshtest.NewServer(func(command string) (string, int) {
+		if command == "systemctl is-system-running" {
+			return "degraded\n", 1
+		}
+		return "", 0
+	})
+	assert.NoError(t, err)
+	defer srv.Close()
+
+	check := ofcirv1.Probe{
+		Type:    ofcirv1.ProbeSSH,
+		Port:    srv.Port,
+		Timeout: &metav1.Duration{Duration: 5 * time.Second},
+	}
+	assert.NoError(t, Probe(check, srv.Host, srv.PrivateKey))
+	assert.ErrorContains(t, Probe(check, srv.Host, nil), ofcirv1.SSHPrivateKeySecretKey)
+
+	check.Command = "systemctl is-system-running"
+	assert.ErrorContains(t, Probe(check, srv.Host, srv.PrivateKey), "degraded")
+	assert.Equal(t, []string{"true", "systemctl is-system-running"}, srv.Commands())
+}
+
+func TestProbeCloudInit(t *testing.T) {
+	var finished atomic.Bool
+	srv, err := sshtest.NewServer(func(command string) (string, int) {
+		if finished.Load() {
+			return "", 0
+		}
+		return "", 1
+	})
+	assert.NoError(t, err)
+	defer srv.Close()
+
+	check := ofcirv1.Probe{
+		Type:    ofcirv1.ProbeCloudInit,
+		Port:    srv.Port,
+		Command: "ignored",
+		Timeout: &metav1.Duration{Duration: 5 * time.Second},
+	}
+	assert.ErrorContains(t, Probe(check, srv.Host, srv.PrivateKey), "cloud-init not finished")
+
+	finished.Store(true)
+	assert.NoError(t, Probe(check, srv.Host, srv.PrivateKey))
+	assert.Equal(t, []string{cloudInitCommand, cloudInitCommand}, srv.Commands())
+}
+
+func TestProbeTimeout(t *testing.T) {
+	assert.Equal(t, 10*time.Second, ofcirv1.Probe{}.GetTimeout())
+	assert.Equal(t, 5*time.Second, ofcirv1.Probe{Timeout: &metav1.Duration{Duration: 5 * time.Second}}.GetTimeout())
+	// The probes must not hold the reconcile for long
+	assert.Equal(t, 30*time.Second, ofcirv1.Probe{Timeout: &metav1.Duration{Duration: 10 * time.Minute}}.GetTimeout())
+}
@@ -0,0 +1,167 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+const (
+	// PointBeforeAnyState is run at every reconcile of a resource, before
+	// handling its state. It is internal to the operator: the pools cannot
+	// configure hooks for it, so only the operator hooks are run there
+	PointBeforeAnyState ofcirv1.HookPoint = "before-any-state"
+
+	// Header set on every HTTP hook request
+	PointHeader = "X-Ofcir-Hook-Point"
+
+	// Max length of the response body reported in the errors
+	maxErrorBodyLength = 256
+)
+
+// Event is passed to the hooks, and POSTed to the HTTP hooks
+type Event struct {
+	Point      ofcirv1.HookPoint `json:"point"`
+	Namespace  string            `json:"namespace"`
+	Pool       string            `json:"pool"`
+	CIResource string            `json:"ciResource"`
+	ResourceId string            `json:"resourceId"`
+	Address    string            `json:"address,omitempty"`
+}
+
+// Func is an in-process hook. A returned error blocks the lifecycle
+// transition the hook was run for
+type Func func(ctx context.Context, event Event) error
+
+// Registry runs the hooks configured in a pool, resolving the builtin
+// hooks among the registered ones
+type Registry struct {
+	mu    sync.RWMutex
+	funcs map[string]Func
+	// The hooks run for every pool, by point
+	operatorHooks map[ofcirv1.HookPoint][]operatorHook
+	httpClient    *http.Client
+}
+
+type operatorHook struct {
+	name string
+	fn   Func
+}
+
+// DefaultRegistry is used by the controllers
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		funcs:         make(map[string]Func),
+		operatorHooks: make(map[ofcirv1.HookPoint][]operatorHook),
+		httpClient:    &http.Client{},
+	}
+}
+
+// Register makes the in-process hook available to the pools as a builtin
+// hook with the given name
+func (r *Registry) Register(name string, fn Func) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.funcs[name] = fn
+}
+
+// Register adds the in-process hook to the DefaultRegistry
+func Register(name string, fn Func) {
+	DefaultRegistry.Register(name, fn)
+}
+
+// Use adds an operator hook, run at the given point for every pool before the
+// hooks configured in the pool. A failing operator hook blocks the transition
+// like the pool ones
+func (r *Registry) Use(point ofcirv1.HookPoint, name string, fn Func) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.operatorHooks[point] = append(r.operatorHooks[point], operatorHook{name: name, fn: fn})
+}
+
+// Use adds the operator hook to the DefaultRegistry
+func Use(point ofcirv1.HookPoint, name string, fn Func) {
+	DefaultRegistry.Use(point, name, fn)
+}
+
+// Run executes in order the operator hooks and then the hooks configured for
+// the event point, stopping at the first failure
+func (r *Registry) Run(ctx context.Context, hooks []ofcirv1.Hook, event Event) error {
+	r.mu.RLock()
+	operatorHooks := r.operatorHooks[event.Point]
+	r.mu.RUnlock()
+	for _, h := range operatorHooks {
+		if err := h.fn(ctx, event); err != nil {
+			return fmt.Errorf("%s hook %s failed: %w", event.Point, h.name, err)
+		}
+	}
+
+	for _, h := range hooks {
+		if !h.RunsAt(event.Point) {
+			continue
+		}
+		if err := r.run(ctx, h, event); err != nil {
+			return fmt.Errorf("%s hook %s failed: %w", event.Point, h.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Registry) run(ctx context.Context, h ofcirv1.Hook, event Event) error {
+	switch {
+	case h.Builtin != "" && h.HTTP != nil:
+		return fmt.Errorf("only one of builtin and http can be set")
+	case h.Builtin != "":
+		r.mu.RLock()
+		fn, ok := r.funcs[h.Builtin]
+		r.mu.RUnlock()
+		if !ok {
+			return fmt.Errorf("builtin hook %s not registered", h.Builtin)
+		}
+		return fn(ctx, event)
+	case h.HTTP != nil:
+		return r.callout(ctx, *h.HTTP, event)
+	}
+	return fmt.Errorf("one of builtin and http must be set")
+}
+
+func (r *Registry) callout(ctx context.Context, h ofcirv1.HTTPHook, event Event) error {
+	ctx, cancel := context.WithTimeout(ctx, h.GetTimeout())
+	defer cancel()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ofcir")
+	req.Header.Set(PointHeader, string(event.Point))
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		if text := strings.TrimSpace(string(msg)); text != "" {
+			return fmt.Errorf("unexpected response status: %s: %s", resp.Status, text)
+		}
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return nil
+}
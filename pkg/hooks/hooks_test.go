@@ -0,0 +1,136 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var event = Event{
+	Point:      ofcirv1.HookPreAcquire,
+	Namespace:  "ofcir-system",
+	Pool:       "cipool-fake",
+	CIResource: "cir-0001",
+	ResourceId: "dummy-1",
+	Address:    "1.1.1.1",
+}
+
+func TestRunBuiltin(t *testing.T) {
+	r := NewRegistry()
+
+	var received []string
+	r.Register("monitoring", func(ctx context.Context, e Event) error {
+		received = append(received, "monitoring:"+string(e.Point))
+		return nil
+	})
+	r.Register("broken", func(ctx context.Context, e Event) error {
+		return errors.New("disk not found")
+	})
+
+	hooks := []ofcirv1.Hook{
+		{Name: "register", Points: []ofcirv1.HookPoint{ofcirv1.HookPostProvision, ofcirv1.HookPreAcquire}, Builtin: "monitoring"},
+		{Name: "wipe", Points: []ofcirv1.HookPoint{ofcirv1.HookPostRelease}, Builtin: "broken"},
+	}
+
+	assert.NoError(t, r.Run(context.TODO(), hooks, event))
+	assert.Equal(t, []string{"monitoring:pre-acquire"}, received)
+
+	release := event
+	release.Point = ofcirv1.HookPostRelease
+	assert.EqualError(t, r.Run(context.TODO(), hooks, release), "post-release hook wipe failed: disk not found")
+
+	hooks[0].Builtin = "unknown"
+	assert.ErrorContains(t, r.Run(context.TODO(), hooks, event), "builtin hook unknown not registered")
+}
+
+func TestRunOperatorHooks(t *testing.T) {
+	r := NewRegistry()
+	var received []string
+	r.Register("monitoring", func(ctx context.Context, e Event) error {
+		received = append(received, "monitoring")
+		return nil
+	})
+	r.Use(ofcirv1.HookPreAcquire, "quota", func(ctx context.Context, e Event) error {
+		received = append(received, "quota")
+		return nil
+	})
+	hooks := []ofcirv1.Hook{
+		{Name: "register", Points: []ofcirv1.HookPoint{ofcirv1.HookPreAcquire}, Builtin: "monitoring"},
+	}
+
+	// The operator hooks are run first, even if the pool does not configure any hook
+	assert.NoError(t, r.Run(context.TODO(), hooks, event))
+	assert.NoError(t, r.Run(context.TODO(), nil, event))
+	assert.Equal(t, []string{"quota", "monitoring", "quota"}, received)
+
+	r.Use(ofcirv1.HookPreAcquire, "paused", func(ctx context.Context, e Event) error {
+		return errors.New("pool paused")
+	})
+	received = nil
+	assert.EqualError(t, r.Run(context.TODO(), hooks, event), "pre-acquire hook paused failed: pool paused")
+	assert.Equal(t, []string{"quota"}, received)
+
+	// Not run at the other points
+	release := event
+	release.Point = ofcirv1.HookPostRelease
+	assert.NoError(t, r.Run(context.TODO(), hooks, release))
+}
+
+func TestRunHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received := Event{}
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&received))
+		assert.Equal(t, event, received)
+		assert.Equal(t, string(ofcirv1.HookPreAcquire), req.Header.Get(PointHeader))
+
+		switch req.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte("host not registered\n"))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	hook := func(path string) []ofcirv1.Hook {
+		return []ofcirv1.Hook{{
+			Name:   "callout",
+			Points: []ofcirv1.HookPoint{ofcirv1.HookPreAcquire},
+			HTTP:   &ofcirv1.HTTPHook{URL: srv.URL + path, Timeout: &metav1.Duration{Duration: 50 * time.Millisecond}},
+		}}
+	}
+
+	r := NewRegistry()
+	assert.NoError(t, r.Run(context.TODO(), hook("/ok"), event))
+	assert.EqualError(t, r.Run(context.TODO(), hook("/fail"), event), "pre-acquire hook callout failed: unexpected response status: 409 Conflict: host not registered")
+	assert.ErrorIs(t, r.Run(context.TODO(), hook("/slow"), event), context.DeadlineExceeded)
+}
+
+func TestHTTPHookTimeout(t *testing.T) {
+	assert.Equal(t, 10*time.Second, ofcirv1.HTTPHook{}.GetTimeout())
+	assert.Equal(t, 5*time.Second, ofcirv1.HTTPHook{Timeout: &metav1.Duration{Duration: 5 * time.Second}}.GetTimeout())
+	// The hooks must not hold the reconcile for long
+	assert.Equal(t, 30*time.Second, ofcirv1.HTTPHook{Timeout: &metav1.Duration{Duration: 10 * time.Minute}}.GetTimeout())
+}
+
+func TestRunInvalidHook(t *testing.T) {
+	r := NewRegistry()
+	r.Register("noop", func(ctx context.Context, e Event) error { return nil })
+
+	assert.ErrorContains(t, r.Run(context.TODO(), []ofcirv1.Hook{{Name: "empty", Points: []ofcirv1.HookPoint{ofcirv1.HookPreAcquire}}}, event), "must be set")
+	assert.ErrorContains(t, r.Run(context.TODO(), []ofcirv1.Hook{{
+		Name:    "both",
+		Points:  []ofcirv1.HookPoint{ofcirv1.HookPreAcquire},
+		Builtin: "noop",
+		HTTP:    &ofcirv1.HTTPHook{URL: "http://localhost"},
+	}}, event), "only one")
+}
@@ -0,0 +1,189 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Headers set on every notification request
+	EventHeader     = "X-Ofcir-Event"
+	SignatureHeader = "X-Ofcir-Signature"
+
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = 2 * time.Second
+	maxBackoff            = 1 * time.Minute
+	deliveryTimeout       = 10 * time.Second
+)
+
+// Event is the payload POSTed to the webhooks
+type Event struct {
+	Type       ofcirv1.CIWebhookEventType `json:"type"`
+	Time       time.Time                  `json:"time"`
+	Namespace  string                     `json:"namespace"`
+	Pool       string                     `json:"pool,omitempty"`
+	CIResource string                     `json:"ciResource,omitempty"`
+	ResourceId string                     `json:"resourceId,omitempty"`
+	State      string                     `json:"state,omitempty"`
+	Message    string                     `json:"message,omitempty"`
+}
+
+// Notifier delivers the events to all the CIWebhooks subscribed to them.
+// A nil Notifier silently discards every event
+type Notifier struct {
+	client     client.Reader
+	httpClient *http.Client
+	logger     logr.Logger
+	wg         sync.WaitGroup
+
+	// How many times a delivery is tried before giving up
+	MaxAttempts int
+	// Delay before the first retry, doubled at every attempt
+	InitialBackoff time.Duration
+}
+
+func NewNotifier(c client.Reader, logger logr.Logger) *Notifier {
+	return &Notifier{
+		client:         c,
+		httpClient:     &http.Client{Timeout: deliveryTimeout},
+		logger:         logger,
+		MaxAttempts:    defaultMaxAttempts,
+		InitialBackoff: defaultInitialBackoff,
+	}
+}
+
+// Notify sends the event to the webhooks defined in the event namespace. The
+// deliveries happen in background, so that the caller is never blocked by
+// slow or unreachable endpoints
+func (n *Notifier) Notify(ctx context.Context, event Event) {
+	if n == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	logger := n.logger.WithValues("Event", event.Type, "Namespace", event.Namespace)
+
+	webhooks := &ofcirv1.CIWebhookList{}
+	if err := n.client.List(ctx, webhooks, client.InNamespace(event.Namespace)); err != nil {
+		logger.Error(err, "could not list CIWebhooks")
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		logger.Error(err, "could not encode event")
+		return
+	}
+
+	for _, w := range webhooks.Items {
+		if !w.Accepts(event.Type) {
+			continue
+		}
+
+		key, err := n.signingKey(ctx, w)
+		if err != nil {
+			logger.Error(err, "could not get CIWebhook signing key, skipping it", "CIWebhook", w.Name)
+			continue
+		}
+
+		n.wg.Add(1)
+		go func(w ofcirv1.CIWebhook) {
+			defer n.wg.Done()
+			if err := n.deliver(w.Spec.URL, event.Type, body, key); err != nil {
+				logger.Error(err, "could not deliver event", "CIWebhook", w.Name)
+			}
+		}(w)
+	}
+}
+
+// Wait blocks until all the pending deliveries are completed
+func (n *Notifier) Wait() {
+	if n == nil {
+		return
+	}
+	n.wg.Wait()
+}
+
+func (n *Notifier) signingKey(ctx context.Context, w ofcirv1.CIWebhook) ([]byte, error) {
+	if w.Spec.SecretRef == nil {
+		return nil, nil
+	}
+
+	secret := &v1.Secret{}
+	if err := n.client.Get(ctx, types.NamespacedName{Namespace: w.Namespace, Name: w.Spec.SecretRef.Name}, secret); err != nil {
+		return nil, err
+	}
+	key, ok := secret.Data[ofcirv1.WebhookSecretKey]
+	if !ok || len(key) == 0 {
+		return nil, fmt.Errorf("secret %s does not contain the `%s` field", secret.Name, ofcirv1.WebhookSecretKey)
+	}
+	return key, nil
+}
+
+// deliver POSTs the payload, retrying with an exponential backoff in case of
+// network errors or server side failures
+func (n *Notifier) deliver(url string, eventType ofcirv1.CIWebhookEventType, body []byte, key []byte) error {
+	backoff := n.InitialBackoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = n.post(url, eventType, body, key)
+		if err == nil || !retry || attempt >= n.MaxAttempts {
+			break
+		}
+
+		n.logger.V(1).Info("event delivery failed, retrying", "URL", url, "Attempt", attempt, "Error", err.Error())
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxBackoff)
+	}
+	return err
+}
+
+func (n *Notifier) post(url string, eventType ofcirv1.CIWebhookEventType, body []byte, key []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ofcir")
+	req.Header.Set(EventHeader, string(eventType))
+	if key != nil {
+		req.Header.Set(SignatureHeader, Sign(key, body))
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Only server side errors and throttling are worth a retry
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return false, nil
+}
+
+// Sign returns the value of the signature header for the given payload
+func Sign(key []byte, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
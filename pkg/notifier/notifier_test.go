@@ -0,0 +1,178 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type request struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+// receiver is a local HTTP endpoint recording the received notifications
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []request
+	failures int
+}
+
+func newReceiver(failures int) *receiver {
+	r := &receiver{failures: failures}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, request{
+			event:     req.Header.Get(EventHeader),
+			signature: req.Header.Get(SignatureHeader),
+			body:      body,
+		})
+		if r.failures > 0 {
+			r.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	return r
+}
+
+func webhook(name string, url string, secret string, events ...ofcirv1.CIWebhookEventType) *ofcirv1.CIWebhook {
+	w := &ofcirv1.CIWebhook{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ofcir-system"},
+		Spec: ofcirv1.CIWebhookSpec{
+			URL:    url,
+			Events: events,
+		},
+	}
+	if secret != "" {
+		w.Spec.SecretRef = &v1.LocalObjectReference{Name: secret}
+	}
+	return w
+}
+
+func newNotifier(t *testing.T, objs ...client.Object) *Notifier {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, ofcirv1.AddToScheme(scheme))
+
+	n := NewNotifier(fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(), logr.Discard())
+	n.InitialBackoff = time.Millisecond
+	return n
+}
+
+func TestNotify(t *testing.T) {
+	event := Event{
+		Type:       ofcirv1.EventResourceError,
+		Namespace:  "ofcir-system",
+		Pool:       "cipool-fake",
+		CIResource: "cir-0001",
+		Message:    "provisioning failed",
+	}
+
+	tests := []struct {
+		name              string
+		failures          int
+		events            []ofcirv1.CIWebhookEventType
+		secret            string
+		expectedRequests  int
+		expectedSignature bool
+	}{
+		{
+			name:             "delivered",
+			expectedRequests: 1,
+		},
+		{
+			name:             "filtered out",
+			events:           []ofcirv1.CIWebhookEventType{ofcirv1.EventResourceTimeout},
+			expectedRequests: 0,
+		},
+		{
+			name:              "signed",
+			events:            []ofcirv1.CIWebhookEventType{ofcirv1.EventResourceTimeout, ofcirv1.EventResourceError},
+			secret:            "webhook-secret",
+			expectedRequests:  1,
+			expectedSignature: true,
+		},
+		{
+			name:             "retried after failures",
+			failures:         2,
+			expectedRequests: 3,
+		},
+		{
+			name:             "given up after max attempts",
+			failures:         10,
+			expectedRequests: defaultMaxAttempts,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newReceiver(tt.failures)
+			defer r.Close()
+
+			secret := &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "webhook-secret", Namespace: "ofcir-system"},
+				Data:       map[string][]byte{ofcirv1.WebhookSecretKey: []byte("s3cr3t")},
+			}
+			n := newNotifier(t, secret, webhook("hook", r.URL, tt.secret, tt.events...))
+
+			n.Notify(context.TODO(), event)
+			n.Wait()
+
+			assert.Len(t, r.requests, tt.expectedRequests)
+			for _, req := range r.requests {
+				assert.Equal(t, string(ofcirv1.EventResourceError), req.event)
+
+				received := Event{}
+				assert.NoError(t, json.Unmarshal(req.body, &received))
+				assert.Equal(t, event.CIResource, received.CIResource)
+				assert.False(t, received.Time.IsZero())
+
+				if tt.expectedSignature {
+					assert.Equal(t, Sign([]byte("s3cr3t"), req.body), req.signature)
+				} else {
+					assert.Empty(t, req.signature)
+				}
+			}
+		})
+	}
+}
+
+func TestNotifyNotRetriedOnClientErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	n := newNotifier(t, webhook("hook", srv.URL, ""))
+	n.Notify(context.TODO(), Event{Type: ofcirv1.EventPoolSizeUnreachable, Namespace: "ofcir-system"})
+	n.Wait()
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestNilNotifier(t *testing.T) {
+	var n *Notifier
+	n.Notify(context.TODO(), Event{Type: ofcirv1.EventResourceError})
+	n.Wait()
+}
@@ -52,6 +52,8 @@ const (
 	PoolNameFilter          = "tag:cipool"
 	InstanceStateFilterName = "instance-state-name"
 	RunningState            = "running"
+	ShuttingDownState       = "shutting-down"
+	TerminatedState         = "terminated"
 )
 
 var _ AWSHandlerInterface = &awsHandler{}
@@ -62,6 +64,7 @@ type AWSHandlerInterface interface {
 	FindInstanceRegion(ctx context.Context, params *FindRegionParams) (*string, error)
 	GetTotalAmountOfPoolInstancesInRegion(ctx context.Context, params *PoolFilterParams) (*int, error)
 	IsInstanceInRegionActive(ctx context.Context, params *InstanceIdentifier) (*bool, error)
+	GetInstanceInRegionState(ctx context.Context, params *InstanceIdentifier) (*string, error)
 	GetInstanceInRegionPublicIP(ctx context.Context, params *InstanceIdentifier) (*string, error)
 }
 
@@ -208,11 +211,13 @@ func (h *awsHandler) ReleaseInstanceInRegion(
 }
 
 func (h *awsHandler) FindInstanceRegion(ctx context.Context, params *FindRegionParams) (*string, error) {
+	queried := 0
 	for _, region := range params.PossibleRegions {
 		client, err := h.clientsBuilder.GetEC2Client(ctx, region, h.staticCredentials)
 		if err != nil {
 			continue
 		}
+		queried++
 
 		output, err := client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
 			InstanceIds: []string{params.InstanceID},
@@ -230,7 +235,11 @@ func (h *awsHandler) FindInstanceRegion(ctx context.Context, params *FindRegionP
 		}
 	}
 
-	return nil, fmt.Errorf("instance %s not found in any region", params.InstanceID)
+	if queried < len(params.PossibleRegions) {
+		return nil, fmt.Errorf("instance %s not found in any region", params.InstanceID)
+	}
+	// All the regions were successfully checked
+	return nil, NewResourceNotFoundError(params.InstanceID)
 }
 
 func (h *awsHandler) GetTotalAmountOfPoolInstancesInRegion(ctx context.Context, params *PoolFilterParams) (*int, error) {
@@ -258,6 +267,15 @@ func (h *awsHandler) GetTotalAmountOfPoolInstancesInRegion(ctx context.Context,
 }
 
 func (h *awsHandler) IsInstanceInRegionActive(ctx context.Context, instanceIdentifier *InstanceIdentifier) (*bool, error) {
+	state, err := h.GetInstanceInRegionState(ctx, instanceIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.ToPtr(*state == RunningState), nil
+}
+
+func (h *awsHandler) GetInstanceInRegionState(ctx context.Context, instanceIdentifier *InstanceIdentifier) (*string, error) {
 	client, err := h.clientsBuilder.GetEC2Client(ctx, instanceIdentifier.Region, h.staticCredentials)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create EC2 client for region %s: %w", instanceIdentifier.Region, err)
@@ -279,7 +297,7 @@ func (h *awsHandler) IsInstanceInRegionActive(ctx context.Context, instanceIdent
 		return nil, fmt.Errorf("instance %s in region %s doesn't have a state", instanceIdentifier.InstanceID, instanceIdentifier.Region)
 	}
 
-	return lo.ToPtr(instance.State.Name == RunningState), nil
+	return lo.ToPtr(string(instance.State.Name)), nil
 }
 
 func (h *awsHandler) GetInstanceInRegionPublicIP(ctx context.Context, instanceIdentifier *InstanceIdentifier) (*string, error) {
@@ -355,8 +373,10 @@ type awsProvider struct {
 	handler AWSHandlerInterface
 }
 
-func AWSProviderFactory(providerInfo string, secretData map[string][]byte, logger logr.Logger) (Provider, error) {
-	config := awsProviderConfig{
+// defaultAWSProviderConfig returns the configuration overridden by the `config`
+// field of the pool secret
+func defaultAWSProviderConfig() awsProviderConfig {
+	return awsProviderConfig{
 		MachineSpec: &MachineSpec{
 			Regions: []RegionSpec{
 				{
@@ -373,6 +393,10 @@ func AWSProviderFactory(providerInfo string, secretData map[string][]byte, logge
 			},
 		},
 	}
+}
+
+func AWSProviderFactory(providerInfo string, secretData map[string][]byte, logger logr.Logger) (Provider, error) {
+	config := defaultAWSProviderConfig()
 
 	if configJson, ok := secretData["config"]; ok {
 		if err := json.Unmarshal(configJson, &config); err != nil {
@@ -489,6 +513,46 @@ func (p *awsProvider) AcquireCompleted(id string) (bool, Resource, error) {
 	return true, res, nil
 }
 
+func (p *awsProvider) Describe(id string) (Resource, error) {
+	ctx := context.Background()
+	res := Resource{Id: id}
+
+	region, err := p.handler.FindInstanceRegion(
+		ctx, &FindRegionParams{InstanceID: id, PossibleRegions: p.getSupportedRegions()},
+	)
+	if err != nil {
+		return res, fmt.Errorf("error finding instance: %w", err)
+	}
+
+	state, err := p.handler.GetInstanceInRegionState(
+		ctx, &InstanceIdentifier{Region: lo.FromPtr(region), InstanceID: id},
+	)
+	if err != nil {
+		return res, fmt.Errorf("error checking instance state: %w", err)
+	}
+
+	switch lo.FromPtr(state) {
+	case RunningState:
+	case ShuttingDownState, TerminatedState:
+		// Terminated instances are still reported for a while
+		return res, NewResourceNotFoundError(id)
+	default:
+		// A stopped or pending instance still exists, but has no public IP
+		return res, nil
+	}
+
+	ip, err := p.handler.GetInstanceInRegionPublicIP(
+		ctx, &InstanceIdentifier{Region: lo.FromPtr(region), InstanceID: id},
+	)
+	if err != nil {
+		return res, fmt.Errorf("error checking if instance public IP: %w", err)
+	}
+
+	res.Address = lo.FromPtr(ip)
+
+	return res, nil
+}
+
 func (p *awsProvider) Clean(id string) error {
 	// AWS doesn't support reloading instance
 	return nil
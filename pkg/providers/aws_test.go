@@ -218,6 +218,24 @@ func TestFindInstanceRegion_AllFail(t *testing.T) {
 	assert.Nil(t, region)
 }
 
+func TestFindInstanceRegion_NotFound(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	builder := NewMockAWSClientsBuilderInterface(ctrl)
+	client := NewMockEC2ClientInterface(ctrl)
+	h := &awsHandler{staticCredentials: credentials.NewStaticCredentialsProvider("ak", "sk", ""), logger: logr.Discard(), clientsBuilder: builder}
+
+	builder.EXPECT().GetEC2Client(ctx, "r1", h.staticCredentials).Return(client, nil)
+	client.EXPECT().DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{"i-x"}}).
+		Return(nil, &smithy.GenericAPIError{Code: "InvalidInstanceID.NotFound"})
+
+	region, err := h.FindInstanceRegion(ctx, &FindRegionParams{InstanceID: "i-x", PossibleRegions: []string{"r1"}})
+	assert.ErrorAs(t, err, &ResourceNotFoundError{})
+	assert.Nil(t, region)
+}
+
 // GetTotalAmountOfPoolInstancesInRegion
 func TestGetTotalAmountOfPoolInstancesInRegion_Success(t *testing.T) {
 	ctx, handler, builder, client, teardown := setup(t)
@@ -282,6 +300,26 @@ func TestIsInstanceInRegionActive_ErrorScenarios(t *testing.T) {
 	assert.Contains(t, err.Error(), "doesn't have a state")
 }
 
+// GetInstanceInRegionState
+func TestGetInstanceInRegionState_Stopped(t *testing.T) {
+	ctx, handler, builder, client, teardown := setup(t)
+	defer teardown()
+
+	ident := &InstanceIdentifier{Region: "eu-central-1", InstanceID: "i-stop"}
+	builder.EXPECT().GetEC2Client(ctx, ident.Region, handler.staticCredentials).Return(client, nil).Times(2)
+	client.EXPECT().DescribeInstances(ctx, gomock.Any()).Return(&ec2.DescribeInstancesOutput{
+		Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{{State: &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped}}}}},
+	}, nil).Times(2)
+
+	state, err := handler.GetInstanceInRegionState(ctx, ident)
+	assert.NoError(t, err)
+	assert.Equal(t, "stopped", *state)
+
+	active, err := handler.IsInstanceInRegionActive(ctx, ident)
+	assert.NoError(t, err)
+	assert.False(t, *active)
+}
+
 // GetInstanceInRegionPublicIP
 func TestGetInstanceInRegionPublicIP_Success(t *testing.T) {
 	ctx, handler, builder, client, teardown := setup(t)
@@ -507,6 +545,75 @@ func TestAcquireCompleted_Scenarios(t *testing.T) {
 	assert.Equal(t, "1.2.3.4", res.Address)
 }
 
+func TestDescribe_Scenarios(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	awsHandlerMock := NewMockAWSHandlerInterface(ctrl)
+	prov := &awsProvider{
+		config:  awsProviderConfig{MachineSpec: &MachineSpec{Regions: []RegionSpec{{Name: "us-east-1"}}}},
+		handler: awsHandlerMock,
+		logger:  logr.Discard(),
+	}
+	id := "i-1"
+
+	// 1) Instance not found in any region
+	awsHandlerMock.EXPECT().FindInstanceRegion(
+		ctx,
+		&FindRegionParams{InstanceID: id, PossibleRegions: []string{"us-east-1"}},
+	).Return(nil, NewResourceNotFoundError(id))
+	_, err := prov.Describe(id)
+	assert.ErrorAs(t, err, &ResourceNotFoundError{})
+
+	// 2) Instance terminated or being terminated
+	for _, state := range []string{TerminatedState, ShuttingDownState} {
+		awsHandlerMock.EXPECT().FindInstanceRegion(
+			ctx,
+			&FindRegionParams{InstanceID: id, PossibleRegions: []string{"us-east-1"}},
+		).Return(lo.ToPtr("us-east-1"), nil)
+		awsHandlerMock.EXPECT().GetInstanceInRegionState(
+			ctx,
+			&InstanceIdentifier{Region: "us-east-1", InstanceID: id},
+		).Return(lo.ToPtr(state), nil)
+		_, err = prov.Describe(id)
+		assert.ErrorAs(t, err, &ResourceNotFoundError{}, state)
+	}
+
+	// 3) Instance stopped or pending, still existing but without a public IP
+	for _, state := range []string{"stopped", "pending"} {
+		awsHandlerMock.EXPECT().FindInstanceRegion(
+			ctx,
+			&FindRegionParams{InstanceID: id, PossibleRegions: []string{"us-east-1"}},
+		).Return(lo.ToPtr("us-east-1"), nil)
+		awsHandlerMock.EXPECT().GetInstanceInRegionState(
+			ctx,
+			&InstanceIdentifier{Region: "us-east-1", InstanceID: id},
+		).Return(lo.ToPtr(state), nil)
+		res, err := prov.Describe(id)
+		assert.NoError(t, err, state)
+		assert.Equal(t, id, res.Id)
+		assert.Empty(t, res.Address)
+	}
+
+	// 4) Success
+	awsHandlerMock.EXPECT().FindInstanceRegion(
+		ctx,
+		&FindRegionParams{InstanceID: id, PossibleRegions: []string{"us-east-1"}},
+	).Return(lo.ToPtr("us-east-1"), nil)
+	awsHandlerMock.EXPECT().GetInstanceInRegionState(
+		ctx,
+		&InstanceIdentifier{Region: "us-east-1", InstanceID: id},
+	).Return(lo.ToPtr(RunningState), nil)
+	awsHandlerMock.EXPECT().GetInstanceInRegionPublicIP(
+		ctx,
+		&InstanceIdentifier{Region: "us-east-1", InstanceID: id},
+	).Return(lo.ToPtr("1.2.3.4"), nil)
+	res, err := prov.Describe(id)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3.4", res.Address)
+}
+
 func TestRelease_Scenarios(t *testing.T) {
 	ctx := context.Background()
 	ctrl := gomock.NewController(t)
@@ -0,0 +1,54 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+// PoolConfigData returns the pool secret data the provider is created from. The
+// `config` field of the secret is overridden by the `spec.providerConfig` settings
+// of the pool, top-level key by top-level key
+func PoolConfigData(pool *ofcirv1.CIPool, secretData map[string][]byte) (map[string][]byte, error) {
+	if pool.Spec.ProviderConfig == nil || len(pool.Spec.ProviderConfig.Raw) == 0 {
+		return secretData, nil
+	}
+
+	config := map[string]json.RawMessage{}
+	if configJSON, ok := secretData[ofcirv1.ProviderConfigSecretKey]; ok {
+		if err := json.Unmarshal(configJSON, &config); err != nil {
+			return nil, fmt.Errorf("error in provider config json: %w", err)
+		}
+	}
+
+	overrides := map[string]json.RawMessage{}
+	if err := json.Unmarshal(pool.Spec.ProviderConfig.Raw, &overrides); err != nil {
+		return nil, fmt.Errorf("error in pool providerConfig json: %w", err)
+	}
+	for k, v := range overrides {
+		config[k] = v
+	}
+
+	merged, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string][]byte, len(secretData)+1)
+	for k, v := range secretData {
+		data[k] = v
+	}
+	data[ofcirv1.ProviderConfigSecretKey] = merged
+	return data, nil
+}
+
+// ValidatePoolConfig checks the provider configuration of a pool, obtained by
+// merging its `spec.providerConfig` settings with the pool secret ones
+func ValidatePoolConfig(pool *ofcirv1.CIPool, secretData map[string][]byte) error {
+	data, err := PoolConfigData(pool, secretData)
+	if err != nil {
+		return err
+	}
+	return ValidateConfig(pool.Spec.Provider, data)
+}
@@ -0,0 +1,70 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+func TestPoolConfigData(t *testing.T) {
+	cases := []struct {
+		name           string
+		secretConfig   string
+		providerConfig string
+		expectedConfig string
+		expectedErr    string
+	}{
+		{
+			name:           "secret only",
+			secretConfig:   `{"endpoint": "https://172.22.0.3:6385"}`,
+			expectedConfig: `{"endpoint": "https://172.22.0.3:6385"}`,
+		},
+		{
+			name:           "pool only",
+			providerConfig: `{"image": "fedora"}`,
+			expectedConfig: `{"image": "fedora"}`,
+		},
+		{
+			name:           "pool overrides secret",
+			secretConfig:   `{"endpoint": "https://172.22.0.3:6385", "image": "centos"}`,
+			providerConfig: `{"image": "fedora"}`,
+			expectedConfig: `{"endpoint": "https://172.22.0.3:6385", "image": "fedora"}`,
+		},
+		{
+			name:           "malformed secret config",
+			secretConfig:   `{"endpoint": `,
+			providerConfig: `{"image": "fedora"}`,
+			expectedErr:    "error in provider config json: unexpected end of JSON input",
+		},
+		{
+			name:           "provider config not an object",
+			providerConfig: `["fedora"]`,
+			expectedErr:    "error in pool providerConfig json: json: cannot unmarshal array",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pool := &ofcirv1.CIPool{Spec: ofcirv1.CIPoolSpec{Provider: string(ProviderIronic)}}
+			if tc.providerConfig != "" {
+				pool.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(tc.providerConfig)}
+			}
+			secretData := map[string][]byte{"password": []byte("secret")}
+			if tc.secretConfig != "" {
+				secretData[ofcirv1.ProviderConfigSecretKey] = []byte(tc.secretConfig)
+			}
+
+			data, err := PoolConfigData(pool, secretData)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.expectedConfig, string(data[ofcirv1.ProviderConfigSecretKey]))
+			assert.Equal(t, "secret", string(data["password"]))
+		})
+	}
+}
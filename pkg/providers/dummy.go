@@ -65,6 +65,16 @@ func (p *dummyProvider) AcquireCompleted(id string) (bool, Resource, error) {
 	return true, resource.Resource, nil
 }
 
+func (p *dummyProvider) Describe(id string) (Resource, error) {
+
+	resource, ok := p.instances[id]
+	if !ok {
+		return Resource{}, NewResourceNotFoundError(id)
+	}
+
+	return resource.Resource, nil
+}
+
 func (p *dummyProvider) Clean(id string) error {
 	_, ok := p.instances[id]
 	if !ok {
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/go-logr/logr"
@@ -131,6 +132,9 @@ func (p *equinixProvider) AcquireCompleted(id string) (bool, Resource, error) {
 
 	if device.State == "active" {
 		resource.Address = device.GetNetworkInfo().PublicIPv4
+		if device.Plan != nil && device.Plan.Pricing != nil {
+			resource.HourlyPrice = float64(device.Plan.Pricing.Hour)
+		}
 		return true, resource, nil
 	}
 
@@ -141,6 +145,23 @@ func (p *equinixProvider) AcquireCompleted(id string) (bool, Resource, error) {
 	return false, resource, nil
 }
 
+func (p *equinixProvider) Describe(id string) (Resource, error) {
+	resource := Resource{
+		Id: id,
+	}
+
+	device, resp, err := p.client.Devices.Get(id, nil)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return resource, NewResourceNotFoundError(id)
+		}
+		return resource, fmt.Errorf("error getting device: %w", err)
+	}
+
+	resource.Address = device.GetNetworkInfo().PublicIPv4
+	return resource, nil
+}
+
 func (p *equinixProvider) Clean(id string) error {
 	rf := packngo.DeviceReinstallFields{
 		DeprovisionFast: true,
@@ -23,6 +23,8 @@ const IbmCloudFastProvisioningServer = 200
 const manualTag = "ofcir-manual"
 const takenTag = "ofcir-taken"
 
+var errNodeNotFound = errors.New("Node node found")
+
 // TODO: Would love to find a way to list locations where the package is available but
 // I can't for the life of me figure it out, need to revisit
 // I've removed some of the more expensive locations from the list below
@@ -229,7 +231,7 @@ func (p *ibmcloudProvider) getNodeByName(name string) (*datatypes.Hardware, erro
 			return &node, nil
 		}
 	}
-	return nil, errors.New("Node node found")
+	return nil, errNodeNotFound
 }
 
 func (p *ibmcloudProvider) ensureSSHKey(newkey string) (*int, error) {
@@ -322,6 +324,11 @@ func (p *ibmcloudProvider) AcquireCompleted(id string) (bool, Resource, error) {
 		return false, res, nil
 	}
 
+	// The IP is assigned only after the server was ordered
+	if node.PrimaryIpAddress == nil {
+		return false, res, nil
+	}
+
 	// Hold back on setting nodes to Available until ssh is available
 	if !utils.IsPortOpen(*node.PrimaryIpAddress, "22") {
 		return false, res, nil
@@ -332,6 +339,26 @@ func (p *ibmcloudProvider) AcquireCompleted(id string) (bool, Resource, error) {
 	return true, res, nil
 }
 
+func (p *ibmcloudProvider) Describe(id string) (Resource, error) {
+	res := Resource{
+		Id: id,
+	}
+
+	node, err := p.getNodeByName(id)
+	if err != nil {
+		if errors.Is(err, errNodeNotFound) {
+			return res, NewResourceNotFoundError(id)
+		}
+		return res, err
+	}
+
+	// Not assigned yet, or already reclaimed
+	if node.PrimaryIpAddress != nil {
+		res.Address = *node.PrimaryIpAddress
+	}
+	return res, nil
+}
+
 func (p *ibmcloudProvider) Clean(id string) error {
 	node, err := p.getNodeByName(id)
 
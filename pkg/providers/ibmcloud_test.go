@@ -0,0 +1,65 @@
+package providers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/softlayer/softlayer-go/datatypes"
+	"github.com/softlayer/softlayer-go/session"
+	"github.com/softlayer/softlayer-go/sl"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSoftLayer returns the given hardware list to every account request
+type fakeSoftLayer struct {
+	hardware []datatypes.Hardware
+}
+
+func (f *fakeSoftLayer) DoRequest(sess *session.Session, service string, method string, args []interface{}, options *sl.Options, pResult interface{}) error {
+	if service == "SoftLayer_Account" && method == "getHardware" {
+		*pResult.(*[]datatypes.Hardware) = f.hardware
+		return nil
+	}
+	return errors.New("unexpected request " + service + "." + method)
+}
+
+func newFakeIbmcloudProvider(hardware ...datatypes.Hardware) *ibmcloudProvider {
+	return &ibmcloudProvider{
+		client: &session.Session{TransportHandler: &fakeSoftLayer{hardware: hardware}},
+	}
+}
+
+func TestIbmcloudDescribe(t *testing.T) {
+	p := newFakeIbmcloudProvider(
+		datatypes.Hardware{Hostname: sl.String("ready"), PrimaryIpAddress: sl.String("10.0.0.1")},
+		datatypes.Hardware{Hostname: sl.String("ordered")},
+	)
+
+	res, err := p.Describe("ready")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", res.Address)
+
+	res, err = p.Describe("ordered")
+	assert.NoError(t, err)
+	assert.Empty(t, res.Address, "no address until assigned")
+
+	_, err = p.Describe("missing")
+	assert.ErrorAs(t, err, &ResourceNotFoundError{})
+}
+
+func TestIbmcloudAcquireCompletedWithoutAddress(t *testing.T) {
+	p := newFakeIbmcloudProvider(datatypes.Hardware{
+		Hostname:        sl.String("ordered"),
+		HardwareStatus:  &datatypes.Hardware_Status{Status: sl.String("ACTIVE")},
+		LastTransaction: &datatypes.Provisioning_Version1_Transaction{TransactionStatus: &datatypes.Provisioning_Version1_Transaction_Status{Name: sl.String("COMPLETE")}},
+	})
+
+	ready, _, err := p.AcquireCompleted("ordered")
+	assert.NoError(t, err)
+	assert.False(t, ready)
+
+	// The other operations do not report a missing node as gone
+	_, _, err = p.AcquireCompleted("missing")
+	assert.ErrorIs(t, err, errNodeNotFound)
+	assert.ErrorIs(t, p.Release("missing"), errNodeNotFound)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -234,6 +235,23 @@ func (p *ironicProvider) AcquireCompleted(id string) (bool, Resource, error) {
 	return true, res, nil
 }
 
+func (p *ironicProvider) Describe(id string) (Resource, error) {
+	res := Resource{
+		Id: id,
+	}
+
+	node, err := p.GetNode(id)
+	if err != nil {
+		if errors.As(err, &gophercloud.ErrDefault404{}) {
+			return res, NewResourceNotFoundError(id)
+		}
+		return res, fmt.Errorf("error getting node: %w", err)
+	}
+
+	res.Address, _ = node.Extra["ofcir_ip"].(string)
+	return res, nil
+}
+
 func (p *ironicProvider) Clean(id string) error {
 	node, err := p.GetNode(id)
 	if err != nil {
@@ -2,6 +2,7 @@ package providers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -130,6 +131,22 @@ func (p *libvirtProvider) AcquireCompleted(id string) (bool, Resource, error) {
 	return false, res, nil
 }
 
+func (p *libvirtProvider) Describe(id string) (Resource, error) {
+	ready, res, err := p.AcquireCompleted(id)
+	if err != nil {
+		var lverr libvirt.Error
+		if errors.As(err, &lverr) && lverr.Code == libvirt.ERR_NO_DOMAIN {
+			return res, NewResourceNotFoundError(id)
+		}
+		return res, err
+	}
+	// The domain is not running, or it has not got an address yet
+	if !ready {
+		return res, fmt.Errorf("domain %s is not ready", id)
+	}
+	return res, nil
+}
+
 func (p *libvirtProvider) Clean(id string) error {
 
 	conn, err := libvirt.NewConnect("qemu:///system")
@@ -187,6 +187,21 @@ func (mr *MockAWSHandlerInterfaceMockRecorder) GetInstanceInRegionPublicIP(ctx,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceInRegionPublicIP", reflect.TypeOf((*MockAWSHandlerInterface)(nil).GetInstanceInRegionPublicIP), ctx, params)
 }
 
+// GetInstanceInRegionState mocks base method.
+func (m *MockAWSHandlerInterface) GetInstanceInRegionState(ctx context.Context, params *InstanceIdentifier) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInstanceInRegionState", ctx, params)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInstanceInRegionState indicates an expected call of GetInstanceInRegionState.
+func (mr *MockAWSHandlerInterfaceMockRecorder) GetInstanceInRegionState(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceInRegionState", reflect.TypeOf((*MockAWSHandlerInterface)(nil).GetInstanceInRegionState), ctx, params)
+}
+
 // GetTotalAmountOfPoolInstancesInRegion mocks base method.
 func (m *MockAWSHandlerInterface) GetTotalAmountOfPoolInstancesInRegion(ctx context.Context, params *PoolFilterParams) (*int, error) {
 	m.ctrl.T.Helper()
@@ -9,6 +9,9 @@ type Resource struct {
 	Address string
 	// Extra information specific to the provider
 	Metadata string
+	// The hourly price of the resource, in the currency used by the
+	// provider. Zero if unknown
+	HourlyPrice float64
 }
 
 type Provider interface {
@@ -31,3 +34,11 @@ type Provider interface {
 	// Release the specified resource, to be used for a new request
 	Release(id string) error
 }
+
+// Describer is optionally implemented by the providers able to report
+// the current details of an existing resource
+type Describer interface {
+	// Return the current details of the resource, or a ResourceNotFoundError
+	// if it does not exist anymore on the provider side
+	Describe(id string) (Resource, error)
+}
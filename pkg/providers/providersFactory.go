@@ -21,19 +21,24 @@ const (
 
 func NewProvider(pool *ofcirv1.CIPool, poolSecret *v1.Secret, logger logr.Logger) (Provider, error) {
 
+	data, err := PoolConfigData(pool, poolSecret.Data)
+	if err != nil {
+		return nil, err
+	}
+
 	switch ProviderType(pool.Spec.Provider) {
 	case ProviderDummy:
-		return DummyProviderFactory(pool.Spec.ProviderInfo, poolSecret.Data), nil
+		return DummyProviderFactory(pool.Spec.ProviderInfo, data), nil
 	case ProviderLibvirt:
-		return LibvirtProviderFactory(pool.Spec.ProviderInfo, poolSecret.Data)
+		return LibvirtProviderFactory(pool.Spec.ProviderInfo, data)
 	case ProviderIronic:
-		return IronicProviderFactory(pool.Spec.ProviderInfo, poolSecret.Data)
+		return IronicProviderFactory(pool.Spec.ProviderInfo, data)
 	case ProviderEquinix:
-		return EquinixProviderFactory(pool.Spec.ProviderInfo, poolSecret.Data, logger)
+		return EquinixProviderFactory(pool.Spec.ProviderInfo, data, logger)
 	case ProviderIbmcloud:
-		return IbmcloudProviderFactory(pool.Spec.ProviderInfo, poolSecret.Data)
+		return IbmcloudProviderFactory(pool.Spec.ProviderInfo, data)
 	case ProviderAWS:
-		return AWSProviderFactory(pool.Spec.ProviderInfo, poolSecret.Data, logger)
+		return AWSProviderFactory(pool.Spec.ProviderInfo, data, logger)
 	default:
 		return nil, fmt.Errorf("unknown provider type: %s", pool.Spec.Provider)
 	}
@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/openshift/ofcir/pkg/providers")
+
+type tracedProvider struct {
+	ctx          context.Context
+	provider     Provider
+	providerType string
+}
+
+// WithTracing wraps the given provider so that every call is traced
+// as a child span of ctx
+func WithTracing(ctx context.Context, provider Provider, providerType string) Provider {
+	p := &tracedProvider{
+		ctx:          ctx,
+		provider:     provider,
+		providerType: providerType,
+	}
+	// Preserve the optional interfaces of the wrapped provider
+	if describer, ok := provider.(Describer); ok {
+		return &tracedDescriber{tracedProvider: p, describer: describer}
+	}
+	return p
+}
+
+func (p *tracedProvider) start(method string, attrs ...attribute.KeyValue) trace.Span {
+	attrs = append(attrs, attribute.String("ofcir.provider", p.providerType))
+	_, span := tracer.Start(p.ctx, "Provider."+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return span
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (p *tracedProvider) Acquire(poolSize int, poolName string, poolType string) (Resource, error) {
+	span := p.start("Acquire", attribute.String("ofcir.pool", poolName))
+	res, err := p.provider.Acquire(poolSize, poolName, poolType)
+	span.SetAttributes(attribute.String("ofcir.resource_id", res.Id))
+	end(span, err)
+	return res, err
+}
+
+func (p *tracedProvider) AcquireCompleted(id string) (bool, Resource, error) {
+	span := p.start("AcquireCompleted", attribute.String("ofcir.resource_id", id))
+	ready, res, err := p.provider.AcquireCompleted(id)
+	span.SetAttributes(attribute.Bool("ofcir.completed", ready))
+	end(span, err)
+	return ready, res, err
+}
+
+func (p *tracedProvider) Clean(id string) error {
+	span := p.start("Clean", attribute.String("ofcir.resource_id", id))
+	err := p.provider.Clean(id)
+	end(span, err)
+	return err
+}
+
+func (p *tracedProvider) CleanCompleted(id string) (bool, error) {
+	span := p.start("CleanCompleted", attribute.String("ofcir.resource_id", id))
+	cleaned, err := p.provider.CleanCompleted(id)
+	span.SetAttributes(attribute.Bool("ofcir.completed", cleaned))
+	end(span, err)
+	return cleaned, err
+}
+
+func (p *tracedProvider) Release(id string) error {
+	span := p.start("Release", attribute.String("ofcir.resource_id", id))
+	err := p.provider.Release(id)
+	end(span, err)
+	return err
+}
+
+type tracedDescriber struct {
+	*tracedProvider
+	describer Describer
+}
+
+func (p *tracedDescriber) Describe(id string) (Resource, error) {
+	span := p.start("Describe", attribute.String("ofcir.resource_id", id))
+	res, err := p.describer.Describe(id)
+	end(span, err)
+	return res, err
+}
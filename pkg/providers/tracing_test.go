@@ -0,0 +1,29 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTracingPreservesDescriber(t *testing.T) {
+	traced := WithTracing(context.TODO(), DummyProviderFactory("", nil), string(ProviderDummy))
+
+	describer, ok := traced.(Describer)
+	assert.True(t, ok)
+
+	res, err := describer.Describe("dummy-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.1.1", res.Address)
+
+	_, err = describer.Describe("unknown")
+	assert.ErrorAs(t, err, &ResourceNotFoundError{})
+}
+
+func TestWithTracingWithoutDescriber(t *testing.T) {
+	traced := WithTracing(context.TODO(), &tracedProvider{}, "fake")
+
+	_, ok := traced.(Describer)
+	assert.False(t, ok)
+}
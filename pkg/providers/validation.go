@@ -0,0 +1,129 @@
+package providers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+// ConfigValidator checks a provider configuration without contacting the provider
+type ConfigValidator func(configJSON []byte) error
+
+// configValidators holds the validation hook of every provider. Providers without
+// a specific hook only require the configuration to be a JSON object
+var configValidators = map[ProviderType]ConfigValidator{
+	ProviderLibvirt:  validateLibvirtConfig,
+	ProviderIronic:   validateIronicConfig,
+	ProviderEquinix:  validateEquinixConfig,
+	ProviderIbmcloud: validateIbmcloudConfig,
+	ProviderAWS:      validateAWSConfig,
+}
+
+// KnownProviders returns the provider types supported by the operator
+func KnownProviders() []ProviderType {
+	return []ProviderType{ProviderDummy, ProviderLibvirt, ProviderIronic, ProviderEquinix, ProviderIbmcloud, ProviderAWS}
+}
+
+// IsKnownProvider returns true if the given provider type is supported
+func IsKnownProvider(provider string) bool {
+	for _, p := range KnownProviders() {
+		if string(p) == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateConfig checks the provider configuration stored in the `config` field
+// of a pool secret. A missing configuration is valid, since every provider has
+// its own defaults
+func ValidateConfig(provider string, secretData map[string][]byte) error {
+	configJSON, ok := secretData[ofcirv1.ProviderConfigSecretKey]
+	if !ok {
+		return nil
+	}
+
+	validate, ok := configValidators[ProviderType(provider)]
+	if !ok {
+		validate = validateJSONObject
+	}
+	return validate(configJSON)
+}
+
+func validateJSONObject(configJSON []byte) error {
+	config := map[string]interface{}{}
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return fmt.Errorf("error in provider config json: %w", err)
+	}
+	return nil
+}
+
+func validateLibvirtConfig(configJSON []byte) error {
+	config := libvirtProviderConfig{}
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return fmt.Errorf("error in provider config json: %w", err)
+	}
+	return nil
+}
+
+func validateIronicConfig(configJSON []byte) error {
+	config := ironicProviderConfig{}
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return fmt.Errorf("error in provider config json: %w", err)
+	}
+	return nil
+}
+
+func validateEquinixConfig(configJSON []byte) error {
+	config := equinixProviderConfig{}
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return fmt.Errorf("error in provider config json: %w", err)
+	}
+	if config.UserData != "" {
+		if _, err := base64.StdEncoding.DecodeString(config.UserData); err != nil {
+			return fmt.Errorf("error decoding userdata: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateIbmcloudConfig(configJSON []byte) error {
+	config := ibmcloudProviderConfig{}
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return fmt.Errorf("error in provider config json: %w", err)
+	}
+	return nil
+}
+
+func validateAWSConfig(configJSON []byte) error {
+	config := defaultAWSProviderConfig()
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return fmt.Errorf("error in provider config json: %w", err)
+	}
+	if config.MachineSpec == nil {
+		return fmt.Errorf("machineSpec cannot be null")
+	}
+
+	if len(config.MachineSpec.Regions) == 0 {
+		return fmt.Errorf("machineSpec: at least one region is required")
+	}
+	for i, region := range config.MachineSpec.Regions {
+		if region.Name == "" {
+			return fmt.Errorf("machineSpec.regions[%d]: name is required", i)
+		}
+		if len(region.Instances) == 0 {
+			return fmt.Errorf("machineSpec.regions[%d]: at least one instance is required", i)
+		}
+		for j, instance := range region.Instances {
+			if instance.Type == "" || instance.AMIID == "" {
+				return fmt.Errorf("machineSpec.regions[%d].instances[%d]: type and amiID are required", i, j)
+			}
+		}
+	}
+	if config.MachineSpec.Device.DeviceSize < 0 {
+		return fmt.Errorf("machineSpec.device: deviceSize cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfig(t *testing.T) {
+	cases := []struct {
+		name        string
+		provider    ProviderType
+		config      string
+		expectedErr string
+	}{
+		{
+			name:     "no config",
+			provider: ProviderIronic,
+		},
+		{
+			name:        "malformed json",
+			provider:    ProviderIronic,
+			config:      `{"endpoint": `,
+			expectedErr: "error in provider config json: unexpected end of JSON input",
+		},
+		{
+			name:        "wrong field type",
+			provider:    ProviderLibvirt,
+			config:      `{"volume": "20"}`,
+			expectedErr: "error in provider config json: json: cannot unmarshal string into Go struct field libvirtProviderConfig.volume of type uint64",
+		},
+		{
+			name:     "valid libvirt config",
+			provider: ProviderLibvirt,
+			config:   `{"pool": "default", "volume": 20, "memory": 4}`,
+		},
+		{
+			name:        "equinix userdata not encoded",
+			provider:    ProviderEquinix,
+			config:      `{"projectid": "p", "userdata": "#!/bin/bash"}`,
+			expectedErr: "error decoding userdata: illegal base64 data at input byte 0",
+		},
+		{
+			name:     "aws defaults",
+			provider: ProviderAWS,
+			config:   `{"accessKey": "key", "secretAccessKey": "secret"}`,
+		},
+		{
+			name:        "aws instance without ami",
+			provider:    ProviderAWS,
+			config:      `{"machineSpec": {"regions": [{"name": "us-east-1", "instances": [{"type": "c5n.metal", "amiID": "ami-1"}, {"type": "m5.metal"}]}]}}`,
+			expectedErr: "machineSpec.regions[0].instances[1]: type and amiID are required",
+		},
+		{
+			name:        "aws without regions",
+			provider:    ProviderAWS,
+			config:      `{"machineSpec": {"regions": []}}`,
+			expectedErr: "machineSpec: at least one region is required",
+		},
+		{
+			name:     "provider without a specific hook",
+			provider: ProviderDummy,
+			config:   `{}`,
+		},
+		{
+			name:        "provider without a specific hook, not an object",
+			provider:    ProviderDummy,
+			config:      `[]`,
+			expectedErr: "error in provider config json: json: cannot unmarshal array into Go value of type map[string]interface {}",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			secretData := map[string][]byte{}
+			if tc.config != "" {
+				secretData["config"] = []byte(tc.config)
+			}
+
+			err := ValidateConfig(string(tc.provider), secretData)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestIsKnownProvider(t *testing.T) {
+	assert.True(t, IsKnownProvider("ironic"))
+	assert.True(t, IsKnownProvider("fake-provider"))
+	assert.False(t, IsKnownProvider("openstack"))
+}
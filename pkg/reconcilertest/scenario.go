@@ -30,6 +30,7 @@ type Scenario[R reconcile.Reconciler, T any, PT interface {
 	client.Object
 }] interface {
 	WithSchemes(...func(s *runtime.Scheme) error) Scenario[R, T, PT]
+	WithIndex(obj client.Object, field string, extractValue client.IndexerFunc) Scenario[R, T, PT]
 	Setup(func() []client.Object) _reconcileStart[T, PT]
 }
 
@@ -78,11 +79,18 @@ type reconcileHandler[PT runtime.Object] struct {
 	action      func(t *testing.T, client client.Client, obj PT)
 	actionLabel string
 }
+type index struct {
+	obj          client.Object
+	field        string
+	extractValue client.IndexerFunc
+}
+
 type scenario[R reconcile.Reconciler, T any, PT interface {
 	*T
 	client.Object
 }] struct {
 	schemes      []func(*runtime.Scheme) error
+	indexes      []index
 	setupHandler func() []client.Object
 	startObj     types.NamespacedName
 	handlers     []reconcileHandler[PT]
@@ -93,6 +101,11 @@ func (s *scenario[R, T, PT]) WithSchemes(schemes ...func(s *runtime.Scheme) erro
 	return s
 }
 
+func (s *scenario[R, T, PT]) WithIndex(obj client.Object, field string, extractValue client.IndexerFunc) Scenario[R, T, PT] {
+	s.indexes = append(s.indexes, index{obj: obj, field: field, extractValue: extractValue})
+	return s
+}
+
 func (s *scenario[R, T, PT]) Setup(sh func() []client.Object) _reconcileStart[T, PT] {
 	s.setupHandler = sh
 	return s
@@ -136,11 +149,14 @@ func (s *scenario[R, T, PT]) Test(t *testing.T) {
 	}
 
 	objs := s.setupHandler()
-	fakeClient := fake.NewClientBuilder().
+	builder := fake.NewClientBuilder().
 		WithScheme(scheme).
 		WithObjects(objs...).
-		WithStatusSubresource(objs...).
-		Build()
+		WithStatusSubresource(objs...)
+	for _, i := range s.indexes {
+		builder = builder.WithIndex(i.obj, i.field, i.extractValue)
+	}
+	fakeClient := builder.Build()
 
 	reconciler := s.createReconcilerWithClient(fakeClient)
 
@@ -0,0 +1,161 @@
+// Package schedule evaluates the cron-style schedules of the pools
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// The operator image may not ship the timezone database
+	_ "time/tzdata"
+)
+
+// Cron is a parsed cron expression, in the standard five fields format:
+// minute, hour, day of month, month and day of week
+type Cron struct {
+	minutes, hours, doms, months, dows uint64
+	// Whether day of month and day of week were restricted
+	domRestricted, dowRestricted bool
+}
+
+type bounds struct {
+	name     string
+	min, max int
+}
+
+var fields = []bounds{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Parse parses a cron expression. Every field supports `*`, single values,
+// ranges (`1-5`), steps (`*/15`, `0-30/10`) and comma separated lists of them
+func Parse(expr string) (*Cron, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected %d fields, found %d", expr, len(fields), len(parts))
+	}
+
+	sets := make([]uint64, len(fields))
+	for i, p := range parts {
+		set, err := parseField(p, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday can be either 0 or 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return &Cron{
+		minutes:       sets[0],
+		hours:         sets[1],
+		doms:          sets[2],
+		months:        sets[3],
+		dows:          sets[4],
+		domRestricted: parts[2] != "*",
+		dowRestricted: parts[4] != "*",
+	}, nil
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid %s step %q", b.name, stepStr)
+			}
+		}
+
+		from, to := b.min, b.max
+		if rng != "*" {
+			fromStr, toStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = strconv.Atoi(fromStr); err != nil {
+				return 0, fmt.Errorf("invalid %s %q", b.name, item)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(toStr); err != nil {
+					return 0, fmt.Errorf("invalid %s %q", b.name, item)
+				}
+			} else if hasStep {
+				// A single value with a step means up to the maximum
+				to = b.max
+			}
+		}
+		if from < b.min || to > b.max || from > to {
+			return 0, fmt.Errorf("%s %q out of the [%d, %d] range", b.name, item, b.min, b.max)
+		}
+
+		for v := from; v <= to; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// Matches returns true if the expression fires at the minute of t, in the t location
+func (c *Cron) Matches(t time.Time) bool {
+	return c.minutes&(1<<t.Minute()) != 0 && c.hours&(1<<t.Hour()) != 0 && c.months&(1<<int(t.Month())) != 0 && c.matchesDay(t)
+}
+
+func (c *Cron) matchesDay(t time.Time) bool {
+	dom := c.doms&(1<<t.Day()) != 0
+	dow := c.dows&(1<<int(t.Weekday())) != 0
+	// As in the standard cron, a day matches either field when both are restricted
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// ActiveAt returns true if t falls within duration since one of the times the
+// expression fired, evaluated in the given location
+func (c *Cron) ActiveAt(t time.Time, duration time.Duration, loc *time.Location) bool {
+	t = t.In(loc)
+	_, ok := c.Prev(t, t.Add(-duration))
+	return ok
+}
+
+// Prev returns the latest time, not after t and strictly after since, when the
+// expression fired, evaluated in the t location. The months, days and hours not
+// matching the expression are skipped as a whole
+func (c *Cron) Prev(t time.Time, since time.Time) (time.Time, bool) {
+	loc := t.Location()
+	for t = t.Truncate(time.Minute); t.After(since); {
+		var start time.Time
+		switch {
+		case c.months&(1<<int(t.Month())) == 0:
+			start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		case !c.matchesDay(t):
+			start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		case c.hours&(1<<t.Hour()) == 0:
+			start = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		case c.minutes&(1<<t.Minute()) == 0:
+			start = t
+		default:
+			return t, true
+		}
+
+		// Moves to the last minute before the skipped period. An ambiguous local
+		// time (when the clock goes back) may resolve after t, then just step back
+		previous := start.Add(-time.Minute)
+		if !previous.Before(t) {
+			previous = t.Add(-time.Minute)
+		}
+		t = previous
+	}
+	return time.Time{}, false
+}
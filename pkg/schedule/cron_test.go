@@ -0,0 +1,156 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr          string
+		expectedError string
+	}{
+		{expr: "* * * * *"},
+		{expr: "0 6 * * 1-5"},
+		{expr: "*/15 0-12/2 1,15 1-6 0,7"},
+		{expr: "0 6 * *", expectedError: `invalid cron expression "0 6 * *": expected 5 fields, found 4`},
+		{expr: "60 6 * * *", expectedError: `invalid cron expression "60 6 * * *": minute "60" out of the [0, 59] range`},
+		{expr: "0 6 0 * *", expectedError: `invalid cron expression "0 6 0 * *": day of month "0" out of the [1, 31] range`},
+		{expr: "0 6 * * 5-1", expectedError: `invalid cron expression "0 6 * * 5-1": day of week "5-1" out of the [0, 7] range`},
+		{expr: "*/0 6 * * *", expectedError: `invalid cron expression "*/0 6 * * *": invalid minute step "0"`},
+		{expr: "0 six * * *", expectedError: `invalid cron expression "0 six * * *": invalid hour "six"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	// 2026-10-19 is a Monday
+	monday := time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		time     time.Time
+		expected bool
+	}{
+		{"0 6 * * 1-5", monday, true},
+		{"0 6 * * 1-5", monday.Add(time.Minute), false},
+		{"0 6 * * 1-5", monday.AddDate(0, 0, 5), false},
+		{"0 6 * * 0", monday.AddDate(0, 0, 6), true},
+		{"0 6 * * 7", monday.AddDate(0, 0, 6), true},
+		{"*/20 * * * *", monday.Add(40 * time.Minute), true},
+		{"*/20 * * * *", monday.Add(50 * time.Minute), false},
+		{"5/20 * * * *", monday.Add(45 * time.Minute), true},
+		{"0 6 19 11 *", monday, false},
+		// Both day fields restricted, either one matches
+		{"0 6 1 * 1", monday, true},
+		{"0 6 19 * 3", monday, true},
+		{"0 6 1 * 3", monday, false},
+	}
+	for _, tt := range tests {
+		c, err := Parse(tt.expr)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, c.Matches(tt.time), "%s at %s", tt.expr, tt.time)
+	}
+}
+
+func TestActiveAt(t *testing.T) {
+	weekdays, err := Parse("0 6 * * 1-5")
+	assert.NoError(t, err)
+	rome, err := time.LoadLocation("Europe/Rome")
+	assert.NoError(t, err)
+
+	// 2026-10-19 is a Monday
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		time     time.Time
+		loc      *time.Location
+		expected bool
+	}{
+		{"before the start", monday.Add(5*time.Hour + 59*time.Minute), time.UTC, false},
+		{"at the start", monday.Add(6 * time.Hour), time.UTC, true},
+		{"within the window", monday.Add(19*time.Hour + 59*time.Minute + 59*time.Second), time.UTC, true},
+		{"at the end", monday.Add(20 * time.Hour), time.UTC, false},
+		{"saturday", monday.AddDate(0, 0, 5).Add(10 * time.Hour), time.UTC, false},
+		{"friday window", monday.AddDate(0, 0, 4).Add(10 * time.Hour), time.UTC, true},
+		{"other timezone, before the start", monday.Add(3*time.Hour + 59*time.Minute), rome, false},
+		{"other timezone, at the start", monday.Add(4 * time.Hour), rome, true},
+		{"other timezone, at the end", monday.Add(18 * time.Hour), rome, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, weekdays.ActiveAt(tt.time, 14*time.Hour, tt.loc))
+		})
+	}
+}
+
+func TestPrev(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	assert.NoError(t, err)
+	now := time.Date(2026, 10, 19, 10, 30, 45, 0, rome)
+
+	tests := []struct {
+		expr     string
+		since    time.Time
+		expected time.Time
+		found    bool
+	}{
+		{"* * * * *", now.AddDate(0, 0, -1), time.Date(2026, 10, 19, 10, 30, 0, 0, rome), true},
+		{"0 6 * * 1-5", now.AddDate(0, 0, -1), time.Date(2026, 10, 19, 6, 0, 0, 0, rome), true},
+		{"0 6 * * 6", now.AddDate(0, 0, -7), time.Date(2026, 10, 17, 6, 0, 0, 0, rome), true},
+		{"0 6 * * 6", now.AddDate(0, 0, -2), time.Time{}, false},
+		{"15 2 1 3 *", now.AddDate(-1, 0, 0), time.Date(2026, 3, 1, 2, 15, 0, 0, rome), true},
+		// Never fires
+		{"0 0 30 2 *", now.AddDate(-5, 0, 0), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := Parse(tt.expr)
+			assert.NoError(t, err)
+			prev, found := c.Prev(now, tt.since)
+			assert.Equal(t, tt.found, found)
+			assert.True(t, tt.expected.Equal(prev), "expected %s, found %s", tt.expected, prev)
+		})
+	}
+}
+
+func TestPrevMatchesEveryMinuteScan(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	assert.NoError(t, err)
+
+	for _, expr := range []string{"0 6 * * 1-5", "*/20 8-17 * * *", "0 0 1,15 * 0", "30 2 * 3,10 *"} {
+		c, err := Parse(expr)
+		assert.NoError(t, err)
+		for _, now := range []time.Time{
+			time.Date(2026, 10, 19, 10, 30, 0, 0, rome),
+			// Across the DST changes, when 02:00-02:59 happens twice or never
+			time.Date(2026, 10, 25, 2, 45, 0, 0, rome),
+			time.Date(2026, 10, 25, 2, 45, 0, 0, rome).Add(time.Hour),
+			time.Date(2026, 3, 29, 3, 5, 0, 0, rome),
+		} {
+			since := now.AddDate(0, 0, -20)
+			var expected time.Time
+			for s := now.Truncate(time.Minute); s.After(since); s = s.Add(-time.Minute) {
+				if c.Matches(s) {
+					expected = s
+					break
+				}
+			}
+			prev, found := c.Prev(now, since)
+			assert.Equal(t, !expected.IsZero(), found, "%s at %s", expr, now)
+			assert.True(t, expected.Equal(prev), "%s at %s: expected %s, found %s", expr, now, expected, prev)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 type OfcirV1Interface interface {
 	CIPools(namespace string) CIPoolInterface
 	CIResources(namespace string) CIResourceInterface
+	CILeases(namespace string) CILeaseInterface
 }
 
 type OfcirV1Client struct {
@@ -43,3 +44,10 @@ func (c *OfcirV1Client) CIResources(namespace string) CIResourceInterface {
 		ns:         namespace,
 	}
 }
+
+func (c *OfcirV1Client) CILeases(namespace string) CILeaseInterface {
+	return &cileaseClient{
+		restClient: c.restClient,
+		ns:         namespace,
+	}
+}
@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"context"
+
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+type CILeaseInterface interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*ofcirv1.CILeaseList, error)
+}
+
+type cileaseClient struct {
+	restClient rest.Interface
+	ns         string
+}
+
+func (c *cileaseClient) List(ctx context.Context, opts metav1.ListOptions) (*ofcirv1.CILeaseList, error) {
+	result := ofcirv1.CILeaseList{}
+	err := c.restClient.
+		Get().
+		Namespace(c.ns).
+		Resource("cileases").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
@@ -5,6 +5,7 @@ import (
 
 	ofcirv1 "github.com/openshift/ofcir/api/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 )
@@ -12,6 +13,7 @@ import (
 type CIPoolInterface interface {
 	List(ctx context.Context, opts metav1.ListOptions) (*ofcirv1.CIPoolList, error)
 	Get(ctx context.Context, name string, options metav1.GetOptions) (*ofcirv1.CIPool, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*ofcirv1.CIPool, error)
 }
 
 type cipoolClient struct {
@@ -45,3 +47,18 @@ func (c *cipoolClient) Get(ctx context.Context, name string, opts metav1.GetOpti
 
 	return &result, err
 }
+
+func (c *cipoolClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*ofcirv1.CIPool, error) {
+	result := ofcirv1.CIPool{}
+	err := c.restClient.
+		Patch(pt).
+		Namespace(c.ns).
+		Resource("cipools").
+		Name(name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
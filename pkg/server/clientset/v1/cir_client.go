@@ -5,6 +5,7 @@ import (
 
 	ofcirv1 "github.com/openshift/ofcir/api/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 )
@@ -17,6 +18,7 @@ type CIResourceInterface interface {
 	List(ctx context.Context, opts metav1.ListOptions) (*ofcirv1.CIResourceList, error)
 	Get(ctx context.Context, name string, opts metav1.GetOptions) (*ofcirv1.CIResource, error)
 	Update(ctx context.Context, cir *ofcirv1.CIResource, opts metav1.UpdateOptions) (*ofcirv1.CIResource, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
 }
 
 type cirClient struct {
@@ -65,3 +67,13 @@ func (c *cirClient) Update(ctx context.Context, cir *ofcirv1.CIResource, opts me
 
 	return &result, err
 }
+
+func (c *cirClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.restClient.
+		Get().
+		Namespace(c.ns).
+		Resource(resourceName).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch(ctx)
+}
@@ -12,8 +12,11 @@ import (
 	"github.com/gin-gonic/gin"
 	ofcirv1 "github.com/openshift/ofcir/api/v1"
 	ofcirclientv1 "github.com/openshift/ofcir/pkg/server/clientset/v1"
+	"github.com/openshift/ofcir/pkg/tracing"
 	"github.com/openshift/ofcir/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type acquireCmd struct {
@@ -21,6 +24,7 @@ type acquireCmd struct {
 	clientset     ofcirclientv1.OfcirV1Interface
 	namespace     string
 	resourceTypes []ofcirv1.CIResourceType
+	job           string
 }
 
 func NewAcquireCmd(c *gin.Context, clientset ofcirclientv1.OfcirV1Interface, ns string, resourceType_str string) command {
@@ -36,6 +40,7 @@ func NewAcquireCmd(c *gin.Context, clientset ofcirclientv1.OfcirV1Interface, ns
 		clientset:     clientset,
 		namespace:     ns,
 		resourceTypes: resourceTypes,
+		job:           c.Query("job"),
 	}
 }
 
@@ -54,13 +59,15 @@ const (
 )
 
 func (c *acquireCmd) Run() error {
-	overallCtx, overallCancel := context.WithTimeout(c.context.Request.Context(), overallTimeout)
-	defer overallCancel()
+	cmdCtx, span := startCommand(c.context.Request.Context(), "Acquire", attribute.String("ofcir.type", fmt.Sprint(c.resourceTypes)))
+	defer span.End()
 
-	listCtx, listCancel := context.WithTimeout(overallCtx, apiCallTimeout)
-	defer listCancel()
+	overallCtx, overallCancel := context.WithTimeout(cmdCtx, overallTimeout)
+	defer overallCancel()
 
+	listCtx, listDone := startTimedCall(overallCtx, "Acquire.ListCIPools")
 	pools, err := c.clientset.CIPools(c.namespace).List(listCtx, v1.ListOptions{})
+	listDone(err)
 	if err != nil {
 		return err
 	}
@@ -68,7 +75,11 @@ func (c *acquireCmd) Run() error {
 	poolsByName := make(map[string]ofcirv1.CIPool)
 	// c.resourceTypes is a list of cir types, no preference is given to the order
 	for _, p := range pools.Items {
-		if (contains(c.resourceTypes, p.Spec.Type)) && utils.CanUsePool(c.context, p.Name) {
+		// Acquiring from a fallback pool provisions a new resource
+		if p.IsFallbackPool() && p.IsOverBudget() {
+			continue
+		}
+		if (contains(c.resourceTypes, p.Spec.Type)) && p.IsSelectable() && utils.CanUsePool(c.context, p.Name) {
 			poolsByName[p.Name] = p
 		}
 	}
@@ -78,10 +89,9 @@ func (c *acquireCmd) Run() error {
 		return nil
 	}
 
-	cirsCtx, cirsCancel := context.WithTimeout(overallCtx, apiCallTimeout)
-	defer cirsCancel()
-
+	cirsCtx, cirsDone := startTimedCall(overallCtx, "Acquire.ListCIResources")
 	allCirs, err := c.clientset.CIResources(c.namespace).List(cirsCtx, v1.ListOptions{})
+	cirsDone(err)
 	if err != nil {
 		return err
 	}
@@ -129,10 +139,26 @@ func (c *acquireCmd) Run() error {
 		return nil
 	}
 
+	c.reportRejection(overallCtx, poolsByName)
 	c.context.String(http.StatusNotFound, "No available resource found of type %v", c.resourceTypes)
 	return nil
 }
 
+// reportRejection marks the autoscaled pools that could not satisfy the request,
+// so that the controller can grow them
+func (c *acquireCmd) reportRejection(ctx context.Context, poolsByName map[string]ofcirv1.CIPool) {
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, ofcirv1.AcquireRejectedAnnotation, time.Now().UTC().Format(time.RFC3339))
+	for _, p := range poolsByName {
+		if p.Spec.Autoscaling == nil {
+			continue
+		}
+		// Best effort, the client gets the rejection anyway
+		patchCtx, patchDone := startTimedCall(ctx, "Acquire.PatchCIPool", attribute.String("ofcir.pool", p.Name))
+		_, err := c.clientset.CIPools(c.namespace).Patch(patchCtx, p.Name, types.MergePatchType, []byte(patch), v1.PatchOptions{})
+		patchDone(err)
+	}
+}
+
 func (c *acquireCmd) lookForAvailableResource(ctx context.Context, cirs []ofcirv1.CIResource, poolsByName map[string]ofcirv1.CIPool) bool {
 	for _, r := range cirs {
 		if ctx.Err() != nil {
@@ -148,9 +174,11 @@ func (c *acquireCmd) lookForAvailableResource(ctx context.Context, cirs []ofcirv
 		if r.Spec.State != ofcirv1.StateInUse && r.Spec.State != ofcirv1.StateMaintenance {
 
 			r.Spec.State = ofcirv1.StateInUse
-			updateCtx, updateCancel := context.WithTimeout(ctx, apiCallTimeout)
+			c.setLeaseAnnotations(&r)
+			updateCtx, updateDone := startTimedCall(ctx, "Acquire.UpdateCIResource", attribute.String("ofcir.cir", r.Name))
+			tracing.Inject(updateCtx, &r)
 			_, err := c.clientset.CIResources(r.Namespace).Update(updateCtx, &r, v1.UpdateOptions{})
-			updateCancel()
+			updateDone(err)
 			if err != nil {
 				continue
 			}
@@ -170,3 +198,22 @@ func (c *acquireCmd) lookForAvailableResource(ctx context.Context, cirs []ofcirv
 
 	return false
 }
+
+// setLeaseAnnotations stores on the resource the lease details that will be
+// recorded once it gets released
+func (c *acquireCmd) setLeaseAnnotations(r *ofcirv1.CIResource) {
+	annotations := r.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[ofcirv1.LeaseAcquiredAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	annotations[ofcirv1.LeaseTokenAnnotation] = c.context.GetString("tokenfingerprint")
+	if c.job != "" {
+		annotations[ofcirv1.LeaseJobAnnotation] = c.job
+	} else {
+		delete(annotations, ofcirv1.LeaseJobAnnotation)
+	}
+
+	r.SetAnnotations(annotations)
+}
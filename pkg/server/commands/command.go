@@ -1,5 +1,25 @@
 package commands
 
+import (
+	"github.com/gin-gonic/gin"
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+)
+
+// Reported as the status of a resource handed out to a job, but then refused
+// by the pre-acquire hooks of its pool
+const acquireFailedStatus = "acquire failed"
+
 type command interface {
 	Run() error
 }
+
+// setReportedStatus adds to the response the status of the resource, as seen by
+// the job that acquired it
+func setReportedStatus(h gin.H, r *ofcirv1.CIResource) gin.H {
+	h["status"] = r.Status.State
+	if r.Status.AcquireFailed {
+		h["status"] = acquireFailedStatus
+		h["error"] = r.Status.LastError
+	}
+	return h
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -12,8 +13,14 @@ import (
 	"github.com/gin-gonic/gin"
 	ofcirv1 "github.com/openshift/ofcir/api/v1"
 	clientv1 "github.com/openshift/ofcir/pkg/server/clientset/v1"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 // --- Fakes ---
@@ -25,6 +32,8 @@ type fakeCIPoolClient struct {
 	getErr   error
 	delay    time.Duration
 	listHits int32
+	mu       sync.Mutex
+	patches  map[string]string
 }
 
 func (f *fakeCIPoolClient) List(ctx context.Context, _ metav1.ListOptions) (*ofcirv1.CIPoolList, error) {
@@ -50,6 +59,16 @@ func (f *fakeCIPoolClient) Get(ctx context.Context, name string, _ metav1.GetOpt
 	return f.pool, f.getErr
 }
 
+func (f *fakeCIPoolClient) Patch(_ context.Context, name string, _ types.PatchType, data []byte, _ metav1.PatchOptions) (*ofcirv1.CIPool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.patches == nil {
+		f.patches = make(map[string]string)
+	}
+	f.patches[name] = string(data)
+	return f.pool, nil
+}
+
 type fakeCIResourceClient struct {
 	resources  *ofcirv1.CIResourceList
 	resource   *ofcirv1.CIResource
@@ -58,6 +77,7 @@ type fakeCIResourceClient struct {
 	updateErr  error
 	delay      time.Duration
 	updateHits int32
+	watcher    *watch.FakeWatcher
 }
 
 func (f *fakeCIResourceClient) List(ctx context.Context, _ metav1.ListOptions) (*ofcirv1.CIResourceList, error) {
@@ -94,9 +114,23 @@ func (f *fakeCIResourceClient) Update(ctx context.Context, cir *ofcirv1.CIResour
 	return cir, f.updateErr
 }
 
+func (f *fakeCIResourceClient) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+	return f.watcher, nil
+}
+
+type fakeCILeaseClient struct {
+	leases  *ofcirv1.CILeaseList
+	listErr error
+}
+
+func (f *fakeCILeaseClient) List(_ context.Context, _ metav1.ListOptions) (*ofcirv1.CILeaseList, error) {
+	return f.leases, f.listErr
+}
+
 type fakeOfcirClient struct {
 	poolClient     *fakeCIPoolClient
 	resourceClient *fakeCIResourceClient
+	leaseClient    *fakeCILeaseClient
 }
 
 func (f *fakeOfcirClient) CIPools(_ string) clientv1.CIPoolInterface {
@@ -107,6 +141,10 @@ func (f *fakeOfcirClient) CIResources(_ string) clientv1.CIResourceInterface {
 	return f.resourceClient
 }
 
+func (f *fakeOfcirClient) CILeases(_ string) clientv1.CILeaseInterface {
+	return f.leaseClient
+}
+
 // --- Helpers ---
 
 func newTestGinContext(reqCtx context.Context) (*gin.Context, *httptest.ResponseRecorder) {
@@ -295,6 +333,140 @@ func isContextError(err error) bool {
 	return err == context.DeadlineExceeded || err == context.Canceled
 }
 
+func TestAcquireSkipsUnselectablePools(t *testing.T) {
+	draining := makePool("pool-draining", 0, ofcirv1.TypeCIHost)
+	draining.Status.State = ofcirv1.StatePoolDraining
+	offline := makePool("pool-offline", 0, ofcirv1.TypeCIHost)
+	offline.Status.State = ofcirv1.StatePoolOffline
+	available := makePool("pool-available", 1, ofcirv1.TypeCIHost)
+	available.Status.State = ofcirv1.StatePoolAvailable
+	overBudget := metav1.Condition{Type: ofcirv1.PoolConditionBudgetExceeded, Status: metav1.ConditionTrue, Reason: ofcirv1.ReasonBudgetExceeded}
+	fallbackOverBudget := makePool("pool-fallback", -1, ofcirv1.TypeCIHost)
+	fallbackOverBudget.Status.State = ofcirv1.StatePoolAvailable
+	fallbackOverBudget.Status.Conditions = []metav1.Condition{overBudget}
+	availableOverBudget := makePool("pool-over-budget", 1, ofcirv1.TypeCIHost)
+	availableOverBudget.Status.State = ofcirv1.StatePoolAvailable
+	availableOverBudget.Status.Conditions = []metav1.Condition{overBudget}
+
+	tests := []struct {
+		name         string
+		pools        []ofcirv1.CIPool
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "only draining and offline pools",
+			pools:        []ofcirv1.CIPool{draining, offline},
+			expectedCode: http.StatusNotFound,
+			expectedBody: "No available pool found",
+		},
+		{
+			name:         "lower priority available pool",
+			pools:        []ofcirv1.CIPool{draining, offline, available},
+			expectedCode: http.StatusOK,
+			expectedBody: `"pool":"pool-available"`,
+		},
+		{
+			name:         "fallback pool over budget",
+			pools:        []ofcirv1.CIPool{draining, fallbackOverBudget},
+			expectedCode: http.StatusNotFound,
+			expectedBody: "No available pool found",
+		},
+		{
+			name:         "provisioned resource of a pool over budget",
+			pools:        []ofcirv1.CIPool{fallbackOverBudget, availableOverBudget},
+			expectedCode: http.StatusOK,
+			expectedBody: `"pool":"pool-over-budget"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeOfcirClient{
+				poolClient: &fakeCIPoolClient{pools: &ofcirv1.CIPoolList{Items: tt.pools}},
+				resourceClient: &fakeCIResourceClient{
+					resources: &ofcirv1.CIResourceList{
+						Items: []ofcirv1.CIResource{
+							makeResource("cir-0", "pool-draining", ofcirv1.StateAvailable, ofcirv1.StateAvailable),
+							makeResource("cir-1", "pool-offline", ofcirv1.StateAvailable, ofcirv1.StateAvailable),
+							makeResource("cir-2", "pool-available", ofcirv1.StateAvailable, ofcirv1.StateAvailable),
+							makeResource("cir-3", "pool-fallback", ofcirv1.StateAvailable, ofcirv1.StateAvailable),
+							makeResource("cir-4", "pool-over-budget", ofcirv1.StateAvailable, ofcirv1.StateAvailable),
+						},
+					},
+				},
+			}
+
+			c, w := newTestGinContext(context.Background())
+			if err := NewAcquireCmd(c, client, "test-ns", string(ofcirv1.TypeCIHost)).Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Fatalf("expected body to contain %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAcquireRejectionReported(t *testing.T) {
+	autoscaled := makePool("pool-autoscaled", 0, ofcirv1.TypeCIHost)
+	autoscaled.Spec.Autoscaling = &ofcirv1.Autoscaling{MinSize: 1, MaxSize: 5}
+	fixed := makePool("pool-fixed", 0, ofcirv1.TypeCIHost)
+
+	tests := []struct {
+		name            string
+		state           ofcirv1.CIResourceState
+		expectedCode    int
+		expectedPatches []string
+	}{
+		{
+			name:         "acquired",
+			state:        ofcirv1.StateAvailable,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:            "rejected",
+			state:           ofcirv1.StateInUse,
+			expectedCode:    http.StatusNotFound,
+			expectedPatches: []string{"pool-autoscaled"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poolClient := &fakeCIPoolClient{pools: &ofcirv1.CIPoolList{Items: []ofcirv1.CIPool{autoscaled, fixed}}}
+			client := &fakeOfcirClient{
+				poolClient: poolClient,
+				resourceClient: &fakeCIResourceClient{
+					resources: &ofcirv1.CIResourceList{
+						Items: []ofcirv1.CIResource{
+							makeResource("cir-0", "pool-autoscaled", tt.state, tt.state),
+							makeResource("cir-1", "pool-fixed", tt.state, tt.state),
+						},
+					},
+				},
+			}
+
+			c, w := newTestGinContext(context.Background())
+			if err := NewAcquireCmd(c, client, "test-ns", string(ofcirv1.TypeCIHost)).Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if len(poolClient.patches) != len(tt.expectedPatches) {
+				t.Fatalf("expected patches for %v, got %v", tt.expectedPatches, poolClient.patches)
+			}
+			for _, name := range tt.expectedPatches {
+				if !strings.Contains(poolClient.patches[name], ofcirv1.AcquireRejectedAnnotation) {
+					t.Fatalf("expected pool %s to be annotated, got %q", name, poolClient.patches[name])
+				}
+			}
+		})
+	}
+}
+
 func TestConcurrentAcquireDoesNotStarve(t *testing.T) {
 	poolClient := &fakeCIPoolClient{
 		delay: 50 * time.Millisecond,
@@ -367,3 +539,338 @@ func TestConcurrentAcquireDoesNotStarve(t *testing.T) {
 	}
 	t.Logf("all %d concurrent requests completed, max duration: %v", numRequests, maxDur)
 }
+
+func makeLease(poolName, token string, acquiredAt, releasedAt time.Time) ofcirv1.CILease {
+	return ofcirv1.CILease{
+		ObjectMeta: metav1.ObjectMeta{Name: "lease", Namespace: "test-ns"},
+		Spec: ofcirv1.CILeaseSpec{
+			CIResource:       "cir-0",
+			PoolRef:          corev1.LocalObjectReference{Name: poolName},
+			Provider:         "fake",
+			Type:             ofcirv1.TypeCIHost,
+			TokenFingerprint: token,
+			AcquiredAt:       metav1.NewTime(acquiredAt),
+			ReleasedAt:       metav1.NewTime(releasedAt),
+		},
+	}
+}
+
+func TestUsage(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	leaseClient := &fakeCILeaseClient{
+		leases: &ofcirv1.CILeaseList{
+			Items: []ofcirv1.CILease{
+				makeLease("pool-1", "aaa", base.Add(1*time.Hour), base.Add(3*time.Hour)),
+				makeLease("pool-1", "bbb", base.Add(4*time.Hour), base.Add(5*time.Hour)),
+				// Only the last hour falls within the requested period
+				makeLease("pool-2", "aaa", base.Add(-2*time.Hour), base.Add(1*time.Hour)),
+				// Outside of the requested period
+				makeLease("pool-2", "bbb", base.Add(-5*time.Hour), base.Add(-4*time.Hour)),
+			},
+		},
+	}
+	client := &fakeOfcirClient{leaseClient: leaseClient}
+
+	tests := []struct {
+		name         string
+		groupBy      string
+		format       string
+		from         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "group by pool",
+			groupBy:      "pool",
+			format:       "json",
+			from:         "2026-01-01",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"from":"2026-01-01T00:00:00Z","groupBy":"pool","to":"2026-01-02T00:00:00Z","usage":[{"key":"pool-1","hours":3,"leases":2},{"key":"pool-2","hours":1,"leases":1}]}`,
+		},
+		{
+			name:         "group by token as csv",
+			groupBy:      "token",
+			format:       "csv",
+			from:         "2026-01-01T00:00:00Z",
+			expectedCode: http.StatusOK,
+			expectedBody: "token,hours,leases\naaa,3.00,2\nbbb,1.00,1\n",
+		},
+		{
+			name:         "invalid group",
+			groupBy:      "color",
+			format:       "json",
+			from:         "2026-01-01",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid period",
+			groupBy:      "pool",
+			format:       "json",
+			from:         "2026-01-03",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newTestGinContext(context.Background())
+			cmd := NewUsageCmd(c, client, "test-ns", tt.from, "2026-01-02", tt.groupBy, tt.format)
+
+			if err := cmd.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Fatalf("unexpected body: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	provisioning := makeResource("cir-0", "pool-1", ofcirv1.StateInUse, ofcirv1.StateProvisioningWait)
+	inUse := makeResource("cir-0", "pool-1", ofcirv1.StateInUse, ofcirv1.StateInUse)
+	inUse.Status.Address = "192.168.1.1"
+	refused := makeResource("cir-0", "pool-1", ofcirv1.StateMaintenance, ofcirv1.StateMaintenance)
+	refused.Status.AcquireFailed = true
+	refused.Status.LastError = "pre-acquire hook test failed: boom"
+
+	// A fallback resource is acquired before being provisioned
+	fallback := func(state ofcirv1.CIResourceState) *ofcirv1.CIResource {
+		r := makeResource("cir-0", "pool-1", ofcirv1.StateInUse, state)
+		r.Status.ResourceId = ofcirv1.FallbackResourceID
+		return r.DeepCopy()
+	}
+	fallbackInUse := inUse.DeepCopy()
+	fallbackInUse.Status.ResourceId = "i-0123456789"
+
+	tests := []struct {
+		name           string
+		validPools     string
+		until          string
+		initial        *ofcirv1.CIResource
+		events         []watch.Event
+		expectedCode   int
+		expectedEvents []string
+	}{
+		{
+			name:       "stream until deleted",
+			validPools: "*",
+			events: []watch.Event{
+				// Not relevant changes are skipped
+				{Type: watch.Modified, Object: provisioning.DeepCopy()},
+				{Type: watch.Modified, Object: inUse.DeepCopy()},
+				{Type: watch.Deleted, Object: inUse.DeepCopy()},
+			},
+			expectedCode: http.StatusOK,
+			expectedEvents: []string{
+				`event:status
+data:{"extra":"","ip":"","name":"cir-0","pool":"pool-1","providerInfo":"","status":"provisioning wait","type":"host"}`,
+				`event:status
+data:{"extra":"","ip":"192.168.1.1","name":"cir-0","pool":"pool-1","providerInfo":"","status":"in use","type":"host"}`,
+				`event:deleted
+data:{"name":"cir-0"}`,
+			},
+		},
+		{
+			name:       "stream until in use",
+			validPools: "*",
+			until:      string(ofcirv1.StateInUse),
+			events: []watch.Event{
+				{Type: watch.Modified, Object: inUse.DeepCopy()},
+			},
+			expectedCode: http.StatusOK,
+			expectedEvents: []string{
+				`event:status
+data:{"extra":"","ip":"","name":"cir-0","pool":"pool-1","providerInfo":"","status":"provisioning wait","type":"host"}`,
+				`event:status
+data:{"extra":"","ip":"192.168.1.1","name":"cir-0","pool":"pool-1","providerInfo":"","status":"in use","type":"host"}`,
+			},
+		},
+		{
+			name:       "stream until acquire refused",
+			validPools: "*",
+			until:      string(ofcirv1.StateInUse),
+			events: []watch.Event{
+				{Type: watch.Modified, Object: refused.DeepCopy()},
+			},
+			expectedCode: http.StatusOK,
+			expectedEvents: []string{
+				`event:status
+data:{"extra":"","ip":"","name":"cir-0","pool":"pool-1","providerInfo":"","status":"provisioning wait","type":"host"}`,
+				`event:status
+data:{"error":"pre-acquire hook test failed: boom","extra":"","ip":"","name":"cir-0","pool":"pool-1","providerInfo":"","status":"acquire failed","type":"host"}`,
+			},
+		},
+		{
+			name:       "stream until fallback resource in use",
+			validPools: "*",
+			until:      string(ofcirv1.StateInUse),
+			initial:    fallback(ofcirv1.StateAvailable),
+			events: []watch.Event{
+				{Type: watch.Modified, Object: fallback(ofcirv1.StateInUse)},
+				{Type: watch.Modified, Object: fallback(ofcirv1.StateProvisioning)},
+				{Type: watch.Modified, Object: fallback(ofcirv1.StateProvisioningWait)},
+				{Type: watch.Modified, Object: fallbackInUse},
+			},
+			expectedCode: http.StatusOK,
+			expectedEvents: []string{
+				`event:status
+data:{"extra":"","ip":"","name":"cir-0","pool":"pool-1","providerInfo":"","status":"available","type":"host"}`,
+				`event:status
+data:{"extra":"","ip":"","name":"cir-0","pool":"pool-1","providerInfo":"","status":"in use","type":"host"}`,
+				`event:status
+data:{"extra":"","ip":"","name":"cir-0","pool":"pool-1","providerInfo":"","status":"provisioning","type":"host"}`,
+				`event:status
+data:{"extra":"","ip":"","name":"cir-0","pool":"pool-1","providerInfo":"","status":"provisioning wait","type":"host"}`,
+				`event:status
+data:{"extra":"","ip":"192.168.1.1","name":"cir-0","pool":"pool-1","providerInfo":"","status":"in use","type":"host"}`,
+			},
+		},
+		{
+			name:         "pool not allowed",
+			validPools:   "pool-2",
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watcher := watch.NewFakeWithChanSize(len(tt.events), false)
+			for _, e := range tt.events {
+				watcher.Action(e.Type, e.Object)
+			}
+			initial := tt.initial
+			if initial == nil {
+				initial = provisioning.DeepCopy()
+			}
+			client := &fakeOfcirClient{
+				resourceClient: &fakeCIResourceClient{
+					resource: initial,
+					watcher:  watcher,
+				},
+			}
+
+			c, w := newTestGinContext(context.Background())
+			c.Set("validpools", tt.validPools)
+			cmd := NewWatchCmd(c, client, "test-ns", "cir-0", tt.until)
+
+			if err := cmd.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedEvents != nil {
+				expectedBody := strings.Join(tt.expectedEvents, "\n\n") + "\n\n"
+				if w.Body.String() != expectedBody {
+					t.Fatalf("unexpected body:\n%s", w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	now := metav1.Now()
+
+	allowedPool := makePool("pool-1", 0, ofcirv1.TypeCIHost)
+	allowedPool.Spec.Timeout = metav1.Duration{Duration: 4 * time.Hour}
+	// Scaled up by a schedule
+	allowedPool.Spec.Size = 2
+	allowedPool.Status.Size = 3
+	allowedPool.Status.EffectiveSize = 5
+	// Not yet reconciled by the controller
+	newPool := makePool("pool-2", 0, ofcirv1.TypeCIHost)
+	newPool.Spec.Size = 4
+	hiddenPool := makePool("pool-hidden", 0, ofcirv1.TypeCIHost)
+
+	acquired := metav1.NewTime(now.Add(-time.Hour))
+	inUse := makeResource("cir-0", "pool-1", ofcirv1.StateInUse, ofcirv1.StateInUse)
+	inUse.Status.StateChanged = &acquired
+	inUse.Status.LastUpdated = &now
+	inUse.Annotations = map[string]string{
+		ofcirv1.LeaseJobAnnotation:   "periodic-e2e/1234",
+		ofcirv1.LeaseTokenAnnotation: "5e884898da28",
+	}
+	inError := makeResource("cir-1", "pool-1", ofcirv1.StateAvailable, ofcirv1.StateError)
+	inError.Status.ResourceId = "broken-resource"
+	hiddenInUse := makeResource("cir-2", "pool-hidden", ofcirv1.StateInUse, ofcirv1.StateInUse)
+	hiddenInUse.Annotations = map[string]string{
+		ofcirv1.LeaseJobAnnotation: "hidden-job",
+	}
+
+	client := &fakeOfcirClient{
+		poolClient: &fakeCIPoolClient{
+			pools: &ofcirv1.CIPoolList{Items: []ofcirv1.CIPool{allowedPool, newPool, hiddenPool}},
+		},
+		resourceClient: &fakeCIResourceClient{
+			resources: &ofcirv1.CIResourceList{Items: []ofcirv1.CIResource{inUse, inError, hiddenInUse}},
+		},
+	}
+
+	c, w := newTestGinContext(context.Background())
+	c.Set("validpools", "pool-1,pool-2")
+	cmd := NewDashboardCmd(c, client, "test-ns")
+
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := w.Body.String()
+	for _, expected := range []string{"pool-1", "<td>3/5</td>", "<td>0/4</td>", "cir-0", "periodic-e2e/1234", "5e884898da28", "3h0m0s", "cir-1", "broken-resource"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected dashboard to contain %q", expected)
+		}
+	}
+	for _, unexpected := range []string{"pool-hidden", "cir-2", "hidden-job"} {
+		if strings.Contains(body, unexpected) {
+			t.Errorf("expected dashboard to not contain %q", unexpected)
+		}
+	}
+}
+
+func TestCommandSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	client := &fakeOfcirClient{
+		poolClient: &fakeCIPoolClient{pools: &ofcirv1.CIPoolList{Items: []ofcirv1.CIPool{makePool("pool-1", 0, ofcirv1.TypeCIHost)}}},
+		resourceClient: &fakeCIResourceClient{
+			resources: &ofcirv1.CIResourceList{
+				Items: []ofcirv1.CIResource{makeResource("cir-0", "pool-1", ofcirv1.StateAvailable, ofcirv1.StateAvailable)},
+			},
+		},
+	}
+	c, w := newTestGinContext(context.Background())
+	if err := NewAcquireCmd(c, client, "test-ns", string(ofcirv1.TypeCIHost)).Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	spans := recorder.Ended()
+	var names []string
+	var command sdktrace.ReadOnlySpan
+	for _, s := range spans {
+		names = append(names, s.Name())
+		if s.Name() == "Acquire" {
+			command = s
+		}
+	}
+	expected := []string{"Acquire.ListCIPools", "Acquire.ListCIResources", "Acquire.UpdateCIResource", "Acquire"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected spans %v, got %v", expected, names)
+	}
+	for _, s := range spans[:len(spans)-1] {
+		if s.Parent().SpanID() != command.SpanContext().SpanID() {
+			t.Fatalf("expected span %s to be a child of the command span", s.Name())
+		}
+	}
+}
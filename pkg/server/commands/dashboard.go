@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"html/template"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	ofcirclientv1 "github.com/openshift/ofcir/pkg/server/clientset/v1"
+	"github.com/openshift/ofcir/pkg/utils"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Max number of resources in error reported by the dashboard
+	maxDashboardErrors = 20
+)
+
+//go:embed dashboard.html
+var dashboardHTML string
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Minute).String()
+	},
+}).Parse(dashboardHTML))
+
+// The states reported in the per-pool counts, in display order
+var dashboardStates = []ofcirv1.CIResourceState{
+	ofcirv1.StateProvisioning,
+	ofcirv1.StateProvisioningWait,
+	ofcirv1.StateAvailable,
+	ofcirv1.StateInUse,
+	ofcirv1.StateMaintenance,
+	ofcirv1.StateCleaning,
+	ofcirv1.StateCleaningWait,
+	ofcirv1.StateDelete,
+	ofcirv1.StateError,
+}
+
+type dashboardPool struct {
+	ofcirv1.CIPool
+	// The number of resources the pool is required to have
+	TargetSize int
+	Counts     []int
+}
+
+type dashboardResource struct {
+	ofcirv1.CIResource
+	Job           string
+	Token         string
+	AcquiredAt    string
+	TimeRemaining time.Duration
+}
+
+type dashboardData struct {
+	Namespace   string
+	GeneratedAt string
+	States      []ofcirv1.CIResourceState
+	Pools       []dashboardPool
+	InUse       []dashboardResource
+	Errors      []dashboardResource
+}
+
+type dashboardCmd struct {
+	context   *gin.Context
+	clientset ofcirclientv1.OfcirV1Interface
+	namespace string
+}
+
+// NewDashboardCmd renders an HTML overview of the pools and resources
+// accessible by the current token
+func NewDashboardCmd(c *gin.Context, clientset ofcirclientv1.OfcirV1Interface, ns string) command {
+	return &dashboardCmd{
+		context:   c,
+		clientset: clientset,
+		namespace: ns,
+	}
+}
+
+func (c *dashboardCmd) Run() error {
+	cmdCtx, span := startCommand(c.context.Request.Context(), "Dashboard")
+	defer span.End()
+
+	overallCtx, overallCancel := context.WithTimeout(cmdCtx, overallTimeout)
+	defer overallCancel()
+
+	poolsCtx, poolsDone := startTimedCall(overallCtx, "Dashboard.ListCIPools")
+	pools, err := c.clientset.CIPools(c.namespace).List(poolsCtx, v1.ListOptions{})
+	poolsDone(err)
+	if err != nil {
+		return err
+	}
+
+	cirsCtx, cirsDone := startTimedCall(overallCtx, "Dashboard.ListCIResources")
+	cirs, err := c.clientset.CIResources(c.namespace).List(cirsCtx, v1.ListOptions{})
+	cirsDone(err)
+	if err != nil {
+		return err
+	}
+
+	data := c.buildData(pools.Items, cirs.Items, time.Now())
+
+	buf := &bytes.Buffer{}
+	if err := dashboardTemplate.Execute(buf, data); err != nil {
+		return err
+	}
+
+	c.context.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+	return nil
+}
+
+func (c *dashboardCmd) buildData(pools []ofcirv1.CIPool, cirs []ofcirv1.CIResource, now time.Time) dashboardData {
+	data := dashboardData{
+		Namespace:   c.namespace,
+		GeneratedAt: now.UTC().Format(time.RFC3339),
+		States:      dashboardStates,
+	}
+
+	stateIndex := make(map[ofcirv1.CIResourceState]int)
+	for i, s := range dashboardStates {
+		stateIndex[s] = i
+	}
+
+	poolsByName := make(map[string]*dashboardPool)
+	for _, p := range pools {
+		if !utils.CanUsePool(c.context, p.Name) {
+			continue
+		}
+		// The size driven by the schedules and the autoscaler, once computed by the controller
+		targetSize := p.Status.EffectiveSize
+		if targetSize == 0 {
+			targetSize = p.Spec.Size
+		}
+		data.Pools = append(data.Pools, dashboardPool{CIPool: p, TargetSize: targetSize, Counts: make([]int, len(dashboardStates))})
+	}
+	sort.Slice(data.Pools, func(i, j int) bool {
+		return data.Pools[i].Name < data.Pools[j].Name
+	})
+	for i := range data.Pools {
+		poolsByName[data.Pools[i].Name] = &data.Pools[i]
+	}
+
+	for _, r := range cirs {
+		pool, ok := poolsByName[r.Spec.PoolRef.Name]
+		if !ok {
+			continue
+		}
+		if i, ok := stateIndex[r.Status.State]; ok {
+			pool.Counts[i]++
+		}
+
+		switch r.Status.State {
+		case ofcirv1.StateInUse:
+			data.InUse = append(data.InUse, newDashboardResource(r, pool.Spec.Timeout.Duration, now))
+		case ofcirv1.StateError:
+			data.Errors = append(data.Errors, newDashboardResource(r, 0, now))
+		}
+	}
+
+	sort.Slice(data.InUse, func(i, j int) bool {
+		return data.InUse[i].TimeRemaining < data.InUse[j].TimeRemaining
+	})
+
+	// Most recent errors first
+	sort.Slice(data.Errors, func(i, j int) bool {
+		return lastUpdated(data.Errors[i].CIResource).After(lastUpdated(data.Errors[j].CIResource))
+	})
+	if len(data.Errors) > maxDashboardErrors {
+		data.Errors = data.Errors[:maxDashboardErrors]
+	}
+
+	return data
+}
+
+func newDashboardResource(r ofcirv1.CIResource, timeout time.Duration, now time.Time) dashboardResource {
+	annotations := r.GetAnnotations()
+	dr := dashboardResource{
+		CIResource: r,
+		Job:        annotations[ofcirv1.LeaseJobAnnotation],
+		Token:      annotations[ofcirv1.LeaseTokenAnnotation],
+		AcquiredAt: annotations[ofcirv1.LeaseAcquiredAnnotation],
+	}
+
+	if since := r.StateSince(); timeout > 0 && !since.IsZero() {
+		dr.TimeRemaining = max(timeout-now.Sub(since), 0)
+	}
+	return dr
+}
+
+func lastUpdated(r ofcirv1.CIResource) time.Time {
+	if r.Status.LastUpdated == nil {
+		return time.Time{}
+	}
+	return r.Status.LastUpdated.Time
+}
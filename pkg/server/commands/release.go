@@ -8,7 +8,9 @@ import (
 	"github.com/gin-gonic/gin"
 	ofcirv1 "github.com/openshift/ofcir/api/v1"
 	ofcirclientv1 "github.com/openshift/ofcir/pkg/server/clientset/v1"
+	"github.com/openshift/ofcir/pkg/tracing"
 	"github.com/openshift/ofcir/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -30,13 +32,15 @@ func NewReleaseCmd(c *gin.Context, clientset ofcirclientv1.OfcirV1Interface, ns
 }
 
 func (c *releaseCmd) Run() error {
-	overallCtx, overallCancel := context.WithTimeout(c.context.Request.Context(), overallTimeout)
-	defer overallCancel()
+	cmdCtx, span := startCommand(c.context.Request.Context(), "Release", attribute.String("ofcir.cir", c.cirName))
+	defer span.End()
 
-	getCtx, getCancel := context.WithTimeout(overallCtx, apiCallTimeout)
-	defer getCancel()
+	overallCtx, overallCancel := context.WithTimeout(cmdCtx, overallTimeout)
+	defer overallCancel()
 
+	getCtx, getDone := startTimedCall(overallCtx, "Release.GetCIResource")
 	r, err := c.clientset.CIResources(c.namespace).Get(getCtx, c.cirName, v1.GetOptions{})
+	getDone(err)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			c.context.JSON(http.StatusBadRequest, gin.H{
@@ -55,9 +59,10 @@ func (c *releaseCmd) Run() error {
 	switch r.Status.State {
 	case ofcirv1.StateInUse:
 		r.Spec.State = ofcirv1.StateAvailable
-		updateCtx, updateCancel := context.WithTimeout(overallCtx, apiCallTimeout)
-		defer updateCancel()
+		updateCtx, updateDone := startTimedCall(overallCtx, "Release.UpdateCIResource")
+		tracing.Inject(updateCtx, r)
 		_, err := c.clientset.CIResources(r.Namespace).Update(updateCtx, r, v1.UpdateOptions{})
+		updateDone(err)
 		if err != nil {
 			return err
 		}
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	ofcirclientv1 "github.com/openshift/ofcir/pkg/server/clientset/v1"
 	"github.com/openshift/ofcir/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -29,13 +30,15 @@ func NewStatusCmd(c *gin.Context, clientset ofcirclientv1.OfcirV1Interface, ns s
 }
 
 func (c *statusCmd) Run() error {
-	overallCtx, overallCancel := context.WithTimeout(c.context.Request.Context(), overallTimeout)
-	defer overallCancel()
+	cmdCtx, span := startCommand(c.context.Request.Context(), "Status", attribute.String("ofcir.cir", c.cirName))
+	defer span.End()
 
-	getCtx, getCancel := context.WithTimeout(overallCtx, apiCallTimeout)
-	defer getCancel()
+	overallCtx, overallCancel := context.WithTimeout(cmdCtx, overallTimeout)
+	defer overallCancel()
 
+	getCtx, getDone := startTimedCall(overallCtx, "Status.GetCIResource")
 	r, err := c.clientset.CIResources(c.namespace).Get(getCtx, c.cirName, v1.GetOptions{})
+	getDone(err)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			c.context.JSON(http.StatusBadRequest, gin.H{
@@ -51,10 +54,9 @@ func (c *statusCmd) Run() error {
 		return nil
 	}
 
-	poolCtx, poolCancel := context.WithTimeout(overallCtx, apiCallTimeout)
-	defer poolCancel()
-
+	poolCtx, poolDone := startTimedCall(overallCtx, "Status.GetCIPool", attribute.String("ofcir.pool", r.Spec.PoolRef.Name))
 	pool, err := c.clientset.CIPools(c.namespace).Get(poolCtx, r.Spec.PoolRef.Name, v1.GetOptions{})
+	poolDone(err)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			c.context.JSON(http.StatusBadRequest, gin.H{
@@ -65,7 +67,7 @@ func (c *statusCmd) Run() error {
 		return err
 	}
 
-	c.context.JSON(http.StatusOK, gin.H{
+	c.context.JSON(http.StatusOK, setReportedStatus(gin.H{
 		"name":         r.Name,
 		"pool":         pool.Name,
 		"provider":     pool.Spec.Provider,
@@ -73,8 +75,7 @@ func (c *statusCmd) Run() error {
 		"type":         r.Spec.Type,
 		"ip":           r.Status.Address,
 		"extra":        r.Status.Extra,
-		"status":       r.Status.State,
-	})
+	}, r))
 
 	return nil
 }
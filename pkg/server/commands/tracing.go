@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/openshift/ofcir/pkg/server/commands")
+
+// startCommand starts the span covering a whole command, as a child of the request one
+func startCommand(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// startCall starts the span of a Kubernetes API call made by a command
+func startCall(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// startTimedCall starts the span of a Kubernetes API call made by a command, limited
+// to apiCallTimeout. The returned function must be invoked with the call result
+func startTimedCall(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, func(error)) {
+	ctx, cancel := context.WithTimeout(ctx, apiCallTimeout)
+	ctx, span := startCall(ctx, name, attrs...)
+	return ctx, func(err error) {
+		endCall(span, err)
+		cancel()
+	}
+}
+
+func endCall(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	ofcirclientv1 "github.com/openshift/ofcir/pkg/server/clientset/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultUsagePeriod = 30 * 24 * time.Hour
+)
+
+// Supported keys for grouping the usage records
+var usageGroupKeys = map[string]func(l ofcirv1.CILease) string{
+	"pool":     func(l ofcirv1.CILease) string { return l.Spec.PoolRef.Name },
+	"provider": func(l ofcirv1.CILease) string { return l.Spec.Provider },
+	"token":    func(l ofcirv1.CILease) string { return l.Spec.TokenFingerprint },
+}
+
+type usageEntry struct {
+	Key    string  `json:"key"`
+	Hours  float64 `json:"hours"`
+	Leases int     `json:"leases"`
+}
+
+type usageCmd struct {
+	context   *gin.Context
+	clientset ofcirclientv1.OfcirV1Interface
+	namespace string
+	from      string
+	to        string
+	groupBy   string
+	format    string
+}
+
+func NewUsageCmd(c *gin.Context, clientset ofcirclientv1.OfcirV1Interface, ns string, from string, to string, groupBy string, format string) command {
+	return &usageCmd{
+		context:   c,
+		clientset: clientset,
+		namespace: ns,
+		from:      from,
+		to:        to,
+		groupBy:   groupBy,
+		format:    format,
+	}
+}
+
+func (c *usageCmd) Run() error {
+	to, err := parseUsageTime(c.to, time.Now())
+	if err != nil {
+		c.context.JSON(http.StatusBadRequest, gin.H{"msg": fmt.Sprintf("invalid `to` value: %s", err)})
+		return nil
+	}
+	from, err := parseUsageTime(c.from, to.Add(-defaultUsagePeriod))
+	if err != nil {
+		c.context.JSON(http.StatusBadRequest, gin.H{"msg": fmt.Sprintf("invalid `from` value: %s", err)})
+		return nil
+	}
+	if !from.Before(to) {
+		c.context.JSON(http.StatusBadRequest, gin.H{"msg": "`from` must be before `to`"})
+		return nil
+	}
+
+	keyOf, ok := usageGroupKeys[c.groupBy]
+	if !ok {
+		c.context.JSON(http.StatusBadRequest, gin.H{"msg": fmt.Sprintf("invalid `groupBy` value: %s", c.groupBy)})
+		return nil
+	}
+
+	if c.format != "json" && c.format != "csv" {
+		c.context.JSON(http.StatusBadRequest, gin.H{"msg": fmt.Sprintf("invalid `format` value: %s", c.format)})
+		return nil
+	}
+
+	cmdCtx, span := startCommand(c.context.Request.Context(), "Usage")
+	defer span.End()
+
+	listCtx, listDone := startTimedCall(cmdCtx, "Usage.ListCILeases")
+	leases, err := c.clientset.CILeases(c.namespace).List(listCtx, v1.ListOptions{})
+	listDone(err)
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string]*usageEntry)
+	for _, l := range leases.Items {
+		d := l.InUseWithin(from, to)
+		if d == 0 {
+			continue
+		}
+
+		key := keyOf(l)
+		if _, ok := entries[key]; !ok {
+			entries[key] = &usageEntry{Key: key}
+		}
+		entries[key].Hours += d.Hours()
+		entries[key].Leases++
+	}
+
+	usage := make([]usageEntry, 0, len(entries))
+	for _, e := range entries {
+		usage = append(usage, *e)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Key < usage[j].Key
+	})
+
+	if c.format == "csv" {
+		return c.writeCSV(usage)
+	}
+
+	c.context.JSON(http.StatusOK, gin.H{
+		"from":    from.UTC().Format(time.RFC3339),
+		"to":      to.UTC().Format(time.RFC3339),
+		"groupBy": c.groupBy,
+		"usage":   usage,
+	})
+	return nil
+}
+
+func (c *usageCmd) writeCSV(usage []usageEntry) error {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+
+	records := [][]string{{c.groupBy, "hours", "leases"}}
+	for _, u := range usage {
+		records = append(records, []string{u.Key, strconv.FormatFloat(u.Hours, 'f', 2, 64), strconv.Itoa(u.Leases)})
+	}
+	if err := w.WriteAll(records); err != nil {
+		return err
+	}
+
+	c.context.Data(http.StatusOK, "text/csv", buf.Bytes())
+	return nil
+}
+
+// parseUsageTime accepts either a RFC3339 timestamp or a plain date
+func parseUsageTime(value string, defaultValue time.Time) (time.Time, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
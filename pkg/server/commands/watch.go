@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	ofcirv1 "github.com/openshift/ofcir/api/v1"
+	ofcirclientv1 "github.com/openshift/ofcir/pkg/server/clientset/v1"
+	"github.com/openshift/ofcir/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// Max duration of a single stream, clients are expected to reconnect if needed
+	watchTimeout = 1 * time.Hour
+	// Interval for sending a comment line, to keep the connection alive through proxies
+	watchKeepAlive = 15 * time.Second
+)
+
+type watchCmd struct {
+	context   *gin.Context
+	clientset ofcirclientv1.OfcirV1Interface
+	namespace string
+	cirName   string
+	until     ofcirv1.CIResourceState
+}
+
+// NewWatchCmd streams the status changes of a CIR as Server-Sent Events. If until
+// is specified, the stream is closed as soon as the CIR reaches such state
+func NewWatchCmd(c *gin.Context, clientset ofcirclientv1.OfcirV1Interface, ns string, cirName string, until string) command {
+	return &watchCmd{
+		context:   c,
+		clientset: clientset,
+		namespace: ns,
+		cirName:   cirName,
+		until:     ofcirv1.CIResourceState(until),
+	}
+}
+
+func (c *watchCmd) Run() error {
+	cmdCtx, span := startCommand(c.context.Request.Context(), "Watch", attribute.String("ofcir.cir", c.cirName))
+	defer span.End()
+
+	overallCtx, overallCancel := context.WithTimeout(cmdCtx, watchTimeout)
+	defer overallCancel()
+
+	getCtx, getDone := startTimedCall(overallCtx, "Watch.GetCIResource")
+	r, err := c.clientset.CIResources(c.namespace).Get(getCtx, c.cirName, v1.GetOptions{})
+	getDone(err)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.context.JSON(http.StatusBadRequest, gin.H{
+				"msg": fmt.Sprintf("%s does not exist in namespace %s", c.cirName, c.namespace),
+			})
+			return nil
+		}
+		return err
+	}
+
+	if !utils.CanUsePool(c.context, r.Spec.PoolRef.Name) {
+		c.context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "401 Unauthorized"})
+		return nil
+	}
+
+	// Start watching from the retrieved version, so that no change could be missed.
+	// The watch outlives its span, which covers only the stream setup
+	_, watchSpan := startCall(overallCtx, "Watch.WatchCIResource")
+	watcher, err := c.clientset.CIResources(c.namespace).Watch(overallCtx, v1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", c.cirName).String(),
+		ResourceVersion: r.ResourceVersion,
+	})
+	endCall(watchSpan, err)
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	c.context.Header("Cache-Control", "no-cache")
+	c.context.Header("Connection", "keep-alive")
+	c.context.Header("X-Accel-Buffering", "no")
+	c.context.Status(http.StatusOK)
+
+	last := c.sendStatus(r)
+	if c.isDone(r) {
+		return nil
+	}
+
+	keepAlive := time.NewTicker(watchKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-overallCtx.Done():
+			return nil
+
+		case <-keepAlive.C:
+			fmt.Fprint(c.context.Writer, ": keep-alive\n\n")
+			c.context.Writer.Flush()
+
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil
+			}
+
+			switch event.Type {
+			case watch.Modified:
+				r, ok := event.Object.(*ofcirv1.CIResource)
+				if !ok {
+					continue
+				}
+				// Notify only the changes relevant for the clients
+				if r.Status.State != last.Status.State || r.Status.Address != last.Status.Address || r.Status.AcquireFailed != last.Status.AcquireFailed {
+					last = c.sendStatus(r)
+				}
+				if c.isDone(r) {
+					return nil
+				}
+
+			case watch.Deleted:
+				c.context.SSEvent("deleted", gin.H{"name": c.cirName})
+				c.context.Writer.Flush()
+				return nil
+
+			case watch.Error:
+				c.context.SSEvent("error", gin.H{"msg": "watch interrupted"})
+				c.context.Writer.Flush()
+				return nil
+			}
+		}
+	}
+}
+
+func (c *watchCmd) sendStatus(r *ofcirv1.CIResource) *ofcirv1.CIResource {
+	c.context.SSEvent("status", setReportedStatus(gin.H{
+		"name":         r.Name,
+		"pool":         r.Spec.PoolRef.Name,
+		"providerInfo": r.Status.ProviderInfo,
+		"type":         r.Spec.Type,
+		"ip":           r.Status.Address,
+		"extra":        r.Status.Extra,
+	}, r))
+	c.context.Writer.Flush()
+	return r
+}
+
+// isDone returns true once the resource reached the requested state. A refused
+// acquisition ends the stream too, since the resource is never going to be in use
+func (c *watchCmd) isDone(r *ofcirv1.CIResource) bool {
+	if c.until == "" {
+		return false
+	}
+	if r.Status.AcquireFailed {
+		return true
+	}
+	if r.Status.State != c.until {
+		return false
+	}
+	// A fallback resource is in use before being provisioned, so it's
+	// ready only once it got its address
+	if c.until == ofcirv1.StateInUse {
+		return r.Status.Address != "" && r.Status.ResourceId != ofcirv1.FallbackResourceID
+	}
+	return true
+}
@@ -4,36 +4,59 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/openshift/ofcir/pkg/server/commands"
+	"github.com/openshift/ofcir/pkg/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
-	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	listerv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 
 	ofcirv1 "github.com/openshift/ofcir/api/v1"
 	ofcirclientv1 "github.com/openshift/ofcir/pkg/server/clientset/v1"
 )
 
+var tracer = otel.Tracer("github.com/openshift/ofcir/pkg/server")
+
+// Every namespace holds its own tokens, granting access to its own pools
+const tokensSecretName = "ofcir-tokens"
+
+// How long to wait for the initial load of the tokens secrets
+const tokensSyncTimeout = 30 * time.Second
+
 type OfcirAPI struct {
 	config    *rest.Config
 	clientset *ofcirclientv1.OfcirV1Client
-	corev1    corev1.CoreV1Interface
 	router    *gin.Engine
+	// The cached tokens secrets, one lister per served namespace (or a
+	// single one for all of them)
+	tokenListers []listerv1.SecretLister
 
-	port      string
-	namespace string
+	port string
+	// The namespaces served by the API, empty for all of them
+	namespaces []string
 }
 
-func NewOfcirAPI(port string, namespace string) *OfcirAPI {
+func NewOfcirAPI(port string, namespaces []string) *OfcirAPI {
 	return &OfcirAPI{
-		port:      port,
-		namespace: namespace,
+		port:       port,
+		namespaces: namespaces,
 	}
 }
 
@@ -69,35 +92,163 @@ func (o *OfcirAPI) Init(kubeconfig string) error {
 	if err != nil {
 		panic(err.Error())
 	}
-	o.corev1 = kubeclient.CoreV1()
+	// The API keeps serving until the process exits
+	o.tokenListers, err = newTokenListers(kubeclient, o.namespaces, wait.NeverStop)
+	if err != nil {
+		return err
+	}
 
 	// Setup the server
 	r := gin.Default()
+	r.Use(o.Tracing())
 	r.Group("/v1").Use(o.AuthRequired()).
 		GET("/ofcir/:cirName", o.handleGetCirStatus).
+		GET("/ofcir/:cirName/watch", o.handleWatchCir).
 		POST("/ofcir", o.handleAcquireCir).
 		DELETE("/ofcir/:cirName", o.handleReleaseCir)
+	r.Group("/v1/admin").Use(o.AuthRequired(), o.AdminRequired()).
+		GET("/usage", o.handleGetUsage)
+	r.Group("/dashboard").Use(o.BrowserAuthRequired()).
+		GET("", o.handleGetDashboard)
 
 	o.router = r
 	return nil
 }
 
 func (o *OfcirAPI) AuthRequired() gin.HandlerFunc {
+	return o.authRequired(false)
+}
+
+// BrowserAuthRequired is like AuthRequired, but it also accepts the token as the
+// basic auth password, so that the browsers could prompt for it
+func (o *OfcirAPI) BrowserAuthRequired() gin.HandlerFunc {
+	return o.authRequired(true)
+}
+
+func (o *OfcirAPI) authRequired(allowBasicAuth bool) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		authCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
-		defer cancel()
-		tokens, err := o.corev1.Secrets("ofcir-system").Get(authCtx, "ofcir-tokens", metav1.GetOptions{})
-		tokenheader := ctx.Request.Header["X-Ofcirtoken"]
-		if (err != nil) || (len(tokenheader) == 0) {
+		unauthorized := func() {
+			if allowBasicAuth {
+				ctx.Header("WWW-Authenticate", `Basic realm="ofcir"`)
+			}
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "401 Unauthorized"})
+		}
+
+		tokenheader := ctx.Request.Header["X-Ofcirtoken"]
+		if allowBasicAuth && len(tokenheader) == 0 {
+			if _, password, ok := ctx.Request.BasicAuth(); ok {
+				tokenheader = []string{password}
+			}
+		}
+		if len(tokenheader) == 0 {
+			unauthorized()
 			return
 		}
 
-		if string(tokens.Data[tokenheader[0]]) == "" {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "401 Unauthorized"})
+		namespace, pools, err := o.lookupToken(tokenheader[0])
+		if err != nil || pools == "" {
+			unauthorized()
+			return
+		}
+		ctx.Set("namespace", namespace)
+		ctx.Set("validpools", pools)
+		ctx.Set("tokenfingerprint", utils.TokenFingerprint(tokenheader[0]))
+	}
+}
+
+// newTokenListers starts an informer on the tokens secret of every served
+// namespace, or of all the namespaces when none is configured, and waits
+// for their initial sync
+func newTokenListers(kubeclient kubernetes.Interface, namespaces []string, stopCh <-chan struct{}) ([]listerv1.SecretLister, error) {
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	var listers []listerv1.SecretLister
+	var synced []cache.InformerSynced
+	for _, ns := range namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(kubeclient, 0,
+			informers.WithNamespace(ns),
+			informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+				opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", tokensSecretName).String()
+			}))
+		secrets := factory.Core().V1().Secrets()
+		listers = append(listers, secrets.Lister())
+		synced = append(synced, secrets.Informer().HasSynced)
+		factory.Start(stopCh)
+	}
+
+	syncCtx, cancel := context.WithTimeout(context.Background(), tokensSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), synced...) {
+		return nil, fmt.Errorf("timed out waiting for the %s secrets to be loaded", tokensSecretName)
+	}
+	return listers, nil
+}
+
+// lookupToken searches the token in the cached tokens secret of the served
+// namespaces, returning the namespace it belongs to and the pools it grants
+// access to. Tokens are expected to be unique: if more than one namespace holds
+// the same token, the first one (in the configured order, or alphabetically when
+// serving all the namespaces) is used
+func (o *OfcirAPI) lookupToken(token string) (string, string, error) {
+	for _, lister := range o.tokenListers {
+		secrets, err := lister.List(labels.Everything())
+		if err != nil {
+			return "", "", err
+		}
+		sort.Slice(secrets, func(i, j int) bool {
+			return secrets[i].Namespace < secrets[j].Namespace
+		})
+
+		for _, secret := range secrets {
+			if secret.Name != tokensSecretName {
+				continue
+			}
+			if pools := strings.TrimSpace(string(secret.Data[token])); pools != "" {
+				return secret.Namespace, pools, nil
+			}
+		}
+	}
+	return "", "", nil
+}
+
+// Tracing starts a server span for every request, continuing the trace
+// propagated by the caller if any
+func (o *OfcirAPI) Tracing() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		reqCtx := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = ctx.Request.URL.Path
+		}
+		reqCtx, span := tracer.Start(reqCtx, fmt.Sprintf("%s %s", ctx.Request.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", ctx.Request.Method),
+				attribute.String("http.route", route),
+			))
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// AdminRequired allows only the tokens having access to all the pools
+func (o *OfcirAPI) AdminRequired() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !utils.IsAdmin(ctx) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"msg": "403 Forbidden"})
 			return
 		}
-		ctx.Set("validpools", strings.TrimSpace(string(tokens.Data[tokenheader[0]])))
 	}
 }
 
@@ -113,7 +264,17 @@ func (o *OfcirAPI) Run() {
 
 func (o *OfcirAPI) handleGetCirStatus(c *gin.Context) {
 	cirName := c.Param("cirName")
-	cmd := commands.NewStatusCmd(c, o.clientset, o.namespace, cirName)
+	cmd := commands.NewStatusCmd(c, o.clientset, utils.Namespace(c), cirName)
+	if err := cmd.Run(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"msg": err.Error(),
+		})
+	}
+}
+
+func (o *OfcirAPI) handleWatchCir(c *gin.Context) {
+	cirName := c.Param("cirName")
+	cmd := commands.NewWatchCmd(c, o.clientset, utils.Namespace(c), cirName, c.Query("until"))
 	if err := cmd.Run(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"msg": err.Error(),
@@ -123,7 +284,7 @@ func (o *OfcirAPI) handleGetCirStatus(c *gin.Context) {
 
 func (o *OfcirAPI) handleAcquireCir(c *gin.Context) {
 	resourceType := c.DefaultQuery("type", string(ofcirv1.TypeCIHost))
-	cmd := commands.NewAcquireCmd(c, o.clientset, o.namespace, resourceType)
+	cmd := commands.NewAcquireCmd(c, o.clientset, utils.Namespace(c), resourceType)
 	if err := cmd.Run(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"msg": err.Error(),
@@ -133,7 +294,25 @@ func (o *OfcirAPI) handleAcquireCir(c *gin.Context) {
 
 func (o *OfcirAPI) handleReleaseCir(c *gin.Context) {
 	cirName := c.Param("cirName")
-	cmd := commands.NewReleaseCmd(c, o.clientset, o.namespace, cirName)
+	cmd := commands.NewReleaseCmd(c, o.clientset, utils.Namespace(c), cirName)
+	if err := cmd.Run(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"msg": err.Error(),
+		})
+	}
+}
+
+func (o *OfcirAPI) handleGetUsage(c *gin.Context) {
+	cmd := commands.NewUsageCmd(c, o.clientset, utils.Namespace(c), c.Query("from"), c.Query("to"), c.DefaultQuery("groupBy", "pool"), c.DefaultQuery("format", "json"))
+	if err := cmd.Run(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"msg": err.Error(),
+		})
+	}
+}
+
+func (o *OfcirAPI) handleGetDashboard(c *gin.Context) {
+	cmd := commands.NewDashboardCmd(c, o.clientset, utils.Namespace(c))
 	if err := cmd.Run(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"msg": err.Error(),
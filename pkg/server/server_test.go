@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/ofcir/pkg/utils"
+)
+
+func tokensSecret(namespace string, tokens map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: tokensSecretName, Namespace: namespace},
+		Data:       map[string][]byte{},
+	}
+	for token, pools := range tokens {
+		secret.Data[token] = []byte(pools)
+	}
+	return secret
+}
+
+func TestAuthRequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	kubeclient := fake.NewClientset(
+		tokensSecret("team-a", map[string]string{"token-a": "*", "shared": "cipool-a"}),
+		tokensSecret("team-b", map[string]string{"token-b": "cipool-b\n", "shared": "cipool-b"}),
+		tokensSecret("team-c", map[string]string{"token-c": "*"}),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-c"}, Data: map[string][]byte{"token-x": []byte("*")}},
+	)
+
+	cases := []struct {
+		name              string
+		namespaces        []string
+		token             string
+		expectedStatus    int
+		expectedNamespace string
+		expectedPools     string
+	}{
+		{
+			name:              "token of the first namespace",
+			namespaces:        []string{"team-a", "team-b"},
+			token:             "token-a",
+			expectedStatus:    http.StatusOK,
+			expectedNamespace: "team-a",
+			expectedPools:     "*",
+		},
+		{
+			name:              "token of the second namespace",
+			namespaces:        []string{"team-a", "team-b"},
+			token:             "token-b",
+			expectedStatus:    http.StatusOK,
+			expectedNamespace: "team-b",
+			expectedPools:     "cipool-b",
+		},
+		{
+			name:           "token of a namespace not served",
+			namespaces:     []string{"team-a", "team-b"},
+			token:          "token-c",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:              "namespace without tokens",
+			namespaces:        []string{"team-d", "team-b"},
+			token:             "token-b",
+			expectedStatus:    http.StatusOK,
+			expectedNamespace: "team-b",
+			expectedPools:     "cipool-b",
+		},
+		{
+			name:              "duplicated token",
+			namespaces:        []string{"team-b", "team-a"},
+			token:             "shared",
+			expectedStatus:    http.StatusOK,
+			expectedNamespace: "team-b",
+			expectedPools:     "cipool-b",
+		},
+		{
+			name:              "all namespaces",
+			token:             "token-c",
+			expectedStatus:    http.StatusOK,
+			expectedNamespace: "team-c",
+			expectedPools:     "*",
+		},
+		{
+			name:           "only the tokens secret is used",
+			token:          "token-x",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewOfcirAPI("8087", tc.namespaces)
+			stop := make(chan struct{})
+			defer close(stop)
+			var err error
+			o.tokenListers, err = newTokenListers(kubeclient, tc.namespaces, stop)
+			assert.NoError(t, err)
+
+			var namespace, pools string
+			r := gin.New()
+			r.GET("/", o.AuthRequired(), func(c *gin.Context) {
+				namespace = utils.Namespace(c)
+				pools = c.GetString("validpools")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.token != "" {
+				req.Header.Set("X-OFCIRTOKEN", tc.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedNamespace, namespace)
+			assert.Equal(t, tc.expectedPools, pools)
+		})
+	}
+}
+
+func TestAuthRequiredTokenAdded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	kubeclient := fake.NewClientset(tokensSecret("team-a", map[string]string{"token-a": "*"}))
+	o := NewOfcirAPI("8087", []string{"team-a"})
+	stop := make(chan struct{})
+	defer close(stop)
+	var err error
+	o.tokenListers, err = newTokenListers(kubeclient, o.namespaces, stop)
+	assert.NoError(t, err)
+
+	r := gin.New()
+	r.GET("/", o.AuthRequired(), func(c *gin.Context) {})
+	status := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-OFCIRTOKEN", "token-new")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, status())
+
+	// The tokens are read from the cache, kept in sync with the secret
+	_, err = kubeclient.CoreV1().Secrets("team-a").Update(context.TODO(),
+		tokensSecret("team-a", map[string]string{"token-a": "*", "token-new": "cipool-a"}), metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return status() == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,124 @@
+package tracing
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Prefix used for storing the trace context in the object annotations
+	annotationPrefix = "ofcir.openshift/"
+)
+
+// Options defines how the traces are exported
+type Options struct {
+	// The OTLP gRPC collector endpoint, tracing is disabled when empty
+	Endpoint string
+	// Disable the transport security for the collector connection
+	Insecure bool
+	// Fraction of the new traces to be sampled
+	SampleRatio float64
+}
+
+// BindFlags adds the tracing flags to the specified FlagSet. The default values
+// are taken from the standard OpenTelemetry environment variables, if defined
+func (o *Options) BindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Endpoint, "otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		"The OTLP gRPC endpoint (host:port or URL) where traces are sent. Tracing is disabled when empty")
+	fs.BoolVar(&o.Insecure, "otlp-insecure", os.Getenv("OTEL_EXPORTER_OTLP_INSECURE") == "true",
+		"Disable TLS for the OTLP endpoint connection")
+	fs.Float64Var(&o.SampleRatio, "trace-sample-ratio", 1.0,
+		"Fraction of the new traces to be sampled, between 0 and 1")
+}
+
+// Setup configures the global tracer provider and propagator. The returned function
+// must be invoked to flush the pending spans before exiting
+func Setup(ctx context.Context, serviceName string, opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporterOpts := []otlptracegrpc.Option{}
+	if strings.Contains(opts.Endpoint, "://") {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithEndpointURL(opts.Endpoint))
+	} else {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithEndpoint(opts.Endpoint))
+	}
+	if opts.Insecure {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// annotationCarrier allows to store a trace context in the annotations of an object
+type annotationCarrier struct {
+	obj metav1.Object
+}
+
+func (a annotationCarrier) Get(key string) string {
+	return a.obj.GetAnnotations()[annotationPrefix+key]
+}
+
+func (a annotationCarrier) Set(key string, value string) {
+	annotations := a.obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[annotationPrefix+key] = value
+	a.obj.SetAnnotations(annotations)
+}
+
+func (a annotationCarrier) Keys() []string {
+	keys := []string{}
+	for k := range a.obj.GetAnnotations() {
+		if strings.HasPrefix(k, annotationPrefix) {
+			keys = append(keys, strings.TrimPrefix(k, annotationPrefix))
+		}
+	}
+	return keys
+}
+
+// Inject stores the trace context of ctx into the object annotations
+func Inject(ctx context.Context, obj metav1.Object) {
+	otel.GetTextMapPropagator().Inject(ctx, annotationCarrier{obj: obj})
+}
+
+// Extract returns a copy of ctx with the trace context stored in the
+// object annotations, if any
+func Extract(ctx context.Context, obj metav1.Object) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, annotationCarrier{obj: obj})
+}
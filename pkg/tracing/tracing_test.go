@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInjectExtract(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03},
+		SpanID:     trace.SpanID{0x04, 0x05},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	obj := &metav1.ObjectMeta{
+		Annotations: map[string]string{"other": "value"},
+	}
+	Inject(ctx, obj)
+
+	assert.Contains(t, obj.Annotations, "ofcir.openshift/traceparent")
+	assert.Equal(t, "value", obj.Annotations["other"])
+
+	extracted := trace.SpanContextFromContext(Extract(context.Background(), obj))
+	assert.Equal(t, sc.TraceID(), extracted.TraceID())
+	assert.Equal(t, sc.SpanID(), extracted.SpanID())
+	assert.True(t, extracted.IsRemote())
+}
+
+func TestExtractWithoutAnnotations(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	ctx := Extract(context.Background(), &metav1.ObjectMeta{})
+	assert.False(t, trace.SpanContextFromContext(ctx).IsValid())
+}
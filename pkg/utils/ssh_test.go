@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/ofcir/pkg/utils/sshtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSSHCommand(t *testing.T) {
+	srv, err := sshtest.NewServer(func(command string) (string, int) {
+		if command == "false" {
+			return "failed\n", 1
+		}
+		return "ok\n", 0
+	})
+	assert.NoError(t, err)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	out, err := RunSSHCommand(ctx, srv.Host, srv.Port, "root", srv.PrivateKey, "true")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok\n", out)
+
+	out, err = RunSSHCommand(ctx, srv.Host, srv.Port, "root", srv.PrivateKey, "false")
+	assert.Error(t, err)
+	assert.Equal(t, "failed\n", out)
+
+	assert.Equal(t, []string{"true", "false"}, srv.Commands())
+}
+
+func TestRunSSHCommandWrongKey(t *testing.T) {
+	srv, err := sshtest.NewServer(func(command string) (string, int) { return "", 0 })
+	assert.NoError(t, err)
+	defer srv.Close()
+
+	other, err := sshtest.NewServer(func(command string) (string, int) { return "", 0 })
+	assert.NoError(t, err)
+	other.Close()
+
+	_, err = RunSSHCommand(context.TODO(), srv.Host, srv.Port, "root", other.PrivateKey, "true")
+	assert.Error(t, err)
+	assert.Empty(t, srv.Commands())
+
+	_, err = RunSSHCommand(context.TODO(), srv.Host, srv.Port, "root", []byte("not a key"), "true")
+	assert.ErrorContains(t, err, "invalid ssh private key")
+}
@@ -0,0 +1,159 @@
+// Package sshtest provides an in-process SSH server for testing the code
+// running commands on remote hosts
+package sshtest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"net"
+	"strconv"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Handler runs the command received by the server, returning its output and
+// exit status
+type Handler func(command string) (string, int)
+
+// Server is an SSH server listening on the loopback interface, accepting only
+// the clients authenticated with PrivateKey
+type Server struct {
+	// Host and Port the server is listening on
+	Host string
+	Port int
+	// The PEM encoded private key accepted by the server
+	PrivateKey []byte
+
+	listener net.Listener
+	config   *ssh.ServerConfig
+	handler  Handler
+
+	mu       sync.Mutex
+	commands []string
+}
+
+// NewServer starts a new server, using the handler to run the received commands
+func NewServer(handler Handler) (*Server, error) {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientPub, clientKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	block, err := ssh.MarshalPrivateKey(clientKey, "")
+	if err != nil {
+		return nil, err
+	}
+	authorizedKey, err := ssh.NewPublicKey(clientPub)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		PrivateKey: pem.EncodeToMemory(block),
+		handler:    handler,
+	}
+	s.config = &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(authorizedKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, &ssh.BannerError{Message: "unknown public key"}
+		},
+	}
+	s.config.AddHostKey(hostSigner)
+
+	s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	s.Host = host
+	s.Port, _ = strconv.Atoi(port)
+
+	go s.serve()
+	return s, nil
+}
+
+// Commands returns the commands received so far
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.commands...)
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	sshConn, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sshConn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleSession(channel, requests)
+	}
+}
+
+func (s *Server) handleSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+
+	for req := range requests {
+		if req.Type != "exec" {
+			req.Reply(false, nil)
+			continue
+		}
+		var payload struct{ Command string }
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			req.Reply(false, nil)
+			continue
+		}
+		req.Reply(true, nil)
+
+		s.mu.Lock()
+		s.commands = append(s.commands, payload.Command)
+		s.mu.Unlock()
+
+		out, status := s.handler(payload.Command)
+		channel.Write([]byte(out))
+
+		exitStatus := make([]byte, 4)
+		binary.BigEndian.PutUint32(exitStatus, uint32(status))
+		channel.SendRequest("exit-status", false, exitStatus)
+		return
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net"
 	"strings"
@@ -30,6 +32,36 @@ func CanUsePool(context *gin.Context, pool string) bool {
 	return contains(validpools, pool)
 }
 
+// Namespace returns the namespace the current token belongs to
+func Namespace(context *gin.Context) string {
+	return context.GetString("namespace")
+}
+
+// ParseNamespaces splits a comma separated list of namespaces. An empty list
+// stands for all the namespaces
+func ParseNamespaces(value string) []string {
+	var namespaces []string
+	for _, ns := range strings.Split(value, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" && !contains(namespaces, ns) {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+// IsAdmin returns true if the current token grants access to all the pools
+func IsAdmin(context *gin.Context) bool {
+	v, _ := context.Get("validpools")
+	return v == "*"
+}
+
+// TokenFingerprint returns a short identifier for the given token, that can
+// be safely stored without disclosing the token itself
+func TokenFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
 func IsPortOpen(ip string, port string) bool {
 	conn, _ := net.DialTimeout("tcp", net.JoinHostPort(ip, port), time.Second*5)
 	if conn != nil {
@@ -122,19 +122,26 @@ func deployOfcirOperator(ctx context.Context, cfg *envconf.Config) (context.Cont
 	log.Println("Waiting for ofcir operator to be ready")
 	r := cfg.Client().Resources(ofcirNamespace)
 
-	err := wait.For(
-		conditions.New(r).ResourceListMatchN(&v1.PodList{}, 1, func(object k8s.Object) bool {
-			pod := object.(*v1.Pod)
-			for _, cond := range pod.Status.Conditions {
-				if cond.Type == v1.PodReady && cond.Status == v1.ConditionTrue {
-					return true
+	var err error
+	// The operator and the API run in separate deployments
+	for _, selector := range []string{"control-plane=controller-manager", "control-plane=api"} {
+		err = wait.For(
+			conditions.New(r).ResourceListMatchN(&v1.PodList{}, 1, func(object k8s.Object) bool {
+				pod := object.(*v1.Pod)
+				for _, cond := range pod.Status.Conditions {
+					if cond.Type == v1.PodReady && cond.Status == v1.ConditionTrue {
+						return true
+					}
 				}
-			}
-			return false
-		}, resources.WithLabelSelector("control-plane=controller-manager")),
-		wait.WithTimeout(180*time.Second),
-		wait.WithInterval(5*time.Second),
-	)
+				return false
+			}, resources.WithLabelSelector(selector)),
+			wait.WithTimeout(180*time.Second),
+			wait.WithInterval(5*time.Second),
+		)
+		if err != nil {
+			break
+		}
+	}
 	if err != nil {
 		var pods v1.PodList
 		if listErr := r.List(context.Background(), &pods); listErr == nil {
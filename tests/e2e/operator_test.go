@@ -16,21 +16,29 @@ import (
 
 func TestOperatorDeploymentWithoutKubeRBACProxy(t *testing.T) {
 	testenv.Test(t, features.New("operator deployment").
-		Assess("controller pod runs operator and api only", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
-			pod := getControllerPod(t, cfg)
-
-			containerNames := make([]string, len(pod.Spec.Containers))
-			for i, c := range pod.Spec.Containers {
-				containerNames[i] = c.Name
-			}
-			assert.ElementsMatch(t, []string{"ofcir-operator", "ofcir-api"}, containerNames)
-			for _, name := range containerNames {
-				assert.NotEqual(t, "kube-rbac-proxy", name)
+		Assess("operator and api pods run a single container each", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			for selector, container := range map[string]string{
+				"control-plane=controller-manager": "ofcir-operator",
+				"control-plane=api":                "ofcir-api",
+			} {
+				pod := getPod(t, cfg, selector)
+
+				containerNames := make([]string, len(pod.Spec.Containers))
+				for i, c := range pod.Spec.Containers {
+					containerNames[i] = c.Name
+				}
+				assert.ElementsMatch(t, []string{container}, containerNames)
+
+				for _, cs := range pod.Status.ContainerStatuses {
+					assert.True(t, cs.Ready, "container %s is not ready", cs.Name)
+				}
 			}
 
-			for _, cs := range pod.Status.ContainerStatuses {
-				assert.True(t, cs.Ready, "container %s is not ready", cs.Name)
-			}
+			return ctx
+		}).
+		Assess("operator and api use their own service accounts", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			assert.Equal(t, "ofcir-controller-manager", getPod(t, cfg, "control-plane=controller-manager").Spec.ServiceAccountName)
+			assert.Equal(t, "ofcir-api", getPod(t, cfg, "control-plane=api").Spec.ServiceAccountName)
 
 			return ctx
 		}).
@@ -77,7 +85,7 @@ func TestAPILifecycleOverHTTP(t *testing.T) {
 		Feature())
 }
 
-func getControllerPod(t *testing.T, cfg *envconf.Config) *v1.Pod {
+func getPod(t *testing.T, cfg *envconf.Config, selector string) *v1.Pod {
 	t.Helper()
 
 	var pods v1.PodList
@@ -85,7 +93,7 @@ func getControllerPod(t *testing.T, cfg *envconf.Config) *v1.Pod {
 	err := r.List(
 		context.Background(),
 		&pods,
-		resources.WithLabelSelector("control-plane=controller-manager"),
+		resources.WithLabelSelector(selector),
 	)
 	require.NoError(t, err)
 	require.Len(t, pods.Items, 1)
@@ -107,6 +115,9 @@ func TestDeploymentSpecMatchesPostProxyRemovalExpectations(t *testing.T) {
 			require.NotEmpty(t, operatorContainer.Ports)
 			assert.Equal(t, int32(8443), operatorContainer.Ports[0].ContainerPort)
 
+			err = cfg.Client().Resources().Get(ctx, "ofcir-api", ofcirNamespace, &deploy)
+			require.NoError(t, err)
+
 			apiContainer := findContainer(t, &deploy, "ofcir-api")
 			require.NotEmpty(t, apiContainer.Ports)
 			assert.Equal(t, int32(8087), apiContainer.Ports[0].ContainerPort)